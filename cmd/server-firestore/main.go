@@ -8,6 +8,7 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/mamiri/findyourroot/internal/config"
 	"github.com/mamiri/findyourroot/internal/database"
 	"github.com/mamiri/findyourroot/internal/handlers"
 	"github.com/mamiri/findyourroot/internal/middleware"
@@ -19,6 +20,12 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// Fail fast on a bad BCRYPT_COST or TOKEN_TTL rather than at the first login
+	authConfig, err := config.LoadAuth()
+	if err != nil {
+		log.Fatalf("Invalid auth configuration: %v", err)
+	}
+
 	ctx := context.Background()
 
 	// Initialize Firestore
@@ -29,7 +36,7 @@ func main() {
 	defer client.Close()
 
 	// Initialize Firestore handlers
-	authHandler := handlers.NewFirestoreAuthHandler(client)
+	authHandler := handlers.NewFirestoreAuthHandler(client, authConfig)
 	treeHandler := handlers.NewFirestoreTreeHandler(client)
 	searchHandler := handlers.NewFirestoreSearchHandler(client)
 	exportHandler := handlers.NewFirestoreExportHandler(client)
@@ -37,6 +44,12 @@ func main() {
 	suggestionHandler := handlers.NewFirestoreSuggestionHandler(client)
 	sseHandler := handlers.NewSSEHandler(client)
 
+	// Periodically cleans up references to deleted people and users
+	handlers.StartIntegritySweep(client)
+
+	// Rejects tree writes while the tree is in read-only mode
+	writable := handlers.RequireWritableTree(client)
+
 	// Setup Gin router
 	router := gin.Default()
 
@@ -62,8 +75,12 @@ func main() {
 		// Public routes
 		auth := v1.Group("/auth")
 		{
-			auth.POST("/login", authHandler.Login)
+			auth.POST("/login", middleware.LoginRateLimit(), authHandler.Login)
 			auth.POST("/register", authHandler.Register)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/logout", authHandler.Logout)
 		}
 
 		// Semi-protected routes (requires valid token)
@@ -71,70 +88,138 @@ func main() {
 		authProtected.Use(middleware.AuthMiddleware())
 		{
 			authProtected.GET("/validate", authHandler.ValidateToken)
-			authProtected.POST("/request-permission", authHandler.RequestPermission)
+			authProtected.POST("/request-permission", middleware.BlockImpersonation(), authHandler.RequestPermission)
+			authProtected.POST("/change-password", middleware.BlockImpersonation(), authHandler.ChangePassword)
+			authProtected.POST("/impersonation/end", authHandler.EndImpersonation)
+		}
+
+		// Family trees
+		trees := v1.Group("/trees")
+		trees.Use(middleware.AuthMiddleware())
+		{
+			trees.GET("", authHandler.ListTrees)
+			trees.POST("", middleware.RequireAdmin(), middleware.BlockImpersonation(), authHandler.CreateTree)
+		}
+
+		// Current-user self-service routes
+		me := v1.Group("/me")
+		me.Use(middleware.AuthMiddleware())
+		{
+			me.POST("/reverify", middleware.BlockImpersonation(), authHandler.ReverifyMe)
+			me.POST("/onboarding/dismiss", middleware.BlockImpersonation(), authHandler.DismissOnboarding)
+			me.GET("/notifications", authHandler.GetNotificationPrefs)
+			me.PUT("/notifications", middleware.BlockImpersonation(), authHandler.UpdateNotificationPrefs)
+			me.GET("/person/suggestions", suggestionHandler.GetMyPersonSuggestions)
 		}
 
 		// Identity claim routes (authenticated users)
 		identity := v1.Group("/identity")
 		identity.Use(middleware.AuthMiddleware())
 		{
-			identity.POST("/claim", identityClaimHandler.ClaimIdentity)
+			identity.POST("/claim", middleware.BlockImpersonation(), identityClaimHandler.ClaimIdentity)
 			identity.GET("/my-claim", identityClaimHandler.GetMyIdentityClaim)
-			identity.PUT("/my-instagram", identityClaimHandler.UpdateMyInstagram) // User updates their own Instagram
+			identity.PUT("/my-instagram", middleware.BlockImpersonation(), writable, identityClaimHandler.UpdateMyInstagram) // User updates their own Instagram
 		}
 
 		// Admin routes
 		admin := v1.Group("/admin")
-		admin.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+		admin.Use(middleware.AuthMiddleware(), middleware.RequireAdmin(), middleware.BlockImpersonation())
 		{
 			admin.GET("/permission-requests", authHandler.GetPermissionRequests)
+			admin.POST("/invites", authHandler.CreateInvite)
 			admin.POST("/permission-requests/:id/approve", authHandler.ApprovePermissionRequest)
 			admin.POST("/permission-requests/:id/reject", authHandler.RejectPermissionRequest)
+			admin.POST("/permission-requests/clear", authHandler.ClearPermissionRequests)
+			admin.DELETE("/person/:person_id/instagram", writable, identityClaimHandler.ClearPersonInstagram)
+			admin.POST("/identity/transfer", writable, identityClaimHandler.TransferIdentityLink)
+			admin.GET("/activity/by-reviewer/:id", suggestionHandler.GetReviewerActivity)
+			admin.POST("/tree/normalize-names", writable, treeHandler.NormalizeTreeNames)
+			admin.POST("/tree/normalize-characters", writable, treeHandler.NormalizeTreeCharacters)
+			admin.POST("/tree/infer-genders", writable, treeHandler.InferGenders)
+			admin.POST("/tree/repair-relationships", writable, treeHandler.RepairRelationships)
+			admin.POST("/integrity/sweep", writable, treeHandler.RunIntegritySweep)
+			admin.GET("/integrity/report", treeHandler.GetIntegrityReport)
+			admin.POST("/integrity/recount-likes", writable, treeHandler.RecountLikes)
+			admin.POST("/tree/:id/sanity-check", treeHandler.SanityCheckPerson)
+			admin.GET("/tree/date-issues", treeHandler.GetDateIssues)
+			admin.GET("/tree/incomplete", treeHandler.GetIncompletePeople)
+			admin.POST("/tree/import/csv", writable, treeHandler.ImportCSV)
+			admin.POST("/tree/snapshots", treeHandler.CreateSnapshot)
+			admin.GET("/tree/snapshots", treeHandler.GetSnapshots)
+			admin.GET("/tree/snapshots/:id/diff", treeHandler.DiffSnapshot)
+			admin.GET("/tree/deleted", treeHandler.GetDeletedPeople)
+			admin.PUT("/tree/settings", treeHandler.UpdateTreeSettings)
+			admin.POST("/tree/deleted/:id/restore", writable, treeHandler.RestoreDeletedPerson)
+		}
+
+		// Deployment-wide overview (super-admin only)
+		superAdmin := v1.Group("/admin/trees")
+		superAdmin.Use(middleware.AuthMiddleware(), middleware.RequireSuperAdmin(), middleware.BlockImpersonation())
+		{
+			superAdmin.GET("/overview", treeHandler.GetTreesOverview)
 		}
 
 		// User management routes (admin only)
 		userMgmt := v1.Group("/admin/users")
-		userMgmt.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+		userMgmt.Use(middleware.AuthMiddleware(), middleware.RequireAdmin(), middleware.BlockImpersonation())
 		{
 			userMgmt.GET("", authHandler.GetAllUsers)
+			userMgmt.POST("", writable, authHandler.CreateUser)
+			userMgmt.GET("/pending-verification", authHandler.GetPendingVerificationUsers)
+			userMgmt.POST("/merge", writable, authHandler.MergeUsers)
 			userMgmt.PUT("/:id/role", authHandler.UpdateUserRole)
+			userMgmt.DELETE("/:id", writable, authHandler.DeleteUser)
 			userMgmt.DELETE("/:id/access", authHandler.RevokeUserAccess)
+			userMgmt.POST("/:id/reverify", authHandler.ReverifyUser)
+			userMgmt.POST("/:id/unlock", authHandler.UnlockUser)
+			userMgmt.POST("/:id/impersonate", authHandler.ImpersonateUser)
 		}
 
 		// Admin identity claim routes
 		adminIdentity := v1.Group("/admin/identity-claims")
-		adminIdentity.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+		adminIdentity.Use(middleware.AuthMiddleware(), middleware.RequireAdmin(), middleware.BlockImpersonation())
 		{
 			adminIdentity.GET("", identityClaimHandler.GetIdentityClaims)
-			adminIdentity.POST("/:id/review", identityClaimHandler.ReviewIdentityClaim)
-			adminIdentity.DELETE("/unlink/:user_id", identityClaimHandler.UnlinkIdentity)
+			adminIdentity.POST("/:id/review", writable, identityClaimHandler.ReviewIdentityClaim)
+			adminIdentity.GET("/conflicts", identityClaimHandler.GetClaimConflicts)
+			adminIdentity.POST("/conflicts/:person_id/resolve", writable, identityClaimHandler.ResolveClaimConflict)
+			adminIdentity.POST("/clear", writable, identityClaimHandler.ClearIdentityClaims)
+			adminIdentity.DELETE("/unlink/:user_id", writable, identityClaimHandler.UnlinkIdentity)
 		}
 
 		// Admin-only routes for linking users to tree nodes (co-admin can self-link)
 		adminLink := v1.Group("/admin")
-		adminLink.Use(middleware.AuthMiddleware(), middleware.RequireApprover())
+		adminLink.Use(middleware.AuthMiddleware(), middleware.RequireApprover(), middleware.BlockImpersonation())
 		{
-			adminLink.POST("/link-user-to-person", identityClaimHandler.LinkUserToPerson)
-			adminLink.PUT("/person/:person_id/instagram", identityClaimHandler.UpdatePersonInstagram)
+			adminLink.POST("/link-user-to-person", writable, identityClaimHandler.LinkUserToPerson)
+			adminLink.PUT("/person/:person_id/instagram", writable, identityClaimHandler.UpdatePersonInstagram)
+			adminLink.POST("/person/:person_id/enrich-instagram", writable, identityClaimHandler.EnrichPersonInstagram)
 			adminLink.GET("/instagram/lookup", identityClaimHandler.LookupInstagramProfile)
+			adminLink.GET("/tree/linked", identityClaimHandler.GetLinkedPeople)
+			adminLink.POST("/tree/:id/verify-data", writable, treeHandler.VerifyPersonData)
+			adminLink.DELETE("/tree/:id/verify-data", writable, treeHandler.ClearPersonDataVerification)
 		}
 
 		// Suggestion routes (for contributors)
 		suggestions := v1.Group("/suggestions")
 		suggestions.Use(middleware.AuthMiddleware())
 		{
-			suggestions.POST("", middleware.RequireContributor(), suggestionHandler.CreateSuggestion)
+			suggestions.POST("", middleware.RequireContributor(), middleware.BlockImpersonation(), writable, handlers.RequireIdentityLink(client), suggestionHandler.CreateSuggestion)
 			suggestions.GET("/my", suggestionHandler.GetMySuggestions)
 		}
 
 		// Admin/co-admin can view all suggestions and review them
 		suggestionsAdmin := v1.Group("/admin/suggestions")
-		suggestionsAdmin.Use(middleware.AuthMiddleware(), middleware.RequireApprover())
+		suggestionsAdmin.Use(middleware.AuthMiddleware(), middleware.RequireApprover(), middleware.BlockImpersonation())
 		{
 			suggestionsAdmin.GET("", suggestionHandler.GetAllSuggestions)
 			suggestionsAdmin.GET("/grouped", suggestionHandler.GetGroupedSuggestions)
-			suggestionsAdmin.POST("/:id/review", suggestionHandler.ReviewSuggestion)
-			suggestionsAdmin.POST("/batch-review", suggestionHandler.BatchReviewSuggestions)
+			suggestionsAdmin.GET("/conflicts/export", suggestionHandler.ExportSuggestionConflicts)
+			suggestionsAdmin.POST("/:id/review", writable, suggestionHandler.ReviewSuggestion)
+			suggestionsAdmin.POST("/:id/assign", suggestionHandler.AssignSuggestion)
+			suggestionsAdmin.DELETE("/:id/assign", suggestionHandler.UnassignSuggestion)
+			suggestionsAdmin.POST("/batch-review", writable, suggestionHandler.BatchReviewSuggestions)
+			suggestionsAdmin.POST("/clear", middleware.RequireAdmin(), writable, suggestionHandler.ClearSuggestions)
 		}
 
 		// Tree routes - split by permission level
@@ -142,10 +227,27 @@ func main() {
 		treePublic.Use(middleware.AuthMiddleware())
 		{
 			treePublic.GET("", treeHandler.GetAllPeople)
+			treePublic.GET("/branch-sizes", treeHandler.GetBranchSizes)
+			treePublic.GET("/genders", treeHandler.GetGenderCounts)
+			treePublic.GET("/analytics", treeHandler.GetTreeAnalytics)
+			treePublic.GET("/name-frequency", treeHandler.GetNameFrequency)
+			treePublic.GET("/relationship", treeHandler.GetRelationship)
+			treePublic.GET("/changes", treeHandler.GetChanges)
 			treePublic.GET("/:id", treeHandler.GetPerson)
-			treePublic.POST("/:id/like", treeHandler.LikePerson)
-			treePublic.DELETE("/:id/like", treeHandler.UnlikePerson)
-			treePublic.POST("/check-duplicate", treeHandler.CheckDuplicateName)
+			treePublic.GET("/:id/export", exportHandler.ExportSinglePerson)
+			treePublic.GET("/:id/lineage", treeHandler.GetLineage)
+			treePublic.GET("/:id/ancestors", treeHandler.GetAncestors)
+			treePublic.GET("/:id/descendants", treeHandler.GetDescendants)
+			treePublic.GET("/:id/network", treeHandler.GetPersonNetwork)
+			treePublic.GET("/:id/history", treeHandler.GetPersonHistory)
+			treePublic.GET("/:id/detail", treeHandler.GetPersonDetail)
+			treePublic.GET("/:id/certificate", treeHandler.GetPersonCertificate)
+			treePublic.GET("/:id/gedcom", exportHandler.ExportFamilyGEDCOM)
+			treePublic.POST("/:id/like", middleware.BlockImpersonation(), writable, treeHandler.LikePerson)
+			treePublic.DELETE("/:id/like", middleware.BlockImpersonation(), writable, treeHandler.UnlikePerson)
+			treePublic.GET("/:id/likes", treeHandler.GetPersonLikes)
+			treePublic.POST("/likes/status", treeHandler.GetLikesStatus)
+			treePublic.POST("/likes/batch", middleware.BlockImpersonation(), writable, treeHandler.BatchLikePeople)
 		}
 
 		// Search routes (authenticated users can search)
@@ -155,6 +257,14 @@ func main() {
 			search.GET("", searchHandler.SearchPeople)
 			search.GET("/locations", searchHandler.GetLocations)
 			search.GET("/roles", searchHandler.GetRoles)
+			search.GET("/instagram", searchHandler.SearchByInstagram)
+		}
+
+		// Debugging tools (authenticated users)
+		tools := v1.Group("/tools")
+		tools.Use(middleware.AuthMiddleware())
+		{
+			tools.GET("/name-normalize", searchHandler.PreviewNameNormalization)
 		}
 
 		// Export routes (authenticated users can export)
@@ -164,26 +274,39 @@ func main() {
 			export.GET("/json", exportHandler.ExportJSON)
 			export.GET("/csv", exportHandler.ExportCSV)
 			export.GET("/text", exportHandler.ExportText)
+			export.GET("/html", exportHandler.ExportHTML)
+			export.GET("/pdf", exportHandler.ExportPDF)
+			export.GET("/dot", exportHandler.ExportDOT)
+			export.GET("/gedcom", exportHandler.ExportGEDCOM)
 		}
 
 		treeEditor := v1.Group("/tree")
-		treeEditor.Use(middleware.AuthMiddleware(), middleware.RequireEditor())
+		treeEditor.Use(middleware.AuthMiddleware(), middleware.RequireEditor(), middleware.BlockImpersonation(), writable, handlers.RequireIdentityLink(client))
 		{
 			treeEditor.POST("", treeHandler.CreatePerson)
+			treeEditor.POST("/check-duplicate", treeHandler.CheckDuplicateName)
 			treeEditor.PUT("/:id", treeHandler.UpdatePerson)
+			treeEditor.PUT("/:id/children/order", treeHandler.ReorderChildren)
+			treeEditor.POST("/:id/move", treeHandler.MovePerson)
+			treeEditor.POST("/:id/spouse", treeHandler.LinkSpouse)
+			treeEditor.DELETE("/:id/spouse/:spouse_id", treeHandler.UnlinkSpouse)
 			treeEditor.DELETE("/:id", treeHandler.DeletePerson)
 		}
 
 		treeAdmin := v1.Group("/tree")
-		treeAdmin.Use(middleware.AuthMiddleware(), middleware.RequireAdmin())
+		treeAdmin.Use(middleware.AuthMiddleware(), middleware.RequireAdmin(), middleware.BlockImpersonation())
 		{
-			treeAdmin.DELETE("/all", treeHandler.DeleteAllPeople)
-			treeAdmin.POST("/populate", treeHandler.PopulateTreeFromText)
+			treeAdmin.DELETE("/all", writable, treeHandler.DeleteAllPeople)
+			treeAdmin.POST("/populate", writable, treeHandler.PopulateTreeFromText)
+			treeAdmin.POST("/import/gedcom", writable, treeHandler.ImportGEDCOM)
 			treeAdmin.PUT("/settings", treeHandler.UpdateTreeSettings)
 		}
 
 		// Tree settings (public read)
 		v1.GET("/tree/settings", treeHandler.GetTreeSettings)
+
+		// Non-secret server configuration for the frontend (public read)
+		v1.GET("/config", treeHandler.GetPublicConfig)
 	}
 
 	// Start server
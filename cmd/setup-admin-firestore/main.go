@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"github.com/mamiri/findyourroot/internal/config"
 	"github.com/mamiri/findyourroot/internal/database"
 	"github.com/mamiri/findyourroot/internal/models"
 	"golang.org/x/crypto/bcrypt"
@@ -35,7 +36,7 @@ func main() {
 	}
 
 	// Check if user already exists
-	iter := client.Collection("users").Where("email", "==", email).Limit(1).Documents(ctx)
+	iter := database.Collection(client, "users").Where("email", "==", email).Limit(1).Documents(ctx)
 	_, err = iter.Next()
 	if err == nil {
 		log.Printf("Admin user already exists: %s", email)
@@ -46,7 +47,11 @@ func main() {
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	authConfig, err := config.LoadAuth()
+	if err != nil {
+		log.Fatalf("Invalid auth configuration: %v", err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), authConfig.BcryptCost)
 	if err != nil {
 		log.Fatalf("Failed to hash password: %v", err)
 	}
@@ -64,7 +69,7 @@ func main() {
 		UpdatedAt:    time.Now(),
 	}
 
-	_, err = client.Collection("users").Doc(userID).Set(ctx, user)
+	_, err = database.Collection(client, "users").Doc(userID).Set(ctx, user)
 	if err != nil {
 		log.Fatalf("Failed to create admin user: %v", err)
 	}
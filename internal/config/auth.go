@@ -0,0 +1,57 @@
+// Package config holds deployment settings read from the environment at startup
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultTokenTTL is the access token lifetime when TOKEN_TTL is unset
+const DefaultTokenTTL = time.Hour
+
+// Auth configures password hashing and access tokens
+type Auth struct {
+	BcryptCost int           // BCRYPT_COST, bcrypt.MinCost to bcrypt.MaxCost
+	TokenTTL   time.Duration // TOKEN_TTL, a Go duration such as "30m" or "24h"
+}
+
+// DefaultAuth is the configuration used when no variables are set
+func DefaultAuth() Auth {
+	return Auth{BcryptCost: bcrypt.DefaultCost, TokenTTL: DefaultTokenTTL}
+}
+
+// Validate reports the first setting outside its allowed range
+func (a Auth) Validate() error {
+	if a.BcryptCost < bcrypt.MinCost || a.BcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("BCRYPT_COST must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, a.BcryptCost)
+	}
+	if a.TokenTTL <= 0 {
+		return fmt.Errorf("TOKEN_TTL must be positive, got %s", a.TokenTTL)
+	}
+	return nil
+}
+
+// LoadAuth reads BCRYPT_COST and TOKEN_TTL, keeping the defaults for unset ones, and
+// fails on values that don't parse or are out of range
+func LoadAuth() (Auth, error) {
+	cfg := DefaultAuth()
+	if v := os.Getenv("BCRYPT_COST"); v != "" {
+		cost, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, fmt.Errorf("BCRYPT_COST must be an integer, got %q", v)
+		}
+		cfg.BcryptCost = cost
+	}
+	if v := os.Getenv("TOKEN_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return cfg, fmt.Errorf("TOKEN_TTL must be a duration such as \"24h\", got %q", v)
+		}
+		cfg.TokenTTL = ttl
+	}
+	return cfg, cfg.Validate()
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoadAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		cost     string
+		ttl      string
+		wantCost int
+		wantTTL  time.Duration
+		wantErr  bool
+	}{
+		{name: "defaults", wantCost: bcrypt.DefaultCost, wantTTL: DefaultTokenTTL},
+		{name: "custom values", cost: "12", ttl: "30m", wantCost: 12, wantTTL: 30 * time.Minute},
+		{name: "minimum cost", cost: "4", wantCost: bcrypt.MinCost, wantTTL: DefaultTokenTTL},
+		{name: "maximum cost", cost: "31", wantCost: bcrypt.MaxCost, wantTTL: DefaultTokenTTL},
+		{name: "cost below minimum", cost: "3", wantErr: true},
+		{name: "cost above maximum", cost: "32", wantErr: true},
+		{name: "cost not a number", cost: "high", wantErr: true},
+		{name: "ttl not a duration", ttl: "60", wantErr: true},
+		{name: "ttl zero", ttl: "0s", wantErr: true},
+		{name: "ttl negative", ttl: "-1h", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BCRYPT_COST", tt.cost)
+			t.Setenv("TOKEN_TTL", tt.ttl)
+
+			cfg, err := LoadAuth()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", cfg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.BcryptCost != tt.wantCost || cfg.TokenTTL != tt.wantTTL {
+				t.Errorf("got cost %d ttl %s, want cost %d ttl %s", cfg.BcryptCost, cfg.TokenTTL, tt.wantCost, tt.wantTTL)
+			}
+		})
+	}
+}
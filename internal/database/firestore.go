@@ -12,6 +12,20 @@ import (
 // test
 var FirestoreClient *firestore.Client
 
+// collectionPrefix is prepended to every top-level collection name so several
+// environments (e.g. "staging_") can share one Firestore database. Empty by default.
+var collectionPrefix = os.Getenv("COLLECTION_PREFIX")
+
+// CollectionName returns the collection name with the environment's prefix applied
+func CollectionName(name string) string {
+	return collectionPrefix + name
+}
+
+// Collection returns a reference to the prefixed top-level collection
+func Collection(client *firestore.Client, name string) *firestore.CollectionRef {
+	return client.Collection(CollectionName(name))
+}
+
 // InitFirestore initializes Firestore client
 func InitFirestore(ctx context.Context) (*firestore.Client, error) {
 	projectID := os.Getenv("GCP_PROJECT_ID")
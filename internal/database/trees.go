@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+)
+
+// treeCollections are kept separately for every tree. The original tree, which predates
+// the trees collection, uses the top-level collections; every other tree has the same
+// collections under its trees/{id} document. Collections not listed here (users, trees,
+// invites, tokens) are shared and scoped by field where needed.
+var treeCollections = map[string]bool{
+	"people":              true,
+	"suggestions":         true,
+	"settings":            true,
+	"deleted_people":      true,
+	"person_history":      true,
+	"identity_claims":     true,
+	"permission_requests": true,
+	"tree_snapshots":      true,
+	"audit_logs":          true,
+}
+
+// IsTreeCollection reports whether name is kept separately per tree
+func IsTreeCollection(name string) bool {
+	return treeCollections[name]
+}
+
+type treeKey struct{}
+
+// WithTree returns a copy of ctx scoped to treeID; empty is the original tree
+func WithTree(ctx context.Context, treeID string) context.Context {
+	return context.WithValue(ctx, treeKey{}, treeID)
+}
+
+// TreeID returns the tree ctx is scoped to, empty for the original tree
+func TreeID(ctx context.Context) string {
+	treeID, _ := ctx.Value(treeKey{}).(string)
+	return treeID
+}
+
+// TreeCollection returns the named collection of the tree ctx is scoped to. Shared
+// collections are returned as Collection would.
+func TreeCollection(ctx context.Context, client *firestore.Client, name string) *firestore.CollectionRef {
+	if treeID := TreeID(ctx); treeID != "" && treeCollections[name] {
+		return Collection(client, "trees").Doc(treeID).Collection(name)
+	}
+	return Collection(client, name)
+}
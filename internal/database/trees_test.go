@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/option"
+)
+
+func newTestClient(t *testing.T) *firestore.Client {
+	t.Helper()
+	client, err := firestore.NewClient(context.Background(), "test-project", option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestTreeCollectionIsolatesTrees(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	treeA := WithTree(ctx, "tree-a")
+	treeB := WithTree(ctx, "tree-b")
+
+	for name := range treeCollections {
+		original := TreeCollection(ctx, client, name).Path
+		a := TreeCollection(treeA, client, name).Path
+		b := TreeCollection(treeB, client, name).Path
+		if a == b || a == original || b == original {
+			t.Errorf("%s: trees share a collection: original %q, A %q, B %q", name, original, a, b)
+		}
+		if want := Collection(client, name).Path; original != want {
+			t.Errorf("%s: original tree got %q, want the top-level %q", name, original, want)
+		}
+		if want := Collection(client, "trees").Doc("tree-a").Collection(name).Path; a != want {
+			t.Errorf("%s: tree A got %q, want %q", name, a, want)
+		}
+	}
+}
+
+func TestTreeCollectionSharedCollections(t *testing.T) {
+	client := newTestClient(t)
+	treeA := WithTree(context.Background(), "tree-a")
+
+	for _, name := range []string{"users", "trees", "invites", "refresh_tokens", "password_resets"} {
+		if got, want := TreeCollection(treeA, client, name).Path, Collection(client, name).Path; got != want {
+			t.Errorf("%s: got %q, want the shared %q", name, got, want)
+		}
+	}
+}
+
+func TestTreeID(t *testing.T) {
+	ctx := context.Background()
+	if got := TreeID(ctx); got != "" {
+		t.Errorf("unscoped context: got %q, want the original tree", got)
+	}
+	if got := TreeID(WithTree(ctx, "tree-a")); got != "tree-a" {
+		t.Errorf("got %q, want %q", got, "tree-a")
+	}
+	if got := TreeID(WithTree(WithTree(ctx, "tree-a"), "")); got != "" {
+		t.Errorf("rescoped to the original tree: got %q", got)
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// ReviewerActivity is one decision made by a reviewer
+type ReviewerActivity struct {
+	Kind           string    `json:"kind"` // "suggestion" or "identity_claim"
+	ID             string    `json:"id"`
+	SuggestionType string    `json:"suggestion_type,omitempty"`
+	PersonID       string    `json:"person_id"`
+	PersonName     string    `json:"person_name,omitempty"`
+	RequesterEmail string    `json:"requester_email"`
+	Outcome        string    `json:"outcome"` // approved, rejected, ...
+	ReviewNotes    string    `json:"review_notes"`
+	DecidedAt      time.Time `json:"decided_at"`
+}
+
+// GetReviewerActivity lists the suggestions and identity claims a user decided, newest first,
+// paginated with ?page= and ?page_size= (admin only)
+func (h *FirestoreSuggestionHandler) GetReviewerActivity(c *gin.Context) {
+	reviewerID := c.Param("id")
+	page, pageSize := parsePagination(c)
+	ctx := treeContext(c)
+
+	activity := []ReviewerActivity{}
+
+	suggestions := h.coll(ctx, "suggestions").Where("reviewed_by", "==", reviewerID).Documents(ctx)
+	defer suggestions.Stop()
+	for {
+		doc, err := suggestions.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+			return
+		}
+		var s models.Suggestion
+		if err := doc.DataTo(&s); err != nil {
+			continue
+		}
+		entry := ReviewerActivity{
+			Kind:           "suggestion",
+			ID:             doc.Ref.ID,
+			SuggestionType: string(s.Type),
+			PersonID:       s.TargetPersonID,
+			RequesterEmail: s.UserEmail,
+			Outcome:        s.Status,
+			ReviewNotes:    s.ReviewNotes,
+			DecidedAt:      s.UpdatedAt,
+		}
+		if s.PersonData != nil {
+			entry.PersonName = s.PersonData.Name
+		}
+		activity = append(activity, entry)
+	}
+
+	claims := h.coll(ctx, "identity_claims").Where("reviewed_by", "==", reviewerID).Documents(ctx)
+	defer claims.Stop()
+	for {
+		doc, err := claims.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch identity claims"})
+			return
+		}
+		var claim models.IdentityClaimRequest
+		if err := doc.DataTo(&claim); err != nil {
+			continue
+		}
+		activity = append(activity, ReviewerActivity{
+			Kind:           "identity_claim",
+			ID:             doc.Ref.ID,
+			PersonID:       claim.PersonID,
+			PersonName:     claim.PersonName,
+			RequesterEmail: claim.UserEmail,
+			Outcome:        claim.Status,
+			ReviewNotes:    claim.ReviewNotes,
+			DecidedAt:      claim.UpdatedAt,
+		})
+	}
+
+	sort.Slice(activity, func(i, j int) bool {
+		return activity[i].DecidedAt.After(activity[j].DecidedAt)
+	})
+
+	total := len(activity)
+	start, end, totalPages := pageBounds(total, page, pageSize)
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviewer_id": reviewerID,
+		"data":        activity[start:end],
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+	})
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// recordAudit stores an audit log entry for the current request's user.
+// Failures are logged but never fail the surrounding request.
+func recordAudit(ctx context.Context, client *firestore.Client, c *gin.Context, action, targetID string, details map[string]interface{}) {
+	actorID, _ := c.Get("user_id")
+	actorEmail, _ := c.Get("email")
+
+	entry := models.AuditLog{
+		ID:        uuid.New().String(),
+		Action:    action,
+		TargetID:  targetID,
+		Details:   details,
+		IPAddress: c.ClientIP(),
+		CreatedAt: time.Now(),
+	}
+	entry.ActorID, _ = actorID.(string)
+	entry.ActorEmail, _ = actorEmail.(string)
+	if entry.Details == nil {
+		entry.Details = map[string]interface{}{}
+	}
+
+	if _, err := database.TreeCollection(ctx, client, "audit_logs").Doc(entry.ID).Set(ctx, entry); err != nil {
+		log.Printf("[Audit] Failed to record %s by %s: %v", action, entry.ActorEmail, err)
+		return
+	}
+	log.Printf("[Audit] %s by %s (target %s)", action, entry.ActorEmail, targetID)
+}
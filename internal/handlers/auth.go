@@ -10,20 +10,23 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/mamiri/findyourroot/internal/config"
 	"github.com/mamiri/findyourroot/internal/middleware"
 	"github.com/mamiri/findyourroot/internal/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
-	db *sql.DB
+	db   *sql.DB
+	auth config.Auth
 }
 
-func NewAuthHandler(db *sql.DB) *AuthHandler {
-	return &AuthHandler{db: db}
+func NewAuthHandler(db *sql.DB, auth config.Auth) *AuthHandler {
+	return &AuthHandler{db: db, auth: auth}
 }
 
-// Login handles user authentication
+// Login handles user authentication.
+// Lockout needs users.failed_login_attempts INT NOT NULL DEFAULT 0 and users.locked_until TIMESTAMPTZ.
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -34,11 +37,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Get user from database
 	var user models.User
 	var password string
+	var lockedUntil sql.NullTime
 	err := h.db.QueryRow(
-		`SELECT id, email, password_hash, role, is_admin, created_at, updated_at 
+		`SELECT id, email, password_hash, role, is_admin, failed_login_attempts, locked_until, created_at, updated_at 
 		 FROM users WHERE email = $1`,
 		req.Email,
-	).Scan(&user.ID, &user.Email, &password, &user.Role, &user.IsAdmin, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Email, &password, &user.Role, &user.IsAdmin, &user.FailedLoginAttempts, &lockedUntil, &user.CreatedAt, &user.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
@@ -50,12 +54,42 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Refuse locked accounts before checking the password
+	if lockedUntil.Valid && lockedUntil.Time.After(time.Now()) {
+		respondAccountLocked(c, lockedUntil.Time)
+		return
+	}
+
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(password), []byte(req.Password)); err != nil {
+		// Count the failure; reaching the threshold locks the account and resets the counter
+		var newLock sql.NullTime
+		err := h.db.QueryRow(
+			`UPDATE users SET
+			   locked_until = CASE WHEN failed_login_attempts + 1 >= $2 THEN $3 ELSE locked_until END,
+			   failed_login_attempts = CASE WHEN failed_login_attempts + 1 >= $2 THEN 0 ELSE failed_login_attempts + 1 END
+			 WHERE id = $1 RETURNING locked_until`,
+			user.ID, loginLockoutThreshold, time.Now().Add(loginLockoutDuration),
+		).Scan(&newLock)
+		if err != nil {
+			fmt.Printf("Error recording failed login for %s: %v\n", user.Email, err)
+		}
+		if newLock.Valid && newLock.Time.After(time.Now()) {
+			fmt.Printf("Account locked after repeated failed logins: %s\n", user.Email)
+			notifyAccountLocked(user, newLock.Time)
+			respondAccountLocked(c, newLock.Time)
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
+	if user.FailedLoginAttempts > 0 {
+		if _, err := h.db.Exec("UPDATE users SET failed_login_attempts = 0 WHERE id = $1", user.ID); err != nil {
+			fmt.Printf("Error resetting failed logins for %s: %v\n", user.Email, err)
+		}
+	}
+
 	// Generate JWT token
 	token, err := h.generateToken(user.Email, user.IsAdmin, string(user.Role))
 	if err != nil {
@@ -133,7 +167,7 @@ func (h *AuthHandler) generateToken(email string, isAdmin bool, role string) (st
 		IsAdmin: isAdmin,
 		Role:    role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(h.auth.TokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "findyourroot-api",
@@ -188,7 +222,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), h.auth.BcryptCost)
 	if err != nil {
 		fmt.Printf("Error hashing password: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user"})
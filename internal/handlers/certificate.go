@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// certificateLabels are the fixed texts of a certificate, per layout direction
+var certificateLabels = map[bool]map[string]string{
+	false: {
+		"title": "Family Record", "also_known_as": "Also known as", "born": "Born", "died": "Died", "deceased": "Deceased", "location": "Location",
+		"occupation": "Occupation", "role": "Role", "lineage": "Lineage", "children": "Children",
+		"none": "None recorded", "more": "and %d more", "generated": "Generated %s", "verified": "Verified by admin",
+	},
+	true: {
+		"title": "شناسنامه خانوادگی", "also_known_as": "نام‌های دیگر", "born": "تولد", "died": "وفات", "deceased": "درگذشته", "location": "محل",
+		"occupation": "شغل", "role": "نسبت", "lineage": "تبار", "children": "فرزندان",
+		"none": "ثبت نشده", "more": "و %d نفر دیگر", "generated": "تهیه شده در %s", "verified": "تأیید شده توسط مدیر",
+	},
+}
+
+// Lines the one-page layout has room for
+const (
+	certificateMaxLineage  = 14
+	certificateMaxChildren = 12
+)
+
+// GetPersonCertificate renders a one-page PDF summary of a person: details, photo,
+// the father's line back to the root ancestor (?via=mother for the mother's) and children.
+// Persian names switch the page to a right-to-left layout; ?dir=ltr|rtl overrides it.
+func (h *FirestoreTreeHandler) GetPersonCertificate(c *gin.Context) {
+	id := c.Param("id")
+	if format := c.DefaultQuery("format", "pdf"); format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Must be 'pdf'"})
+		return
+	}
+	preferGender := "male"
+	switch c.DefaultQuery("via", "father") {
+	case "father":
+	case "mother":
+		preferGender = "female"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "via must be 'father' or 'mother'"})
+		return
+	}
+
+	ctx := treeContext(c)
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+	var person models.Person
+	if err := doc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+
+	chain, _, err := lineageChain(ctx, h.client, person, preferGender)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lineage"})
+		return
+	}
+	children, err := fetchPeopleByIDs(ctx, h.client, person.Children)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch children"})
+		return
+	}
+
+	rtl := utils.ContainsPersianCharacters(person.Name)
+	switch c.Query("dir") {
+	case "rtl":
+		rtl = true
+	case "ltr":
+		rtl = false
+	}
+
+	settings := loadTreeSettings(ctx, h.client)
+	data, err := renderCertificate(settings.TreeName, person, chain, children, rtl)
+	if err != nil {
+		log.Printf("[Certificate] Failed to render %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", pdfFilename("certificate-"+person.ID)))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// renderCertificate lays out the certificate page
+func renderCertificate(treeName string, person models.Person, chain, children []models.Person, rtl bool) ([]byte, error) {
+	labels := certificateLabels[rtl]
+	d := newPDFDoc(rtl)
+
+	d.centered(treeName, 12, 6)
+	d.centered(labels["title"], 18, 10)
+	d.rule()
+
+	// Photo in the outer top corner, when the avatar is a real image
+	const photoSize = 35.0
+	pageW, _ := d.pdf.GetPageSize()
+	left, _, right, _ := d.pdf.GetMargins()
+	top := d.pdf.GetY()
+	if d.registerRemoteImage("avatar", person.Avatar) {
+		x := pageW - right - photoSize
+		if rtl {
+			x = left
+		}
+		d.pdf.ImageOptions("avatar", x, top, photoSize, photoSize, false, fpdf.ImageOptions{}, 0, "")
+		// Keep the details clear of the photo
+		if rtl {
+			d.pdf.SetLeftMargin(left + photoSize + 5)
+		} else {
+			d.pdf.SetRightMargin(right + photoSize + 5)
+		}
+	}
+
+	d.line(person.Name, 20, 11)
+	d.field(labels["also_known_as"], strings.Join(person.AltNames, "، "))
+	d.field(labels["born"], person.Birth)
+	if person.Death != "" {
+		d.field(labels["died"], person.Death)
+	} else if person.Deceased {
+		d.field(labels["died"], labels["deceased"])
+	}
+	d.field(labels["location"], person.Location)
+	d.field(labels["occupation"], person.Occupation)
+	d.field(labels["role"], person.Role)
+	if person.DataVerified {
+		d.line(labels["verified"], 9, 6)
+	}
+
+	d.pdf.SetLeftMargin(left)
+	d.pdf.SetRightMargin(right)
+	if y := top + photoSize + 3; d.pdf.GetY() < y {
+		d.pdf.SetY(y)
+	}
+	d.rule()
+
+	d.line(labels["lineage"], 14, 8)
+	ancestors := chain
+	skipped := 0
+	if len(ancestors) > certificateMaxLineage {
+		// Keep the root and the generations closest to the person
+		skipped = len(ancestors) - certificateMaxLineage
+		ancestors = append([]models.Person{chain[0]}, chain[skipped+1:]...)
+	}
+	for i, p := range ancestors {
+		if i == 1 && skipped > 0 {
+			d.line("…", 10, 5)
+		}
+		label := p.Name
+		if p.Birth != "" {
+			label = fmt.Sprintf("%s (%s)", p.Name, p.Birth)
+		}
+		d.line(label, 10, 6)
+	}
+	d.rule()
+
+	d.line(labels["children"], 14, 8)
+	if len(children) == 0 {
+		d.line(labels["none"], 10, 6)
+	}
+	for i, child := range children {
+		if i == certificateMaxChildren || d.remaining() < 16 {
+			d.line(fmt.Sprintf(labels["more"], len(children)-i), 10, 6)
+			break
+		}
+		label := child.Name
+		if child.Birth != "" {
+			label = fmt.Sprintf("%s (%s)", child.Name, child.Birth)
+		}
+		d.line(label, 10, 6)
+	}
+
+	_, pageH := d.pdf.GetPageSize()
+	d.pdf.SetY(pageH - 20)
+	d.pdf.SetTextColor(120, 120, 120)
+	d.centered(fmt.Sprintf(labels["generated"], time.Now().Format("2006-01-02")), 8, 5)
+
+	return d.output()
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mamiri/findyourroot/internal/database"
+)
+
+// Every top-level collection is resolved through these accessors so COLLECTION_PREFIX
+// applies everywhere and per-tree collections resolve to the tree ctx is scoped to (see
+// treeContext). Subcollections (e.g. snapshot chunks) live under a prefixed parent
+// document and keep their plain names.
+
+func (h *FirestoreTreeHandler) coll(ctx context.Context, name string) *firestore.CollectionRef {
+	return database.TreeCollection(ctx, h.client, name)
+}
+
+func (h *FirestoreAuthHandler) coll(ctx context.Context, name string) *firestore.CollectionRef {
+	return database.TreeCollection(ctx, h.client, name)
+}
+
+func (h *FirestoreSuggestionHandler) coll(ctx context.Context, name string) *firestore.CollectionRef {
+	return database.TreeCollection(ctx, h.client, name)
+}
+
+func (h *FirestoreIdentityClaimHandler) coll(ctx context.Context, name string) *firestore.CollectionRef {
+	return database.TreeCollection(ctx, h.client, name)
+}
+
+func (h *FirestoreSearchHandler) coll(ctx context.Context, name string) *firestore.CollectionRef {
+	return database.TreeCollection(ctx, h.client, name)
+}
+
+func (h *FirestoreExportHandler) coll(ctx context.Context, name string) *firestore.CollectionRef {
+	return database.TreeCollection(ctx, h.client, name)
+}
+
+func (h *SSEHandler) coll(ctx context.Context, name string) *firestore.CollectionRef {
+	return database.TreeCollection(ctx, h.client, name)
+}
+
+func (s *ReferentialIntegrityService) coll(ctx context.Context, name string) *firestore.CollectionRef {
+	return database.TreeCollection(ctx, s.client, name)
+}
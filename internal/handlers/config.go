@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPublicConfig returns the non-secret server configuration the frontend adapts to:
+// tree settings, registration rules and which optional features are enabled.
+// Never add secrets (JWT_SECRET, API keys, credentials) here - this route is public.
+func (h *FirestoreTreeHandler) GetPublicConfig(c *gin.Context) {
+	settings := loadTreeSettings(treeContext(c), h.client)
+
+	c.JSON(http.StatusOK, gin.H{
+		"tree": gin.H{
+			"name":                  settings.TreeName,
+			"edit_policy":           settings.EditPolicy,
+			"contributor_fields":    settings.ContributorFields, // Empty means every field is allowed
+			"require_identity_link": settings.RequireIdentityLink,
+			"read_only":             settings.ReadOnly,
+		},
+		"registration": gin.H{
+			"required_fields":     []string{"email", "password", "tree_name", "father_name", "birth_year"},
+			"min_password_length": minPasswordLength,
+		},
+		"features": gin.H{
+			"instagram":     true,
+			"gemini":        os.Getenv("GEMINI_API_KEY") != "",
+			"impersonation": true,
+			"multi_tree":    true,
+		},
+		"limits": gin.H{
+			"max_children_soft_cap":   maxChildrenSoftCap,
+			"max_claim_evidence":      maxClaimEvidence,
+			"max_birth_age_years":     maxBirthAgeYears,
+			"review_claim_minutes":    int(reviewClaimTimeout.Minutes()),
+			"export_cache_seconds":    int(exportCacheTTL.Seconds()),
+			"default_page_size":       defaultPageSize,
+			"max_page_size":           maxPageSize,
+			"max_pending_suggestions": maxPendingSuggestions,
+			"max_unpaginated_people":  maxUnpaginatedPeople,
+		},
+	})
+}
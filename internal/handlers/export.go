@@ -2,17 +2,19 @@ package handlers
 
 import (
 	"bytes"
-	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"github.com/mamiri/findyourroot/internal/models"
-	"google.golang.org/api/iterator"
+	"github.com/mamiri/findyourroot/internal/utils"
 )
 
 // FirestoreExportHandler handles export operations
@@ -25,38 +27,157 @@ func NewFirestoreExportHandler(client *firestore.Client) *FirestoreExportHandler
 	return &FirestoreExportHandler{client: client}
 }
 
+// ExportPerson is the export-friendly format of a person (without internal fields)
+type ExportPerson struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	AltNames         []string `json:"alt_names"`
+	Role             string   `json:"role"`
+	Occupation       string   `json:"occupation"`
+	Birth            string   `json:"birth"`
+	Death            string   `json:"death"`
+	Deceased         bool     `json:"deceased"`
+	Location         string   `json:"location"`
+	Avatar           string   `json:"avatar"`
+	Bio              string   `json:"bio"`
+	Children         []string `json:"children"`
+	ChildrenNames    []string `json:"children_names"` // Same order as children; unknown ids are left out
+	Spouses          []string `json:"spouses"`
+	RelationshipType string   `json:"relationship_type"` // To the parent listing this person
+	DataVerified     bool     `json:"data_verified"`     // Record was vetted by an admin/co-admin
+}
+
+// personNames maps each person's id to their name, for resolving references in exports
+func personNames(people []models.Person) map[string]string {
+	names := make(map[string]string, len(people))
+	for _, p := range people {
+		names[p.ID] = p.Name
+	}
+	return names
+}
+
+// childrenNames resolves a person's children to names, skipping ids not in names
+func childrenNames(p models.Person, names map[string]string) []string {
+	resolved := make([]string, 0, len(p.Children))
+	for _, childID := range p.Children {
+		if name, ok := names[childID]; ok {
+			resolved = append(resolved, name)
+		}
+	}
+	return resolved
+}
+
+// toExportPerson converts a person to its export format, naming children from names
+func toExportPerson(p models.Person, names map[string]string) ExportPerson {
+	return ExportPerson{
+		ID:               p.ID,
+		Name:             p.Name,
+		AltNames:         p.AltNames,
+		Role:             p.Role,
+		Occupation:       p.Occupation,
+		Birth:            p.Birth,
+		Death:            p.Death,
+		Deceased:         p.Deceased,
+		Location:         p.Location,
+		Avatar:           p.Avatar,
+		Bio:              p.Bio,
+		Children:         p.Children,
+		ChildrenNames:    childrenNames(p, names),
+		Spouses:          p.Spouses,
+		RelationshipType: p.ParentRelationship(),
+		DataVerified:     p.DataVerified,
+	}
+}
+
+// livingMaskYears is how recent a birth must be for a person without the deceased flag
+// to count as living in masked exports (LIVING_MASK_YEARS, default 100)
+var livingMaskYears = envPositiveInt("LIVING_MASK_YEARS", 100)
+
+// isPresumedLiving reports whether a person should be treated as living: not marked
+// deceased and born within livingMaskYears. An unknown birth year counts as living.
+func isPresumedLiving(p models.Person, now time.Time) bool {
+	if p.Deceased {
+		return false
+	}
+	year, ok := utils.ParseBirthYear(p.Birth)
+	return !ok || year > now.Year()-livingMaskYears
+}
+
+// livingBirthLabel replaces the birth of a masked living person whose birth year is unknown
+const livingBirthLabel = "Living"
+
+// maskLivingPeople returns a copy of people in which presumed-living people keep only
+// their birth year ("Living" when unknown) and have no bio or location. The input
+// slice is shared with the export snapshot cache, so it is never modified.
+func maskLivingPeople(people []models.Person, now time.Time) []models.Person {
+	masked := make([]models.Person, len(people))
+	for i, p := range people {
+		if isPresumedLiving(p, now) {
+			if year, ok := utils.ParseBirthYear(p.Birth); ok {
+				p.Birth = strconv.Itoa(year)
+			} else {
+				p.Birth = livingBirthLabel
+			}
+			p.Bio = ""
+			p.Location = ""
+		}
+		masked[i] = p
+	}
+	return masked
+}
+
+// deathLabel is the death date for exports, "deceased" when the date is unknown,
+// or empty for living people
+func deathLabel(p models.Person) string {
+	if p.Death != "" {
+		return p.Death
+	}
+	if p.Deceased {
+		return "deceased"
+	}
+	return ""
+}
+
+// writeTextPerson writes a single person's text export block, naming children from names
+func writeTextPerson(buf *bytes.Buffer, person models.Person, names map[string]string) {
+	buf.WriteString(fmt.Sprintf("%s (%s)\n", person.Name, person.Role))
+	if len(person.AltNames) > 0 {
+		buf.WriteString(fmt.Sprintf("  Also known as: %s\n", strings.Join(person.AltNames, ", ")))
+	}
+	if person.Occupation != "" {
+		buf.WriteString(fmt.Sprintf("  Occupation: %s\n", person.Occupation))
+	}
+	buf.WriteString(fmt.Sprintf("  Born: %s\n", person.Birth))
+	if died := deathLabel(person); died != "" {
+		buf.WriteString(fmt.Sprintf("  Died: %s\n", died))
+	}
+	buf.WriteString(fmt.Sprintf("  Location: %s\n", person.Location))
+	if person.Bio != "" {
+		buf.WriteString(fmt.Sprintf("  About: %s\n", person.Bio))
+	}
+	if children := childrenNames(person, names); len(children) > 0 {
+		buf.WriteString("  Children:\n")
+		for _, name := range children {
+			buf.WriteString(fmt.Sprintf("    - %s\n", name))
+		}
+	}
+	if person.DataVerified {
+		buf.WriteString("  Verified by admin\n")
+	}
+}
+
 // ExportJSON exports tree data as JSON
 func (h *FirestoreExportHandler) ExportJSON(c *gin.Context) {
-	people, err := h.getAllPeople(c)
+	people, err := h.exportPeople(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Create export-friendly format (without internal fields)
-	type ExportPerson struct {
-		ID       string   `json:"id"`
-		Name     string   `json:"name"`
-		Role     string   `json:"role"`
-		Birth    string   `json:"birth"`
-		Location string   `json:"location"`
-		Avatar   string   `json:"avatar"`
-		Bio      string   `json:"bio"`
-		Children []string `json:"children"`
-	}
-
+	names := personNames(people)
 	exportData := make([]ExportPerson, len(people))
 	for i, p := range people {
-		exportData[i] = ExportPerson{
-			ID:       p.ID,
-			Name:     p.Name,
-			Role:     p.Role,
-			Birth:    p.Birth,
-			Location: p.Location,
-			Avatar:   p.Avatar,
-			Bio:      p.Bio,
-			Children: p.Children,
-		}
+		exportData[i] = toExportPerson(p, names)
 	}
 
 	jsonData, err := json.MarshalIndent(exportData, "", "  ")
@@ -71,9 +192,12 @@ func (h *FirestoreExportHandler) ExportJSON(c *gin.Context) {
 	c.Data(http.StatusOK, "application/json", jsonData)
 }
 
+// csvExportHeader is the header row of ExportCSV; ImportCSV must accept every column
+var csvExportHeader = []string{"ID", "Name", "Alternate Names", "Role", "Occupation", "Birth Year", "Died", "Location", "Bio", "Avatar URL", "Children Names"}
+
 // ExportCSV exports tree data as CSV
 func (h *FirestoreExportHandler) ExportCSV(c *gin.Context) {
-	people, err := h.getAllPeople(c)
+	people, err := h.exportPeople(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -83,22 +207,26 @@ func (h *FirestoreExportHandler) ExportCSV(c *gin.Context) {
 	writer := csv.NewWriter(&buf)
 
 	// Write header
-	header := []string{"ID", "Name", "Role", "Birth Year", "Location", "Bio", "Avatar URL"}
-	if err := writer.Write(header); err != nil {
+	if err := writer.Write(csvExportHeader); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write CSV header"})
 		return
 	}
 
 	// Write data rows
+	names := personNames(people)
 	for _, person := range people {
 		row := []string{
 			person.ID,
 			person.Name,
+			strings.Join(person.AltNames, "; "),
 			person.Role,
+			person.Occupation,
 			person.Birth,
+			deathLabel(person),
 			person.Location,
 			person.Bio,
 			person.Avatar,
+			strings.Join(childrenNames(person, names), "; "),
 		}
 		if err := writer.Write(row); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write CSV row"})
@@ -118,9 +246,9 @@ func (h *FirestoreExportHandler) ExportCSV(c *gin.Context) {
 	c.Data(http.StatusOK, "text/csv", buf.Bytes())
 }
 
-// ExportText exports tree data as plain text (for PDF-like output)
+// ExportText exports tree data as plain text (ExportPDF renders the hierarchy as a PDF)
 func (h *FirestoreExportHandler) ExportText(c *gin.Context) {
-	people, err := h.getAllPeople(c)
+	people, err := h.exportPeople(c)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -131,13 +259,9 @@ func (h *FirestoreExportHandler) ExportText(c *gin.Context) {
 	buf.WriteString(fmt.Sprintf("Generated: %s\n", time.Now().Format("January 2, 2006")))
 	buf.WriteString("================================\n\n")
 
+	names := personNames(people)
 	for _, person := range people {
-		buf.WriteString(fmt.Sprintf("%s (%s)\n", person.Name, person.Role))
-		buf.WriteString(fmt.Sprintf("  Born: %s\n", person.Birth))
-		buf.WriteString(fmt.Sprintf("  Location: %s\n", person.Location))
-		if person.Bio != "" {
-			buf.WriteString(fmt.Sprintf("  About: %s\n", person.Bio))
-		}
+		writeTextPerson(&buf, person, names)
 		buf.WriteString("\n")
 	}
 
@@ -147,33 +271,164 @@ func (h *FirestoreExportHandler) ExportText(c *gin.Context) {
 	c.Data(http.StatusOK, "text/plain", buf.Bytes())
 }
 
-// getAllPeople fetches all people from Firestore
-func (h *FirestoreExportHandler) getAllPeople(c *gin.Context) ([]models.Person, error) {
-	ctx := context.Background()
+// ExportSinglePerson exports one person's data card as json, text or vcard, masked like
+// the tree exports when ?mask_living=true
+func (h *FirestoreExportHandler) ExportSinglePerson(c *gin.Context) {
+	id := c.Param("id")
+	format := c.DefaultQuery("format", "json")
 
-	iter := h.client.Collection("people").Documents(ctx)
-	defer iter.Stop()
+	if format != "json" && format != "text" && format != "vcard" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Must be 'json', 'text', or 'vcard'"})
+		return
+	}
 
-	var people []models.Person
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+
+	var person models.Person
+	if err := doc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+
+	children, err := fetchPeopleByIDs(ctx, h.client, uniqueIDs(person.Children))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch children"})
+		return
+	}
+	names := personNames(children)
+
+	// The card follows the same living-person masking as the tree exports
+	person = maskRequested(c, []models.Person{person})[0]
+
+	basename := "person-" + person.ID
+
+	switch format {
+	case "json":
+		jsonData, err := json.MarshalIndent(toExportPerson(person, names), "", "  ")
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch people: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate JSON"})
+			return
 		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.json", basename))
+		c.Data(http.StatusOK, "application/json", jsonData)
+
+	case "text":
+		var buf bytes.Buffer
+		writeTextPerson(&buf, person, names)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.txt", basename))
+		c.Data(http.StatusOK, "text/plain", buf.Bytes())
+
+	case "vcard":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.vcf", basename))
+		c.Data(http.StatusOK, "text/vcard", []byte(buildVCard(person)))
+	}
+}
+
+// buildVCard renders a person as a vCard 4.0 contact
+func buildVCard(person models.Person) string {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCARD\r\n")
+	b.WriteString("VERSION:4.0\r\n")
+	b.WriteString(fmt.Sprintf("FN:%s\r\n", escapeVCard(person.Name)))
+	b.WriteString(fmt.Sprintf("N:;%s;;;\r\n", escapeVCard(person.Name)))
+	if len(person.AltNames) > 0 {
+		escaped := make([]string, len(person.AltNames))
+		for i, alt := range person.AltNames {
+			escaped[i] = escapeVCard(alt)
+		}
+		b.WriteString(fmt.Sprintf("NICKNAME:%s\r\n", strings.Join(escaped, ",")))
+	}
+	if person.Birth != "" && person.Birth != livingBirthLabel {
+		b.WriteString(fmt.Sprintf("BDAY:%s\r\n", escapeVCard(person.Birth)))
+	}
+	switch person.Gender {
+	case "male":
+		b.WriteString("GENDER:M\r\n")
+	case "female":
+		b.WriteString("GENDER:F\r\n")
+	}
+	if person.Occupation != "" {
+		b.WriteString(fmt.Sprintf("TITLE:%s\r\n", escapeVCard(person.Occupation)))
+	}
+	if person.Avatar != "" {
+		b.WriteString(fmt.Sprintf("PHOTO:%s\r\n", person.Avatar))
+	}
+	if person.Location != "" {
+		b.WriteString(fmt.Sprintf("ADR:;;;%s;;;\r\n", escapeVCard(person.Location)))
+	}
+	if person.Bio != "" {
+		b.WriteString(fmt.Sprintf("NOTE:%s\r\n", escapeVCard(person.Bio)))
+	}
+	b.WriteString(fmt.Sprintf("UID:urn:uuid:%s\r\n", person.ID))
+	b.WriteString("END:VCARD\r\n")
+	return b.String()
+}
 
-		var person models.Person
-		if err := doc.DataTo(&person); err != nil {
-			continue
+// escapeVCard escapes text values per RFC 6350
+func escapeVCard(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(s)
+}
+
+// exportCacheTTL is how long export endpoints reuse a people snapshot
+// (EXPORT_CACHE_TTL_SECONDS, default 60; 0 disables caching)
+var exportCacheTTL = func() time.Duration {
+	if v := os.Getenv("EXPORT_CACHE_TTL_SECONDS"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
 		}
-		people = append(people, person)
 	}
+	return 60 * time.Second
+}()
+
+// peopleSnapshotCache holds the shared people snapshot used by exports
+var peopleSnapshotCache = utils.NewTTLCache(exportCacheTTL)
 
-	if people == nil {
-		people = []models.Person{}
+// getAllPeople returns all people for an export, from the shared snapshot when fresh enough.
+// ?fresh=true bypasses the snapshot. Also sets Cache-Control on the response.
+func (h *FirestoreExportHandler) getAllPeople(c *gin.Context) ([]models.Person, error) {
+	ctx := treeContext(c)
+	fresh := c.Query("fresh") == "true" || exportCacheTTL == 0
+
+	if fresh {
+		c.Header("Cache-Control", "no-store")
+	} else {
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(exportCacheTTL.Seconds())))
+		if cached, ok := peopleSnapshotCache.Get(treeCacheKey(ctx, "people")); ok {
+			return cached.([]models.Person), nil
+		}
 	}
 
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		return nil, err
+	}
+	if exportCacheTTL > 0 {
+		peopleSnapshotCache.Set(treeCacheKey(ctx, "people"), people)
+	}
 	return people, nil
 }
+
+// exportPeople returns the people for a tree export, with living people masked
+// when ?mask_living=true
+func (h *FirestoreExportHandler) exportPeople(c *gin.Context) ([]models.Person, error) {
+	people, err := h.getAllPeople(c)
+	if err != nil {
+		return nil, err
+	}
+	return maskRequested(c, people), nil
+}
+
+// maskRequested applies maskLivingPeople when the request asks for ?mask_living=true
+func maskRequested(c *gin.Context, people []models.Person) []models.Person {
+	if c.Query("mask_living") == "true" {
+		return maskLivingPeople(people, time.Now())
+	}
+	return people
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// dotEscaper escapes text for a double-quoted DOT string
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", `\n`)
+
+// buildDOT renders people as a Graphviz digraph: one node per person labeled with name and
+// birth, and an edge from each parent to each child. Children missing from people are skipped.
+// The output is UTF-8, so Persian names render with any font Graphviz can find for them.
+func buildDOT(people []models.Person) string {
+	exists := make(map[string]bool, len(people))
+	for _, p := range people {
+		exists[p.ID] = true
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph FamilyTree {\n")
+	buf.WriteString("  charset=\"UTF-8\";\n")
+	buf.WriteString("  rankdir=TB;\n")
+	buf.WriteString("  node [shape=box, style=rounded];\n\n")
+	for _, p := range people {
+		label := p.Name
+		if p.Birth != "" {
+			label += "\n" + p.Birth
+		}
+		fmt.Fprintf(&buf, "  \"%s\" [label=\"%s\"];\n", dotEscaper.Replace(p.ID), dotEscaper.Replace(label))
+	}
+	buf.WriteString("\n")
+	for _, p := range people {
+		for _, childID := range p.Children {
+			if exists[childID] {
+				fmt.Fprintf(&buf, "  \"%s\" -> \"%s\";\n", dotEscaper.Replace(p.ID), dotEscaper.Replace(childID))
+			}
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// ExportDOT exports the tree as a Graphviz DOT file for rendering with dot -Tsvg and friends
+func (h *FirestoreExportHandler) ExportDOT(c *gin.Context) {
+	people, err := h.exportPeople(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("family-tree-%s.dot", time.Now().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "text/vnd.graphviz")
+	c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(buildDOT(people)))
+}
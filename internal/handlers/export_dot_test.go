@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+func TestBuildDOTEdges(t *testing.T) {
+	people := []models.Person{
+		{ID: "dad", Name: "Ali", Birth: "1950", Children: []string{"son", "daughter", "missing"}},
+		{ID: "son", Name: "Reza", Children: []string{"grandson"}},
+		{ID: "daughter", Name: "Sara"},
+		{ID: "grandson", Name: "Amir"},
+	}
+	dot := buildDOT(people)
+
+	for _, edge := range []string{
+		`"dad" -> "son";`,
+		`"dad" -> "daughter";`,
+		`"son" -> "grandson";`,
+	} {
+		if !strings.Contains(dot, edge) {
+			t.Errorf("missing edge %s in:\n%s", edge, dot)
+		}
+	}
+	if strings.Contains(dot, `"missing"`) {
+		t.Errorf("edge to a child outside the export was written:\n%s", dot)
+	}
+	if got := strings.Count(dot, "->"); got != 3 {
+		t.Errorf("got %d edges, want 3", got)
+	}
+	if !strings.Contains(dot, `"dad" [label="Ali\n1950"];`) {
+		t.Errorf("node label with birth missing:\n%s", dot)
+	}
+}
+
+func TestBuildDOTEscapesQuotes(t *testing.T) {
+	dot := buildDOT([]models.Person{
+		{ID: `a"b`, Name: `Ali "the elder" \ Rezaei`, Children: []string{"c"}},
+		{ID: "c", Name: "محمد"},
+	})
+	if !strings.Contains(dot, `"a\"b" [label="Ali \"the elder\" \\ Rezaei"];`) {
+		t.Errorf("node not escaped:\n%s", dot)
+	}
+	if !strings.Contains(dot, `"a\"b" -> "c";`) {
+		t.Errorf("edge not escaped:\n%s", dot)
+	}
+	if !strings.Contains(dot, `"c" [label="محمد"];`) {
+		t.Errorf("Persian label not written as UTF-8:\n%s", dot)
+	}
+}
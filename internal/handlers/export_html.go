@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// subtreePeople returns rootID and everyone below it. Dangling child ids and cycles are skipped.
+func subtreePeople(people []models.Person, rootID string) ([]models.Person, bool) {
+	byID := make(map[string]models.Person, len(people))
+	for _, p := range people {
+		byID[p.ID] = p
+	}
+	if _, ok := byID[rootID]; !ok {
+		return nil, false
+	}
+
+	var result []models.Person
+	visited := map[string]bool{rootID: true}
+	queue := []string{rootID}
+	for len(queue) > 0 {
+		person := byID[queue[0]]
+		queue = queue[1:]
+		result = append(result, person)
+		for _, childID := range person.Children {
+			if _, ok := byID[childID]; ok && !visited[childID] {
+				visited[childID] = true
+				queue = append(queue, childID)
+			}
+		}
+	}
+	return result, true
+}
+
+// ExportHTML exports the tree as a standalone HTML page that draws the hierarchy offline.
+// ?root= limits the export to that person's subtree.
+func (h *FirestoreExportHandler) ExportHTML(c *gin.Context) {
+	people, err := h.exportPeople(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	title := "Family Tree"
+	if rootID := c.Query("root"); rootID != "" {
+		subtree, ok := subtreePeople(people, rootID)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Root person not found"})
+			return
+		}
+		people = subtree
+		title = fmt.Sprintf("Family Tree of %s", people[0].Name)
+	}
+
+	names := personNames(people)
+	exportData := make([]ExportPerson, len(people))
+	for i, p := range people {
+		exportData[i] = toExportPerson(p, names)
+	}
+
+	// json.Marshal escapes <, > and & so the data cannot close the script tag
+	jsonData, err := json.Marshal(exportData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate HTML"})
+		return
+	}
+
+	page := strings.NewReplacer(
+		"{{TITLE}}", html.EscapeString(title),
+		"{{GENERATED}}", html.EscapeString(time.Now().Format("January 2, 2006")),
+		"{{DATA}}", string(jsonData),
+	).Replace(htmlExportTemplate)
+
+	filename := fmt.Sprintf("family-tree-%s.html", time.Now().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+}
+
+// htmlExportTemplate is the self-contained page used by ExportHTML
+const htmlExportTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{TITLE}}</title>
+<style>
+body { font-family: sans-serif; margin: 0; background: #fafafa; }
+header { padding: 12px 16px; border-bottom: 1px solid #ddd; background: #fff; }
+header h1 { margin: 0; font-size: 20px; }
+header p { margin: 4px 0 0; color: #666; font-size: 13px; }
+#tree { overflow: auto; }
+.node rect { fill: #fff; stroke: #4a7; rx: 6; }
+.node text { font-size: 12px; text-anchor: middle; }
+.node .sub { fill: #666; font-size: 10px; }
+.link { stroke: #aaa; fill: none; }
+#details { position: fixed; right: 16px; top: 70px; width: 260px; background: #fff; border: 1px solid #ddd; padding: 12px; display: none; font-size: 13px; }
+</style>
+</head>
+<body>
+<header><h1>{{TITLE}}</h1><p>Generated: {{GENERATED}}</p></header>
+<div id="tree"></div>
+<div id="details"></div>
+<script>
+var people = {{DATA}};
+(function () {
+  var W = 150, H = 40, GX = 20, GY = 70;
+  var byId = {}, hasParent = {};
+  people.forEach(function (p) { byId[p.id] = p; });
+  people.forEach(function (p) {
+    (p.children || []).forEach(function (c) { if (byId[c]) hasParent[c] = true; });
+  });
+
+  var pos = {}, nextX = 0, maxDepth = 0;
+  function layout(id, depth) {
+    if (pos[id]) return pos[id].x;
+    pos[id] = { x: 0, y: depth * (H + GY) };
+    maxDepth = Math.max(maxDepth, depth);
+    var kids = (byId[id].children || []).filter(function (c) { return byId[c] && !pos[c]; });
+    var xs = kids.map(function (c) { return layout(c, depth + 1); });
+    if (xs.length === 0) { pos[id].x = nextX; nextX += W + GX; }
+    else { pos[id].x = (xs[0] + xs[xs.length - 1]) / 2; }
+    return pos[id].x;
+  }
+  people.forEach(function (p) { if (!hasParent[p.id]) layout(p.id, 0); });
+  people.forEach(function (p) { if (!pos[p.id]) layout(p.id, 0); });
+
+  var ns = "http://www.w3.org/2000/svg";
+  function el(name, attrs, parent) {
+    var e = document.createElementNS(ns, name);
+    for (var k in attrs) e.setAttribute(k, attrs[k]);
+    parent.appendChild(e);
+    return e;
+  }
+  var svg = el("svg", { width: Math.max(nextX, W) + 20, height: (maxDepth + 1) * (H + GY) + 20 }, document.getElementById("tree"));
+  var g = el("g", { transform: "translate(10,10)" }, svg);
+
+  people.forEach(function (p) {
+    (p.children || []).forEach(function (c) {
+      if (!byId[c]) return;
+      var a = pos[p.id], b = pos[c], my = (a.y + H + b.y) / 2;
+      el("path", { "class": "link", d: "M" + (a.x + W / 2) + "," + (a.y + H) + " V" + my + " H" + (b.x + W / 2) + " V" + b.y }, g);
+    });
+  });
+
+  var details = document.getElementById("details");
+  function show(p) {
+    details.innerHTML = "";
+    function line(label, value) {
+      if (!value) return;
+      var d = document.createElement("div");
+      var b = document.createElement("b");
+      b.textContent = label + ": ";
+      d.appendChild(b);
+      d.appendChild(document.createTextNode(value));
+      details.appendChild(d);
+    }
+    line("Name", p.name);
+    line("Also known as", (p.alt_names || []).join(", "));
+    line("Role", p.role);
+    line("Occupation", p.occupation);
+    line("Born", p.birth);
+    line("Died", p.death || (p.deceased ? "Deceased" : ""));
+    line("Location", p.location);
+    line("About", p.bio);
+    details.style.display = "block";
+  }
+
+  people.forEach(function (p) {
+    var n = el("g", { "class": "node", transform: "translate(" + pos[p.id].x + "," + pos[p.id].y + ")" }, g);
+    n.style.cursor = "pointer";
+    n.addEventListener("click", function () { show(p); });
+    el("rect", { width: W, height: H }, n);
+    el("text", { x: W / 2, y: 17 }, n).textContent = p.name;
+    el("text", { "class": "sub", x: W / 2, y: 32 }, n).textContent = p.birth || "";
+  });
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// PDF tree layout: indent per generation, capped so deep trees keep room for names
+const (
+	pdfTreeIndent    = 6.0
+	pdfTreeMaxIndent = 15
+	pdfTreeLine      = 6.0
+)
+
+// pdfTreeLabel is the line written for one person: name, role and birth
+func pdfTreeLabel(p models.Person) string {
+	label := p.Name
+	if p.Role != "" {
+		label += " — " + p.Role
+	}
+	if p.Birth != "" {
+		label += fmt.Sprintf(" (b. %s)", p.Birth)
+	}
+	return label
+}
+
+// renderTreePDF writes people as an indented hierarchy following the children arrays.
+// Each person appears once, under the first parent reached. Branches start at rootID when
+// given, then at people listed as nobody's child, then at anyone only reachable through a cycle.
+func renderTreePDF(title string, people []models.Person, rootID string) ([]byte, error) {
+	byID := make(map[string]models.Person, len(people))
+	isChild := make(map[string]bool)
+	for _, p := range people {
+		byID[p.ID] = p
+	}
+	for _, p := range people {
+		for _, childID := range p.Children {
+			if _, ok := byID[childID]; ok && childID != p.ID {
+				isChild[childID] = true
+			}
+		}
+	}
+
+	// The requested root first, then people without a parent here in name order; the
+	// rest follow so cyclic data still gets printed
+	order := make([]models.Person, len(people))
+	copy(order, people)
+	sort.SliceStable(order, func(i, j int) bool {
+		if (order[i].ID == rootID) != (order[j].ID == rootID) {
+			return order[i].ID == rootID
+		}
+		if isChild[order[i].ID] != isChild[order[j].ID] {
+			return !isChild[order[i].ID]
+		}
+		return strings.ToLower(order[i].Name) < strings.ToLower(order[j].Name)
+	})
+
+	d := newPDFDoc(false)
+	pageW, _ := d.pdf.GetPageSize()
+	left, top, right, _ := d.pdf.GetMargins()
+
+	d.centered(title, 18, 10)
+	d.pdf.SetTextColor(120, 120, 120)
+	d.centered(fmt.Sprintf("Generated %s · %d people", time.Now().Format("January 2, 2006"), len(people)), 9, 5)
+	d.pdf.SetTextColor(0, 0, 0)
+	d.rule()
+
+	visited := make(map[string]bool, len(people))
+	var write func(p models.Person, depth int)
+	write = func(p models.Person, depth int) {
+		visited[p.ID] = true
+		if d.remaining() < pdfTreeLine {
+			d.pdf.AddPage()
+			d.pdf.SetY(top)
+		}
+		indent := depth
+		if indent > pdfTreeMaxIndent {
+			indent = pdfTreeMaxIndent
+		}
+		x := left + float64(indent)*pdfTreeIndent
+		label := pdfTreeLabel(p)
+		d.pdf.SetFont(d.family, "", 10)
+		d.pdf.SetX(x)
+		d.pdf.CellFormat(pageW-right-x, pdfTreeLine, d.text(label), "", 1, d.align(label), false, 0, "")
+
+		for _, childID := range p.Children {
+			if child, ok := byID[childID]; ok && !visited[childID] {
+				write(child, depth+1)
+			}
+		}
+	}
+	for _, p := range order {
+		if !visited[p.ID] {
+			write(p, 0)
+		}
+	}
+
+	return d.output()
+}
+
+// ExportPDF exports the tree as a PDF with each person indented under their parent.
+// ?root_id= limits the export to that person's subtree.
+func (h *FirestoreExportHandler) ExportPDF(c *gin.Context) {
+	people, err := h.exportPeople(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	title := "Family Tree"
+	rootID := c.Query("root_id")
+	if rootID != "" {
+		subtree, ok := subtreePeople(people, rootID)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Root person not found"})
+			return
+		}
+		people = subtree
+		title = fmt.Sprintf("Family Tree of %s", people[0].Name)
+	}
+
+	data, err := renderTreePDF(title, people, rootID)
+	if err != nil {
+		log.Printf("[ExportPDF] Failed to render: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", pdfFilename("family-tree")))
+	c.Header("Content-Type", "application/pdf")
+	c.Data(http.StatusOK, "application/pdf", data)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+func TestMaskLivingPeople(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	people := []models.Person{
+		{ID: "living", Birth: "1990-04-12", Location: "Tehran", Bio: "Engineer"},
+		{ID: "unknown", Location: "Shiraz", Bio: "Student"},
+		{ID: "deceased", Birth: "1990-04-12", Deceased: true, Location: "Tabriz", Bio: "Poet"},
+		{ID: "old", Birth: "1900", Location: "Yazd", Bio: "Merchant"},
+	}
+
+	masked := maskLivingPeople(people, now)
+
+	want := []struct{ birth, location, bio string }{
+		{"1990", "", ""},
+		{"Living", "", ""},
+		{"1990-04-12", "Tabriz", "Poet"},
+		{"1900", "Yazd", "Merchant"},
+	}
+	for i, w := range want {
+		p := masked[i]
+		if p.Birth != w.birth || p.Location != w.location || p.Bio != w.bio {
+			t.Errorf("%s: got birth=%q location=%q bio=%q, want %q %q %q",
+				p.ID, p.Birth, p.Location, p.Bio, w.birth, w.location, w.bio)
+		}
+	}
+
+	// The input is shared with the snapshot cache and must stay intact
+	if people[0].Birth != "1990-04-12" || people[0].Bio != "Engineer" {
+		t.Errorf("input modified: %+v", people[0])
+	}
+}
+
+func TestIsPresumedLivingCutoff(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cutoff := now.Year() - livingMaskYears
+	if isPresumedLiving(models.Person{Birth: strconv.Itoa(cutoff)}, now) {
+		t.Errorf("born %d counted as living", cutoff)
+	}
+	if !isPresumedLiving(models.Person{Birth: strconv.Itoa(cutoff + 1)}, now) {
+		t.Errorf("born %d not counted as living", cutoff+1)
+	}
+}
+
+func TestVCardOmitsMaskedBirth(t *testing.T) {
+	masked := maskLivingPeople([]models.Person{{ID: "p1", Name: "Sara", Location: "Tehran"}}, time.Now())[0]
+	card := buildVCard(masked)
+	if strings.Contains(card, "BDAY") || strings.Contains(card, "ADR") {
+		t.Errorf("masked card leaks birth or address:\n%s", card)
+	}
+}
@@ -2,15 +2,20 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"sort"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/mamiri/findyourroot/internal/config"
+	"github.com/mamiri/findyourroot/internal/database"
 	"github.com/mamiri/findyourroot/internal/models"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/api/iterator"
@@ -18,10 +23,11 @@ import (
 
 type FirestoreAuthHandler struct {
 	client *firestore.Client
+	auth   config.Auth
 }
 
-func NewFirestoreAuthHandler(client *firestore.Client) *FirestoreAuthHandler {
-	return &FirestoreAuthHandler{client: client}
+func NewFirestoreAuthHandler(client *firestore.Client, auth config.Auth) *FirestoreAuthHandler {
+	return &FirestoreAuthHandler{client: client, auth: auth}
 }
 
 // Login handles user authentication
@@ -32,10 +38,10 @@ func (h *FirestoreAuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Query user by email
-	iter := h.client.Collection("users").Where("email", "==", req.Email).Limit(1).Documents(ctx)
+	iter := h.coll(ctx, "users").Where("email", "==", req.Email).Limit(1).Documents(ctx)
 	doc, err := iter.Next()
 	if err == iterator.Done {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
@@ -52,13 +58,38 @@ func (h *FirestoreAuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	user.ID = doc.Ref.ID
+
+	// Refuse locked accounts before checking the password
+	if user.LockedUntil.After(time.Now()) {
+		respondAccountLocked(c, user.LockedUntil)
+		return
+	}
+
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		lockedUntil, locked, lockErr := recordFailedLogin(ctx, h.client, user.ID)
+		if lockErr != nil {
+			log.Printf("[Login] Failed to record failed attempt for %s: %v", user.ID, lockErr)
+		}
+		if locked {
+			recordAudit(ctx, h.client, c, "account_lockout", user.ID, map[string]interface{}{
+				"email":        user.Email,
+				"locked_until": lockedUntil,
+			})
+			notifyAccountLocked(user, lockedUntil)
+			respondAccountLocked(c, lockedUntil)
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	user.ID = doc.Ref.ID
+	if user.FailedLoginAttempts > 0 {
+		if _, err := doc.Ref.Update(ctx, []firestore.Update{{Path: "failed_login_attempts", Value: 0}}); err != nil {
+			log.Printf("[Login] Failed to reset failed attempts for %s: %v", user.ID, err)
+		}
+	}
 
 	// Generate JWT token
 	token, err := h.generateToken(user)
@@ -66,16 +97,26 @@ func (h *FirestoreAuthHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
+	refreshToken, err := h.issueRefreshToken(ctx, nil, user.ID, "")
+	if err != nil {
+		log.Printf("[Login] Failed to issue refresh token for %s: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int(h.auth.TokenTTL.Seconds()),
 		"user": gin.H{
 			"id":          user.ID,
 			"email":       user.Email,
 			"role":        user.Role,
 			"is_admin":    user.Role == models.RoleAdmin,
 			"tree_name":   user.TreeName,
+			"tree_id":     user.TreeID,
 			"is_verified": user.IsVerified,
+			"permissions": user.Role.Permissions(),
 		},
 	})
 }
@@ -89,10 +130,10 @@ func (h *FirestoreAuthHandler) ValidateToken(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Get user from Firestore
-	doc, err := h.client.Collection("users").Doc(userID.(string)).Get(ctx)
+	doc, err := h.coll(ctx, "users").Doc(userID.(string)).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
@@ -110,7 +151,7 @@ func (h *FirestoreAuthHandler) ValidateToken(c *gin.Context) {
 	// Query: find person where linked_user_id == this user's ID
 	var personID string
 	var personName string
-	personIter := h.client.Collection("people").Where("linked_user_id", "==", user.ID).Limit(1).Documents(ctx)
+	personIter := h.coll(ctx, "people").Where("linked_user_id", "==", user.ID).Limit(1).Documents(ctx)
 	personDoc, err := personIter.Next()
 	if err == nil {
 		var person models.Person
@@ -132,11 +173,13 @@ func (h *FirestoreAuthHandler) ValidateToken(c *gin.Context) {
 			"is_verified": user.IsVerified,
 			"person_id":   personID,   // Derived from Person.LinkedUserID
 			"person_name": personName, // For display
+			"permissions": user.Role.Permissions(),
+			"onboarding":  buildOnboarding(ctx, h.client, user, personID != ""),
 		},
 	})
 }
 
-// generateToken creates a JWT token
+// generateToken creates a JWT access token valid for the configured TOKEN_TTL
 func (h *FirestoreAuthHandler) generateToken(user models.User) (string, error) {
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
@@ -148,9 +191,10 @@ func (h *FirestoreAuthHandler) generateToken(user models.User) (string, error) {
 		"email":    user.Email,
 		"is_admin": user.IsAdmin,
 		"role":     string(user.Role),
+		"tree_id":  user.TreeID,
 		"iss":      "findyourroot-api",
 		"sub":      user.ID,
-		"exp":      time.Now().Add(24 * time.Hour).Unix(),
+		"exp":      time.Now().Add(h.auth.TokenTTL).Unix(),
 		"nbf":      time.Now().Unix(),
 		"iat":      time.Now().Unix(),
 	}
@@ -159,7 +203,11 @@ func (h *FirestoreAuthHandler) generateToken(user models.User) (string, error) {
 	return token.SignedString([]byte(jwtSecret))
 }
 
-// Register creates a new user with 'viewer' role by default
+// minPasswordLength is the shortest password accepted at registration
+const minPasswordLength = 6
+
+// Register creates a new user with 'viewer' role by default. A valid invite_code instead
+// gives the invite's tree and role and verifies the user outright.
 func (h *FirestoreAuthHandler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -172,93 +220,39 @@ func (h *FirestoreAuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	if len(req.Password) < 6 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at least 6 characters"})
+	if len(req.Password) < minPasswordLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Password must be at least %d characters", minPasswordLength)})
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
-	// Fetch configured tree name from settings
-	settingsDoc, err := h.client.Collection("settings").Doc("tree").Get(ctx)
-	var configuredTreeName string
-	if err == nil {
-		if tn, ok := settingsDoc.Data()["tree_name"].(string); ok && tn != "" {
-			configuredTreeName = tn
+	// The tree to join: one from the trees collection, or the original tree named in settings.
+	// With an invite code the invite decides, once it is consumed below.
+	var tree models.Tree
+	if req.InviteCode == "" {
+		var err error
+		tree, err = resolveRegistrationTree(ctx, h.client, req.TreeID, req.TreeName)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 	}
 
-	// Validate tree name - must match the admin-configured tree name
-	if configuredTreeName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No tree has been created yet. Please contact admin."})
-		return
-	}
-	if req.TreeName != configuredTreeName {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tree name. The available tree is: " + configuredTreeName})
-		return
-	}
-
 	// Check if user already exists
-	iter := h.client.Collection("users").Where("email", "==", req.Email).Limit(1).Documents(ctx)
-	_, err = iter.Next()
+	iter := h.coll(ctx, "users").Where("email", "==", req.Email).Limit(1).Documents(ctx)
+	_, err := iter.Next()
 	if err != iterator.Done {
 		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 		return
 	}
 
-	// Verify user exists in the family tree by father's name and birth year
-	peopleIter := h.client.Collection("people").Where("birth", "==", req.BirthYear).Documents(ctx)
-	defer peopleIter.Stop()
-
-	var foundMatch bool
-	for {
-		doc, err := peopleIter.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			continue
-		}
-
-		var person models.Person
-		if err := doc.DataTo(&person); err != nil {
-			continue
-		}
-
-		// Find this person's parent and check if father's name matches
-		parentsIter := h.client.Collection("people").Where("children", "array-contains", person.ID).Documents(ctx)
-		for {
-			parentDoc, err := parentsIter.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				continue
-			}
-
-			var parent models.Person
-			if err := parentDoc.DataTo(&parent); err != nil {
-				continue
-			}
-
-			// Check if this parent's name contains the father's name
-			if parent.Name == req.FatherName ||
-				(len(parent.Name) > 0 && len(req.FatherName) > 0 &&
-					(parent.Name == req.FatherName ||
-						(len(parent.Name) >= len(req.FatherName) && parent.Name[:len(req.FatherName)] == req.FatherName))) {
-				foundMatch = true
-				break
-			}
-		}
-		parentsIter.Stop()
-
-		if foundMatch {
-			break
-		}
-	}
+	// Verify user exists in the family tree they are joining by father's name and birth year
+	match := matchTreeMembership(database.WithTree(ctx, tree.ID), h.client, req.FatherName, req.BirthYear)
+	foundMatch := match != nil
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), h.auth.BcryptCost)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user"})
 		return
@@ -271,7 +265,8 @@ func (h *FirestoreAuthHandler) Register(c *gin.Context) {
 		PasswordHash: string(hashedPassword),
 		Role:         models.RoleViewer,
 		IsAdmin:      false,
-		TreeName:     req.TreeName,
+		TreeName:     tree.Name,
+		TreeID:       tree.ID,
 		FatherName:   req.FatherName,
 		BirthYear:    req.BirthYear,
 		IsVerified:   foundMatch,
@@ -279,13 +274,34 @@ func (h *FirestoreAuthHandler) Register(c *gin.Context) {
 		UpdatedAt:    now,
 	}
 
-	docRef, _, err := h.client.Collection("users").Add(ctx, user)
+	userRef := h.coll(ctx, "users").NewDoc()
+	user.ID = userRef.ID
+	if req.InviteCode == "" {
+		_, err = userRef.Create(ctx, user)
+	} else {
+		// The invite's use is taken in the same transaction that creates the user
+		inviteRef := h.coll(ctx, "invites").Doc(hashToken(strings.TrimSpace(req.InviteCode)))
+		err = h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			invite, err := consumeInvite(tx, inviteRef)
+			if err != nil {
+				return err
+			}
+			user.Role = invite.Role
+			user.TreeID = invite.TreeID
+			user.TreeName = invite.TreeName
+			user.IsVerified = true
+			return tx.Create(userRef, user)
+		})
+	}
+	if errors.Is(err, errInvalidInvite) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired invite code"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user"})
 		return
 	}
-
-	user.ID = docRef.ID
+	tree = models.Tree{ID: user.TreeID, Name: user.TreeName}
 
 	// Generate token
 	token, err := h.generateToken(user)
@@ -293,20 +309,29 @@ func (h *FirestoreAuthHandler) Register(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
 		return
 	}
+	refreshToken, err := h.issueRefreshToken(ctx, nil, user.ID, "")
+	if err != nil {
+		log.Printf("[Register] Failed to issue refresh token for %s: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"token": token,
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int(h.auth.TokenTTL.Seconds()),
 		"user": gin.H{
 			"id":          user.ID,
 			"email":       user.Email,
 			"role":        user.Role,
 			"is_admin":    false,
 			"tree_name":   user.TreeName,
+			"tree_id":     user.TreeID,
 			"is_verified": user.IsVerified,
 		},
 		"message": func() string {
 			if user.IsVerified {
-				return "Account created and verified! You are part of the Batur family tree."
+				return fmt.Sprintf("Account created and verified! You are part of the %s family tree.", tree.Name)
 			}
 			return "Account created. Verification pending - we couldn't automatically match your information to the tree. An admin will review your details."
 		}(),
@@ -328,16 +353,19 @@ func (h *FirestoreAuthHandler) RequestPermission(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
 		return
 	}
+	if !sanitizeFields(c, &req.Message) {
+		return
+	}
 
 	if req.RequestedRole != models.RoleContributor && req.RequestedRole != models.RoleCoAdmin && req.RequestedRole != models.RoleAdmin {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role requested. Must be 'contributor', 'co-admin', or 'admin'"})
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Check for existing pending requests
-	iter := h.client.Collection("permission_requests").
+	iter := h.coll(ctx, "permission_requests").
 		Where("user_id", "==", userID).
 		Where("status", "==", "pending").
 		Documents(ctx)
@@ -359,7 +387,7 @@ func (h *FirestoreAuthHandler) RequestPermission(c *gin.Context) {
 		UpdatedAt:     now,
 	}
 
-	docRef, _, err := h.client.Collection("permission_requests").Add(ctx, permReq)
+	docRef, _, err := h.coll(ctx, "permission_requests").Add(ctx, permReq)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating permission request"})
 		return
@@ -384,9 +412,9 @@ func (h *FirestoreAuthHandler) GetPermissionRequests(c *gin.Context) {
 		status = "pending"
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 	// Query without OrderBy to avoid needing composite index
-	iter := h.client.Collection("permission_requests").
+	iter := h.coll(ctx, "permission_requests").
 		Where("status", "==", status).
 		Documents(ctx)
 
@@ -440,10 +468,10 @@ func (h *FirestoreAuthHandler) ApprovePermissionRequest(c *gin.Context) {
 
 	requestID := c.Param("id")
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Get the permission request
-	doc, err := h.client.Collection("permission_requests").Doc(requestID).Get(ctx)
+	doc, err := h.coll(ctx, "permission_requests").Doc(requestID).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Permission request not found"})
 		return
@@ -460,27 +488,7 @@ func (h *FirestoreAuthHandler) ApprovePermissionRequest(c *gin.Context) {
 		return
 	}
 
-	// Use the requested role from the permission request
-	newRole := req.RequestedRole
-	isAdmin := newRole == models.RoleAdmin
-
-	// Update user role
-	_, err = h.client.Collection("users").Doc(req.UserID).Update(ctx, []firestore.Update{
-		{Path: "role", Value: newRole},
-		{Path: "is_admin", Value: isAdmin},
-		{Path: "updated_at", Value: time.Now()},
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating user"})
-		return
-	}
-
-	// Update permission request status
-	_, err = h.client.Collection("permission_requests").Doc(requestID).Update(ctx, []firestore.Update{
-		{Path: "status", Value: "approved"},
-		{Path: "updated_at", Value: time.Now()},
-	})
-	if err != nil {
+	if err := h.resolvePermissionRequest(ctx, requestID, req, true, ""); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating request"})
 		return
 	}
@@ -488,10 +496,46 @@ func (h *FirestoreAuthHandler) ApprovePermissionRequest(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Permission request approved",
 		"user":    req.UserEmail,
-		"role":    newRole,
+		"role":    req.RequestedRole,
 	})
 }
 
+// resolvePermissionRequest approves (granting the requested role) or rejects a pending request
+func (h *FirestoreAuthHandler) resolvePermissionRequest(ctx context.Context, requestID string, req models.PermissionRequest, approved bool, notes string) error {
+	newStatus := "rejected"
+	if approved {
+		newStatus = "approved"
+
+		// Use the requested role from the permission request
+		newRole := req.RequestedRole
+		isAdmin := newRole == models.RoleAdmin
+
+		// Update user role
+		_, err := h.coll(ctx, "users").Doc(req.UserID).Update(ctx, []firestore.Update{
+			{Path: "role", Value: newRole},
+			{Path: "is_admin", Value: isAdmin},
+			{Path: "updated_at", Value: time.Now()},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Update permission request status
+	updates := []firestore.Update{
+		{Path: "status", Value: newStatus},
+		{Path: "updated_at", Value: time.Now()},
+	}
+	if notes != "" {
+		updates = append(updates, firestore.Update{Path: "review_notes", Value: notes})
+	}
+	if _, err := h.coll(ctx, "permission_requests").Doc(requestID).Update(ctx, updates); err != nil {
+		return err
+	}
+	notifyPermissionReviewed(h.client, req, newStatus, notes)
+	return nil
+}
+
 // RejectPermissionRequest rejects a permission request (admin only)
 func (h *FirestoreAuthHandler) RejectPermissionRequest(c *gin.Context) {
 	role, exists := c.Get("role")
@@ -501,10 +545,10 @@ func (h *FirestoreAuthHandler) RejectPermissionRequest(c *gin.Context) {
 	}
 
 	requestID := c.Param("id")
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Get the permission request
-	doc, err := h.client.Collection("permission_requests").Doc(requestID).Get(ctx)
+	doc, err := h.coll(ctx, "permission_requests").Doc(requestID).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Permission request not found"})
 		return
@@ -521,12 +565,7 @@ func (h *FirestoreAuthHandler) RejectPermissionRequest(c *gin.Context) {
 		return
 	}
 
-	// Update permission request status
-	_, err = h.client.Collection("permission_requests").Doc(requestID).Update(ctx, []firestore.Update{
-		{Path: "status", Value: "rejected"},
-		{Path: "updated_at", Value: time.Now()},
-	})
-	if err != nil {
+	if err := h.resolvePermissionRequest(ctx, requestID, req, false, ""); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating request"})
 		return
 	}
@@ -540,7 +579,7 @@ func (h *FirestoreAuthHandler) RejectPermissionRequest(c *gin.Context) {
 // GetAllUsers returns all users (admin only)
 // PersonID is derived from Person.LinkedUserID - Person owns the relationship
 func (h *FirestoreAuthHandler) GetAllUsers(c *gin.Context) {
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Build a map of userID -> (personID, personName) from the Person collection
 	// Person is the OWNER of the link relationship
@@ -549,7 +588,7 @@ func (h *FirestoreAuthHandler) GetAllUsers(c *gin.Context) {
 		PersonName string
 	})
 
-	peopleIter := h.client.Collection("people").Documents(ctx)
+	peopleIter := h.coll(ctx, "people").Documents(ctx)
 	for {
 		doc, err := peopleIter.Next()
 		if err == iterator.Done {
@@ -573,7 +612,7 @@ func (h *FirestoreAuthHandler) GetAllUsers(c *gin.Context) {
 	}
 	peopleIter.Stop()
 
-	iter := h.client.Collection("users").Documents(ctx)
+	iter := h.coll(ctx, "users").Documents(ctx)
 	defer iter.Stop()
 
 	var users []models.UserListResponse
@@ -587,6 +626,9 @@ func (h *FirestoreAuthHandler) GetAllUsers(c *gin.Context) {
 			return
 		}
 
+		if !userInTree(ctx, doc) {
+			continue
+		}
 		var user models.User
 		if err := doc.DataTo(&user); err != nil {
 			continue
@@ -648,11 +690,11 @@ func (h *FirestoreAuthHandler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Get the target user
-	doc, err := h.client.Collection("users").Doc(targetUserID).Get(ctx)
-	if err != nil {
+	doc, err := h.coll(ctx, "users").Doc(targetUserID).Get(ctx)
+	if err != nil || !userInTree(ctx, doc) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -665,7 +707,7 @@ func (h *FirestoreAuthHandler) UpdateUserRole(c *gin.Context) {
 
 	// Update user role
 	isAdmin := req.Role == models.RoleAdmin
-	_, err = h.client.Collection("users").Doc(targetUserID).Update(ctx, []firestore.Update{
+	_, err = h.coll(ctx, "users").Doc(targetUserID).Update(ctx, []firestore.Update{
 		{Path: "role", Value: req.Role},
 		{Path: "is_admin", Value: isAdmin},
 		{Path: "updated_at", Value: time.Now()},
@@ -693,11 +735,11 @@ func (h *FirestoreAuthHandler) RevokeUserAccess(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Get the target user
-	doc, err := h.client.Collection("users").Doc(targetUserID).Get(ctx)
-	if err != nil {
+	doc, err := h.coll(ctx, "users").Doc(targetUserID).Get(ctx)
+	if err != nil || !userInTree(ctx, doc) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -709,7 +751,7 @@ func (h *FirestoreAuthHandler) RevokeUserAccess(c *gin.Context) {
 	}
 
 	// Set role to viewer
-	_, err = h.client.Collection("users").Doc(targetUserID).Update(ctx, []firestore.Update{
+	_, err = h.coll(ctx, "users").Doc(targetUserID).Update(ctx, []firestore.Update{
 		{Path: "role", Value: models.RoleViewer},
 		{Path: "is_admin", Value: false},
 		{Path: "updated_at", Value: time.Now()},
@@ -2,16 +2,19 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
 	"google.golang.org/api/iterator"
 )
 
@@ -33,6 +36,13 @@ func (h *FirestoreSuggestionHandler) CreateSuggestion(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
 		return
 	}
+	fields := []*string{&req.Message}
+	if req.PersonData != nil {
+		fields = append(fields, &req.PersonData.Bio)
+	}
+	if !sanitizeFields(c, fields...) {
+		return
+	}
 
 	// Validate suggestion type
 	if req.Type != models.SuggestionAdd && req.Type != models.SuggestionEdit && req.Type != models.SuggestionDelete {
@@ -63,6 +73,56 @@ func (h *FirestoreSuggestionHandler) CreateSuggestion(c *gin.Context) {
 		}
 	}
 
+	if req.PersonData != nil && len(req.PersonData.ClearedFields) > 0 {
+		if req.Type != models.SuggestionEdit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cleared_fields is only allowed in edit suggestions"})
+			return
+		}
+		cleared, err := cleanClearedFields(req.PersonData)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.PersonData.ClearedFields = cleared
+	}
+
+	if req.PersonData != nil && req.PersonData.Birth != "" {
+		if err := validateBirth(req.PersonData.Birth); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.PersonData != nil && req.PersonData.Death != "" {
+		if err := validateDeath(req.PersonData.Death, req.PersonData.Birth); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.PersonData.Deceased = true
+	}
+
+	if req.PersonData != nil && req.PersonData.AltNames != nil {
+		altNames, err := utils.CleanAltNames(req.PersonData.AltNames)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.PersonData.AltNames = altNames
+	}
+
+	// Enforce the tree's contributor field permissions (co-admins/admins are exempt)
+	role, _ := c.Get("role")
+	if req.PersonData != nil && !models.UserRole(role.(string)).CanEditDirectly() {
+		settings := loadTreeSettings(treeContext(c), h.client)
+		if disallowed := disallowedSuggestionFields(req.Type, req.PersonData, settings.ContributorFields); len(disallowed) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":             "Contributors may not suggest changes to some of these fields",
+				"disallowed_fields": disallowed,
+				"allowed_fields":    settings.ContributorFields,
+			})
+			return
+		}
+	}
+
 	if req.Type == models.SuggestionDelete {
 		if req.TargetPersonID == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "target_person_id is required for delete suggestions"})
@@ -70,11 +130,31 @@ func (h *FirestoreSuggestionHandler) CreateSuggestion(c *gin.Context) {
 		}
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
+
+	// Cap how many suggestions a contributor can have waiting for review (approvers are exempt)
+	if !models.UserRole(role.(string)).CanApprove() {
+		pending, err := countQuery(ctx, h.coll(ctx, "suggestions").
+			Where("user_id", "==", userID.(string)).
+			Where("status", "==", "pending"))
+		if err != nil {
+			log.Printf("[Suggestion] Failed to count pending suggestions for %s: %v", email, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check pending suggestions"})
+			return
+		}
+		if pending >= int64(maxPendingSuggestions) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   fmt.Sprintf("You already have %d suggestions waiting for review. Please wait until some are reviewed before adding more.", pending),
+				"pending": pending,
+				"limit":   maxPendingSuggestions,
+			})
+			return
+		}
+	}
 
 	// For edit/delete, verify the target person exists
 	if req.Type == models.SuggestionEdit || req.Type == models.SuggestionDelete {
-		_, err := h.client.Collection("people").Doc(req.TargetPersonID).Get(ctx)
+		_, err := h.coll(ctx, "people").Doc(req.TargetPersonID).Get(ctx)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Target person not found"})
 			return
@@ -83,7 +163,7 @@ func (h *FirestoreSuggestionHandler) CreateSuggestion(c *gin.Context) {
 
 	// For add with parent, verify parent exists
 	if req.Type == models.SuggestionAdd && req.TargetPersonID != "" {
-		_, err := h.client.Collection("people").Doc(req.TargetPersonID).Get(ctx)
+		_, err := h.coll(ctx, "people").Doc(req.TargetPersonID).Get(ctx)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Parent person not found"})
 			return
@@ -100,11 +180,12 @@ func (h *FirestoreSuggestionHandler) CreateSuggestion(c *gin.Context) {
 		Status:         "pending",
 		UserID:         userID.(string),
 		UserEmail:      email.(string),
+		TreeID:         requestTreeID(c),
 		CreatedAt:      now,
 		UpdatedAt:      now,
 	}
 
-	_, err := h.client.Collection("suggestions").Doc(suggestion.ID).Set(ctx, suggestion)
+	_, err := h.coll(ctx, "suggestions").Doc(suggestion.ID).Set(ctx, suggestion)
 	if err != nil {
 		log.Printf("[Suggestion] Error creating suggestion: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create suggestion"})
@@ -119,30 +200,168 @@ func (h *FirestoreSuggestionHandler) CreateSuggestion(c *gin.Context) {
 	})
 }
 
+// suggestionFields lists the PersonData fields (by JSON name) a suggestion can carry
+var suggestionFields = []string{
+	"name", "alt_names", "role", "occupation", "gender", "birth", "death", "deceased",
+	"location", "avatar", "bio", "instagram_username", "instagram_avatar_url",
+}
+
+// clearableFields are the optional fields an edit suggestion may empty via cleared_fields.
+// Clearing "deceased" also removes the death date.
+var clearableFields = []string{"alt_names", "occupation", "death", "deceased", "location", "avatar", "bio"}
+
+// cleanClearedFields validates and de-duplicates pd.ClearedFields. A field can't be
+// both cleared and given a new value.
+func cleanClearedFields(pd *models.PersonData) ([]string, error) {
+	set := make(map[string]bool)
+	for _, field := range suggestionFieldsUsed(pd) {
+		set[field] = true
+	}
+	var cleared []string
+	for _, field := range pd.ClearedFields {
+		field = strings.TrimSpace(field)
+		switch {
+		case !containsString(clearableFields, field):
+			return nil, fmt.Errorf("%q cannot be cleared (allowed: %s)", field, strings.Join(clearableFields, ", "))
+		case set[field]:
+			return nil, fmt.Errorf("%q cannot be both set and cleared", field)
+		case field == "deceased" && pd.Death != "":
+			return nil, errors.New("deceased cannot be cleared while setting a death date")
+		}
+		if !containsString(cleared, field) {
+			cleared = append(cleared, field)
+		}
+	}
+	return cleared, nil
+}
+
+// suggestionFieldsUsed returns the PersonData fields that are set
+func suggestionFieldsUsed(pd *models.PersonData) []string {
+	values := map[string]bool{
+		"name":                 pd.Name != "",
+		"alt_names":            len(pd.AltNames) > 0,
+		"role":                 pd.Role != "",
+		"occupation":           pd.Occupation != "",
+		"gender":               pd.Gender != "",
+		"birth":                pd.Birth != "",
+		"death":                pd.Death != "",
+		"deceased":             pd.Deceased,
+		"location":             pd.Location != "",
+		"avatar":               pd.Avatar != "",
+		"bio":                  pd.Bio != "",
+		"instagram_username":   pd.InstagramUsername != "",
+		"instagram_avatar_url": pd.InstagramAvatarURL != "",
+	}
+	var used []string
+	for _, field := range suggestionFields {
+		if values[field] {
+			used = append(used, field)
+		}
+	}
+	return used
+}
+
+// suggestionFieldsTouched returns the fields a suggestion sets or clears
+func suggestionFieldsTouched(pd *models.PersonData) []string {
+	touched := suggestionFieldsUsed(pd)
+	for _, field := range pd.ClearedFields {
+		if !containsString(touched, field) {
+			touched = append(touched, field)
+		}
+	}
+	return touched
+}
+
+// disallowedSuggestionFields returns the fields in pd that contributors may not set.
+// An empty allowed list permits everything; add suggestions may always set the fields
+// they require (name, role, birth).
+func disallowedSuggestionFields(suggestionType models.SuggestionType, pd *models.PersonData, allowed []string) []string {
+	if len(allowed) == 0 {
+		return nil
+	}
+	permitted := make(map[string]bool, len(allowed))
+	for _, field := range allowed {
+		permitted[field] = true
+	}
+	if suggestionType == models.SuggestionAdd {
+		permitted["name"], permitted["role"], permitted["birth"] = true, true, true
+	}
+
+	var disallowed []string
+	for _, field := range suggestionFieldsTouched(pd) {
+		if !permitted[field] {
+			disallowed = append(disallowed, field)
+		}
+	}
+	return disallowed
+}
+
 // GetMySuggestions returns suggestions created by the current user
 func (h *FirestoreSuggestionHandler) GetMySuggestions(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	status := c.DefaultQuery("status", "")
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
-	query := h.client.Collection("suggestions").Where("user_id", "==", userID.(string))
+	query := h.coll(ctx, "suggestions").Where("user_id", "==", userID.(string))
 	if status != "" {
 		query = query.Where("status", "==", status)
 	}
 
+	suggestions, err := h.collectSuggestions(ctx, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// GetMyPersonSuggestions returns pending suggestions targeting the caller's linked person,
+// so members can see (and flag) proposed changes to their own profile
+func (h *FirestoreSuggestionHandler) GetMyPersonSuggestions(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	ctx := treeContext(c)
+
+	linked, err := linkedPersonIDs(ctx, h.client, userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked person"})
+		return
+	}
+	if len(linked) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You are not linked to a person in the tree"})
+		return
+	}
+
+	query := h.coll(ctx, "suggestions").
+		Where("target_person_id", "==", linked[0]).
+		Where("status", "==", "pending")
+
+	suggestions, err := h.collectSuggestions(ctx, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"person_id":   linked[0],
+		"suggestions": suggestions,
+	})
+}
+
+// collectSuggestions runs a suggestion query and returns responses, newest first
+func (h *FirestoreSuggestionHandler) collectSuggestions(ctx context.Context, query firestore.Query) ([]models.SuggestionResponse, error) {
 	iter := query.Documents(ctx)
 	defer iter.Stop()
 
-	var suggestions []models.SuggestionResponse
+	suggestions := []models.SuggestionResponse{}
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
-			return
+			return nil, err
 		}
 
 		var s models.Suggestion
@@ -150,12 +369,7 @@ func (h *FirestoreSuggestionHandler) GetMySuggestions(c *gin.Context) {
 			continue
 		}
 
-		resp := h.suggestionToResponse(ctx, s)
-		suggestions = append(suggestions, resp)
-	}
-
-	if suggestions == nil {
-		suggestions = []models.SuggestionResponse{}
+		suggestions = append(suggestions, h.suggestionToResponse(ctx, s))
 	}
 
 	// Sort by created_at descending
@@ -165,20 +379,26 @@ func (h *FirestoreSuggestionHandler) GetMySuggestions(c *gin.Context) {
 		return ti.After(tj)
 	})
 
-	c.JSON(http.StatusOK, suggestions)
+	return suggestions, nil
 }
 
 // GetAllSuggestions returns all suggestions (for admins/co-admins)
+// Optional ?assigned_to=me|unassigned|<user_id> filters by active review claim
 func (h *FirestoreSuggestionHandler) GetAllSuggestions(c *gin.Context) {
 	status := c.DefaultQuery("status", "pending")
+	assignedTo := c.Query("assigned_to")
 	email, _ := c.Get("email")
 	role, _ := c.Get("role")
+	if assignedTo == "me" {
+		userID, _ := c.Get("user_id")
+		assignedTo, _ = userID.(string)
+	}
 
-	log.Printf("[GetAllSuggestions] Request from %s (role: %s), filter status: %s", email, role, status)
+	log.Printf("[GetAllSuggestions] Request from %s (role: %s), filter status: %s, assigned_to: %s", email, role, status, assignedTo)
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
-	iter := h.client.Collection("suggestions").Where("status", "==", status).Documents(ctx)
+	iter := h.coll(ctx, "suggestions").Where("status", "==", status).Documents(ctx)
 	defer iter.Stop()
 
 	var suggestions []models.SuggestionResponse
@@ -199,6 +419,16 @@ func (h *FirestoreSuggestionHandler) GetAllSuggestions(c *gin.Context) {
 			continue
 		}
 
+		if assignedTo != "" {
+			reviewer := activeReviewer(s)
+			if assignedTo == "unassigned" && reviewer != "" {
+				continue
+			}
+			if assignedTo != "unassigned" && reviewer != assignedTo {
+				continue
+			}
+		}
+
 		resp := h.suggestionToResponse(ctx, s)
 		suggestions = append(suggestions, resp)
 	}
@@ -219,6 +449,130 @@ func (h *FirestoreSuggestionHandler) GetAllSuggestions(c *gin.Context) {
 	c.JSON(http.StatusOK, suggestions)
 }
 
+// maxPendingSuggestions is how many pending suggestions one contributor may have at a time
+// (MAX_PENDING_SUGGESTIONS, default 50). Approvers are not limited.
+var maxPendingSuggestions = envPositiveInt("MAX_PENDING_SUGGESTIONS", 50)
+
+// reviewClaimTimeout is how long a review claim lasts before the suggestion returns to the shared queue
+const reviewClaimTimeout = 30 * time.Minute
+
+var (
+	errNotFound        = errors.New("suggestion not found")
+	errAlreadyReviewed = errors.New("suggestion already reviewed")
+	errClaimedByOther  = errors.New("suggestion claimed by another approver")
+)
+
+// activeReviewer returns the user currently holding the review claim, or "" if unclaimed/expired
+func activeReviewer(s models.Suggestion) string {
+	if s.ReviewingBy == "" || time.Since(s.ReviewingAt) > reviewClaimTimeout {
+		return ""
+	}
+	return s.ReviewingBy
+}
+
+// AssignSuggestion claims a pending suggestion for review by the current approver
+// Fails with 409 if another approver holds an active claim
+func (h *FirestoreSuggestionHandler) AssignSuggestion(c *gin.Context) {
+	suggestionID := c.Param("id")
+	userID, _ := c.Get("user_id")
+	email, _ := c.Get("email")
+	reviewerID := userID.(string)
+
+	ctx := treeContext(c)
+	ref := h.coll(ctx, "suggestions").Doc(suggestionID)
+
+	var suggestion models.Suggestion
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return errNotFound
+		}
+		if err := doc.DataTo(&suggestion); err != nil {
+			return err
+		}
+		if suggestion.Status != "pending" {
+			return errAlreadyReviewed
+		}
+		if reviewer := activeReviewer(suggestion); reviewer != "" && reviewer != reviewerID {
+			return errClaimedByOther
+		}
+
+		now := time.Now()
+		suggestion.ReviewingBy = reviewerID
+		suggestion.ReviewingEmail, _ = email.(string)
+		suggestion.ReviewingAt = now
+		return tx.Update(ref, []firestore.Update{
+			{Path: "reviewing_by", Value: suggestion.ReviewingBy},
+			{Path: "reviewing_email", Value: suggestion.ReviewingEmail},
+			{Path: "reviewing_at", Value: now},
+		})
+	})
+
+	switch err {
+	case nil:
+	case errNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Suggestion not found"})
+		return
+	case errAlreadyReviewed:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Suggestion has already been reviewed"})
+		return
+	case errClaimedByOther:
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           "Suggestion is already being reviewed by another approver",
+			"reviewing_email": suggestion.ReviewingEmail,
+		})
+		return
+	default:
+		log.Printf("[AssignSuggestion] Error assigning suggestion %s: %v", suggestionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign suggestion"})
+		return
+	}
+
+	log.Printf("[AssignSuggestion] Suggestion %s claimed by %s", suggestionID, email)
+	c.JSON(http.StatusOK, h.suggestionToResponse(ctx, suggestion))
+}
+
+// UnassignSuggestion releases the current approver's review claim
+func (h *FirestoreSuggestionHandler) UnassignSuggestion(c *gin.Context) {
+	suggestionID := c.Param("id")
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	ctx := treeContext(c)
+	ref := h.coll(ctx, "suggestions").Doc(suggestionID)
+
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Suggestion not found"})
+		return
+	}
+
+	var suggestion models.Suggestion
+	if err := doc.DataTo(&suggestion); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse suggestion"})
+		return
+	}
+
+	// Admins may release anyone's claim; others only their own
+	reviewer := activeReviewer(suggestion)
+	if reviewer != "" && reviewer != userID.(string) && role != string(models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Suggestion is claimed by another approver"})
+		return
+	}
+
+	_, err = ref.Update(ctx, []firestore.Update{
+		{Path: "reviewing_by", Value: ""},
+		{Path: "reviewing_email", Value: ""},
+		{Path: "reviewing_at", Value: time.Time{}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unassign suggestion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suggestion unassigned"})
+}
+
 // ReviewSuggestion approves or rejects a suggestion (admins/co-admins)
 func (h *FirestoreSuggestionHandler) ReviewSuggestion(c *gin.Context) {
 	suggestionID := c.Param("id")
@@ -230,11 +584,14 @@ func (h *FirestoreSuggestionHandler) ReviewSuggestion(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
 		return
 	}
+	if !sanitizeFields(c, &req.ReviewNotes) {
+		return
+	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Get the suggestion
-	doc, err := h.client.Collection("suggestions").Doc(suggestionID).Get(ctx)
+	doc, err := h.coll(ctx, "suggestions").Doc(suggestionID).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Suggestion not found"})
 		return
@@ -267,11 +624,12 @@ func (h *FirestoreSuggestionHandler) ReviewSuggestion(c *gin.Context) {
 	}
 
 	// Update suggestion status
-	_, err = h.client.Collection("suggestions").Doc(suggestionID).Update(ctx, []firestore.Update{
+	_, err = h.coll(ctx, "suggestions").Doc(suggestionID).Update(ctx, []firestore.Update{
 		{Path: "status", Value: newStatus},
 		{Path: "reviewed_by", Value: reviewerID.(string)},
 		{Path: "reviewer_email", Value: reviewerEmail.(string)},
 		{Path: "review_notes", Value: req.ReviewNotes},
+		{Path: "reviewing_by", Value: ""},
 		{Path: "updated_at", Value: now},
 	})
 	if err != nil {
@@ -280,6 +638,7 @@ func (h *FirestoreSuggestionHandler) ReviewSuggestion(c *gin.Context) {
 	}
 
 	log.Printf("[Suggestion] Suggestion %s %s by %s", suggestionID, newStatus, reviewerEmail)
+	notifySuggestionReviewed(h.client, suggestion, newStatus, req.ReviewNotes)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": fmt.Sprintf("Suggestion %s", newStatus),
@@ -312,24 +671,30 @@ func (h *FirestoreSuggestionHandler) executeAdd(ctx context.Context, s models.Su
 	}
 
 	person := models.Person{
-		ID:        id,
-		Name:      s.PersonData.Name,
-		Role:      s.PersonData.Role,
-		Gender:    s.PersonData.Gender,
-		Birth:     s.PersonData.Birth,
-		Location:  s.PersonData.Location,
-		Avatar:    avatar,
-		Bio:       s.PersonData.Bio,
-		Children:  []string{},
-		CreatedBy: s.UserID,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:         id,
+		Name:       s.PersonData.Name,
+		AltNames:   s.PersonData.AltNames,
+		Role:       s.PersonData.Role,
+		Occupation: s.PersonData.Occupation,
+		Gender:     s.PersonData.Gender,
+		Birth:      s.PersonData.Birth,
+		Death:      s.PersonData.Death,
+		Deceased:   s.PersonData.Deceased || s.PersonData.Death != "",
+		Location:   s.PersonData.Location,
+		Avatar:     avatar,
+		Bio:        s.PersonData.Bio,
+		Children:   []string{},
+		ParentIDs:  uniqueIDs([]string{s.TargetPersonID}),
+		CreatedBy:  s.UserID,
+		TreeID:     s.TreeID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
 
 	// If parent ID provided, use transaction to add person and update parent
 	if s.TargetPersonID != "" {
 		return h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
-			parentRef := h.client.Collection("people").Doc(s.TargetPersonID)
+			parentRef := h.coll(ctx, "people").Doc(s.TargetPersonID)
 			parentDoc, err := tx.Get(parentRef)
 			if err != nil {
 				return fmt.Errorf("parent not found: %v", err)
@@ -341,13 +706,16 @@ func (h *FirestoreSuggestionHandler) executeAdd(ctx context.Context, s models.Su
 			}
 
 			// Create the new person
-			personRef := h.client.Collection("people").Doc(id)
+			personRef := h.coll(ctx, "people").Doc(id)
 			if err := tx.Set(personRef, person); err != nil {
 				return err
 			}
 
 			// Update parent's children
 			newChildren := append(parent.Children, id)
+			if _, err := validateChildren(ctx, h.client, parent.ID, newChildren, false); err != nil {
+				return err
+			}
 			if err := tx.Update(parentRef, []firestore.Update{
 				{Path: "children", Value: newChildren},
 				{Path: "updated_at", Value: now},
@@ -360,41 +728,93 @@ func (h *FirestoreSuggestionHandler) executeAdd(ctx context.Context, s models.Su
 	}
 
 	// No parent - just create the person
-	_, err := h.client.Collection("people").Doc(id).Set(ctx, person)
+	_, err := h.coll(ctx, "people").Doc(id).Set(ctx, person)
 	return err
 }
 
 func (h *FirestoreSuggestionHandler) executeEdit(ctx context.Context, s models.Suggestion) error {
+	ref := h.coll(ctx, "people").Doc(s.TargetPersonID)
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("person not found: %v", err)
+	}
+	var before models.Person
+	if err := doc.DataTo(&before); err != nil {
+		return err
+	}
+
+	if _, err := ref.Update(ctx, editUpdates(s.PersonData, time.Now())); err != nil {
+		return err
+	}
+
+	// The suggester is the editor; the suggestion records who approved it
+	if doc, err := ref.Get(ctx); err == nil {
+		var after models.Person
+		if err := doc.DataTo(&after); err == nil {
+			recordPersonHistory(ctx, h.client, before, after, s.UserID, s.UserEmail, "suggestion", s.ID)
+		}
+	}
+	return nil
+}
+
+// editUpdates turns an edit suggestion's data into the person updates: blank values
+// leave a field alone and cleared_fields empty theirs
+func editUpdates(pd *models.PersonData, now time.Time) []firestore.Update {
 	updates := []firestore.Update{
-		{Path: "updated_at", Value: time.Now()},
+		{Path: "updated_at", Value: now},
 	}
 
-	if s.PersonData.Name != "" {
-		updates = append(updates, firestore.Update{Path: "name", Value: s.PersonData.Name})
+	if pd.Name != "" {
+		updates = append(updates, firestore.Update{Path: "name", Value: pd.Name})
+	}
+	if len(pd.AltNames) > 0 {
+		updates = append(updates, firestore.Update{Path: "alt_names", Value: pd.AltNames})
+	}
+	if pd.Role != "" {
+		updates = append(updates, firestore.Update{Path: "role", Value: pd.Role})
 	}
-	if s.PersonData.Role != "" {
-		updates = append(updates, firestore.Update{Path: "role", Value: s.PersonData.Role})
+	if pd.Occupation != "" {
+		updates = append(updates, firestore.Update{Path: "occupation", Value: pd.Occupation})
 	}
-	if s.PersonData.Birth != "" {
-		updates = append(updates, firestore.Update{Path: "birth", Value: s.PersonData.Birth})
+	if pd.Birth != "" {
+		updates = append(updates, firestore.Update{Path: "birth", Value: pd.Birth})
 	}
-	if s.PersonData.Location != "" {
-		updates = append(updates, firestore.Update{Path: "location", Value: s.PersonData.Location})
+	// Blank values leave a field alone; cleared_fields empties it below
+	if pd.Death != "" {
+		updates = append(updates, firestore.Update{Path: "death", Value: pd.Death})
 	}
-	if s.PersonData.Avatar != "" {
-		updates = append(updates, firestore.Update{Path: "avatar", Value: s.PersonData.Avatar})
+	if pd.Deceased || pd.Death != "" {
+		updates = append(updates, firestore.Update{Path: "deceased", Value: true})
 	}
-	if s.PersonData.Bio != "" {
-		updates = append(updates, firestore.Update{Path: "bio", Value: s.PersonData.Bio})
+	if pd.Location != "" {
+		updates = append(updates, firestore.Update{Path: "location", Value: pd.Location})
+	}
+	if pd.Avatar != "" {
+		updates = append(updates, firestore.Update{Path: "avatar", Value: pd.Avatar})
+	}
+	if pd.Bio != "" {
+		updates = append(updates, firestore.Update{Path: "bio", Value: pd.Bio})
+	}
+	for _, field := range pd.ClearedFields {
+		switch field {
+		case "alt_names":
+			updates = append(updates, firestore.Update{Path: "alt_names", Value: []string{}})
+		case "deceased":
+			updates = append(updates,
+				firestore.Update{Path: "deceased", Value: false},
+				firestore.Update{Path: "death", Value: ""},
+			)
+		case "occupation", "death", "location", "avatar", "bio":
+			updates = append(updates, firestore.Update{Path: field, Value: ""})
+		}
 	}
 
-	_, err := h.client.Collection("people").Doc(s.TargetPersonID).Update(ctx, updates)
-	return err
+	return updates
 }
 
 func (h *FirestoreSuggestionHandler) executeDelete(ctx context.Context, s models.Suggestion) error {
 	// Get the person to delete
-	doc, err := h.client.Collection("people").Doc(s.TargetPersonID).Get(ctx)
+	doc, err := h.coll(ctx, "people").Doc(s.TargetPersonID).Get(ctx)
 	if err != nil {
 		return fmt.Errorf("person not found: %v", err)
 	}
@@ -405,8 +825,10 @@ func (h *FirestoreSuggestionHandler) executeDelete(ctx context.Context, s models
 	}
 
 	return h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var parentIDs []string
+
 		// Find and update parent to remove this person from children
-		parentsIter := h.client.Collection("people").Where("children", "array-contains", s.TargetPersonID).Documents(ctx)
+		parentsIter := h.coll(ctx, "people").Where("children", "array-contains", s.TargetPersonID).Documents(ctx)
 		for {
 			parentDoc, err := parentsIter.Next()
 			if err == iterator.Done {
@@ -420,6 +842,7 @@ func (h *FirestoreSuggestionHandler) executeDelete(ctx context.Context, s models
 			if err := parentDoc.DataTo(&parent); err != nil {
 				continue
 			}
+			parentIDs = append(parentIDs, parentDoc.Ref.ID)
 
 			// Remove deleted person from parent's children
 			newChildren := make([]string, 0)
@@ -438,8 +861,40 @@ func (h *FirestoreSuggestionHandler) executeDelete(ctx context.Context, s models
 		}
 		parentsIter.Stop()
 
+		// Children keep any other parent but lose this one
+		childDocs, err := h.coll(ctx, "people").Where("parent_ids", "array-contains", s.TargetPersonID).Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, childDoc := range childDocs {
+			if err := tx.Update(childDoc.Ref, []firestore.Update{
+				{Path: "parent_ids", Value: firestore.ArrayRemove(s.TargetPersonID)},
+				{Path: "updated_at", Value: time.Now()},
+			}); err != nil {
+				return err
+			}
+		}
+
+		// Spouse links are symmetric, so drop the person from their spouses' arrays too
+		spouseDocs, err := h.coll(ctx, "people").Where("spouses", "array-contains", s.TargetPersonID).Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, spouseDoc := range spouseDocs {
+			if err := tx.Update(spouseDoc.Ref, []firestore.Update{
+				{Path: "spouses", Value: firestore.ArrayRemove(s.TargetPersonID)},
+				{Path: "updated_at", Value: time.Now()},
+			}); err != nil {
+				return err
+			}
+		}
+
 		// Delete the person
-		return tx.Delete(h.client.Collection("people").Doc(s.TargetPersonID))
+		if err := tx.Delete(h.coll(ctx, "people").Doc(s.TargetPersonID)); err != nil {
+			return err
+		}
+		// Tombstone for sync and restore, written in the same transaction
+		return tx.Set(h.coll(ctx, "deleted_people").Doc(s.TargetPersonID), newTombstone(person, parentIDs, "", s.ID))
 	})
 }
 
@@ -461,9 +916,15 @@ func (h *FirestoreSuggestionHandler) suggestionToResponse(ctx context.Context, s
 		UpdatedAt:      s.UpdatedAt.Format(time.RFC3339),
 	}
 
+	if reviewer := activeReviewer(s); reviewer != "" {
+		resp.ReviewingBy = reviewer
+		resp.ReviewingEmail = s.ReviewingEmail
+		resp.ReviewingUntil = s.ReviewingAt.Add(reviewClaimTimeout).Format(time.RFC3339)
+	}
+
 	// For edit/delete, include the target person info
 	if s.TargetPersonID != "" && (s.Type == models.SuggestionEdit || s.Type == models.SuggestionDelete) {
-		doc, err := h.client.Collection("people").Doc(s.TargetPersonID).Get(ctx)
+		doc, err := h.coll(ctx, "people").Doc(s.TargetPersonID).Get(ctx)
 		if err == nil {
 			var person models.Person
 			if err := doc.DataTo(&person); err == nil {
@@ -483,9 +944,26 @@ func (h *FirestoreSuggestionHandler) GetGroupedSuggestions(c *gin.Context) {
 
 	log.Printf("[GetGroupedSuggestions] Request from %s (role: %s), filter status: %s", email, role, status)
 
-	ctx := context.Background()
+	groups, total, err := h.fetchGroupedSuggestions(treeContext(c), status)
+	if err != nil {
+		log.Printf("[GetGroupedSuggestions] Error fetching suggestions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+		return
+	}
+
+	log.Printf("[GetGroupedSuggestions] Grouped %d suggestions into %d groups", total, len(groups))
 
-	iter := h.client.Collection("suggestions").Where("status", "==", status).Documents(ctx)
+	c.JSON(http.StatusOK, gin.H{
+		"groups":      groups,
+		"total_count": total,
+		"group_count": len(groups),
+	})
+}
+
+// fetchGroupedSuggestions loads the suggestions with the given status, groups them and
+// marks conflicts between groups. It also returns the number of suggestions loaded.
+func (h *FirestoreSuggestionHandler) fetchGroupedSuggestions(ctx context.Context, status string) ([]models.GroupedSuggestion, int, error) {
+	iter := h.coll(ctx, "suggestions").Where("status", "==", status).Documents(ctx)
 	defer iter.Stop()
 
 	var suggestions []models.Suggestion
@@ -495,9 +973,7 @@ func (h *FirestoreSuggestionHandler) GetGroupedSuggestions(c *gin.Context) {
 			break
 		}
 		if err != nil {
-			log.Printf("[GetGroupedSuggestions] Error fetching suggestions: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
-			return
+			return nil, 0, err
 		}
 
 		var s models.Suggestion
@@ -513,13 +989,7 @@ func (h *FirestoreSuggestionHandler) GetGroupedSuggestions(c *gin.Context) {
 	// Detect conflicts between groups
 	h.detectConflicts(groups)
 
-	log.Printf("[GetGroupedSuggestions] Grouped %d suggestions into %d groups", len(suggestions), len(groups))
-
-	c.JSON(http.StatusOK, gin.H{
-		"groups":      groups,
-		"total_count": len(suggestions),
-		"group_count": len(groups),
-	})
+	return groups, len(suggestions), nil
 }
 
 // groupSuggestions groups similar suggestions together
@@ -569,7 +1039,7 @@ func (h *FirestoreSuggestionHandler) groupSuggestions(ctx context.Context, sugge
 
 			// Fetch target person info for edit/delete
 			if s.TargetPersonID != "" && (s.Type == models.SuggestionEdit || s.Type == models.SuggestionDelete) {
-				doc, err := h.client.Collection("people").Doc(s.TargetPersonID).Get(ctx)
+				doc, err := h.coll(ctx, "people").Doc(s.TargetPersonID).Get(ctx)
 				if err == nil {
 					var person models.Person
 					if err := doc.DataTo(&person); err == nil {
@@ -639,28 +1109,34 @@ func (h *FirestoreSuggestionHandler) detectConflicts(groups []models.GroupedSugg
 		// Check for conflict types
 		hasDelete := false
 		hasEdit := false
-		var deleteGroup, editGroup *models.GroupedSuggestion
-
 		for _, g := range affectedGroups {
 			if g.Type == models.SuggestionDelete {
 				hasDelete = true
-				deleteGroup = g
 			}
 			if g.Type == models.SuggestionEdit {
 				hasEdit = true
-				editGroup = g
 			}
 		}
 
-		// Delete + Edit conflict
+		// Delete + Edit conflict: every delete group conflicts with every edit group
 		if hasDelete && hasEdit {
-			deleteGroup.HasConflicts = true
-			deleteGroup.ConflictsWith = append(deleteGroup.ConflictsWith, editGroup.GroupID)
-			deleteGroup.ConflictType = fmt.Sprintf("Conflicts with edit suggestion for person %s", personID)
+			for _, deleteGroup := range affectedGroups {
+				if deleteGroup.Type != models.SuggestionDelete {
+					continue
+				}
+				for _, editGroup := range affectedGroups {
+					if editGroup.Type != models.SuggestionEdit {
+						continue
+					}
+					deleteGroup.HasConflicts = true
+					deleteGroup.ConflictsWith = append(deleteGroup.ConflictsWith, editGroup.GroupID)
+					deleteGroup.ConflictType = fmt.Sprintf("Conflicts with edit suggestion for person %s", personID)
 
-			editGroup.HasConflicts = true
-			editGroup.ConflictsWith = append(editGroup.ConflictsWith, deleteGroup.GroupID)
-			editGroup.ConflictType = fmt.Sprintf("Conflicts with delete suggestion for person %s", personID)
+					editGroup.HasConflicts = true
+					editGroup.ConflictsWith = append(editGroup.ConflictsWith, deleteGroup.GroupID)
+					editGroup.ConflictType = fmt.Sprintf("Conflicts with delete suggestion for person %s", personID)
+				}
+			}
 		}
 
 		// Multiple different edit suggestions
@@ -695,88 +1171,111 @@ func (h *FirestoreSuggestionHandler) BatchReviewSuggestions(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
 		return
 	}
+	if !sanitizeFields(c, &req.ReviewNotes) {
+		return
+	}
 
 	if len(req.SuggestionIDs) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No suggestion IDs provided"})
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
+	successCount, failures := h.reviewSuggestions(ctx, req.SuggestionIDs, req.Approved, req.ReviewNotes, reviewerID.(string), reviewerEmail.(string))
+	failCount := len(failures)
+
+	log.Printf("[BatchReview] Batch review completed: %d success, %d failed", successCount, failCount)
+
+	if failCount > 0 && successCount == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":         "All suggestions failed to review",
+			"success_count": successCount,
+			"fail_count":    failCount,
+			"failures":      failures,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       fmt.Sprintf("Reviewed %d suggestions", successCount),
+		"success_count": successCount,
+		"fail_count":    failCount,
+		"failures":      failures,
+	})
+}
+
+// SuggestionReviewFailure is a suggestion a batch review could not complete
+type SuggestionReviewFailure struct {
+	SuggestionID string `json:"suggestion_id"`
+	Error        string `json:"error"`
+}
+
+// reviewSuggestions approves (executing them) or rejects the given pending suggestions,
+// skipping any that were already reviewed. It returns how many were reviewed and why
+// the others failed; one failure never stops the rest.
+func (h *FirestoreSuggestionHandler) reviewSuggestions(ctx context.Context, ids []string, approved bool, notes, reviewerID, reviewerEmail string) (int, []SuggestionReviewFailure) {
 	now := time.Now()
 
 	newStatus := "rejected"
-	if req.Approved {
+	if approved {
 		newStatus = "approved"
 	}
 
-	successCount := 0
-	failCount := 0
-	var firstError error
-
-	for _, suggestionID := range req.SuggestionIDs {
+	return reviewEach(ids, func(suggestionID string) error {
 		// Get the suggestion
-		doc, err := h.client.Collection("suggestions").Doc(suggestionID).Get(ctx)
+		doc, err := h.coll(ctx, "suggestions").Doc(suggestionID).Get(ctx)
 		if err != nil {
-			failCount++
-			if firstError == nil {
-				firstError = err
-			}
-			continue
+			return errors.New("Suggestion not found")
 		}
 
 		var suggestion models.Suggestion
 		if err := doc.DataTo(&suggestion); err != nil {
-			failCount++
-			continue
+			return errors.New("Failed to parse suggestion")
 		}
 
 		if suggestion.Status != "pending" {
-			// Already reviewed, skip
-			continue
+			return errAlreadyReviewed
 		}
 
 		// If approved, execute the suggestion
-		if req.Approved {
+		if approved {
 			if err := h.executeSuggestion(ctx, suggestion); err != nil {
 				log.Printf("[BatchReview] Error executing suggestion %s: %v", suggestionID, err)
-				failCount++
-				if firstError == nil {
-					firstError = err
-				}
-				continue
+				return fmt.Errorf("Failed to execute suggestion: %v", err)
 			}
 		}
 
 		// Update suggestion status
-		_, err = h.client.Collection("suggestions").Doc(suggestionID).Update(ctx, []firestore.Update{
+		_, err = h.coll(ctx, "suggestions").Doc(suggestionID).Update(ctx, []firestore.Update{
 			{Path: "status", Value: newStatus},
-			{Path: "reviewed_by", Value: reviewerID.(string)},
-			{Path: "reviewer_email", Value: reviewerEmail.(string)},
-			{Path: "review_notes", Value: req.ReviewNotes},
+			{Path: "reviewed_by", Value: reviewerID},
+			{Path: "reviewer_email", Value: reviewerEmail},
+			{Path: "review_notes", Value: notes},
+			{Path: "reviewing_by", Value: ""},
 			{Path: "updated_at", Value: now},
 		})
 		if err != nil {
-			failCount++
-			continue
+			return errors.New("Failed to update suggestion")
 		}
 
-		successCount++
-	}
-
-	log.Printf("[BatchReview] Batch review completed: %d success, %d failed", successCount, failCount)
+		notifySuggestionReviewed(h.client, suggestion, newStatus, notes)
+		return nil
+	})
+}
 
-	if failCount > 0 && successCount == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":         "All suggestions failed to review",
-			"success_count": successCount,
-			"fail_count":    failCount,
-		})
-		return
+// reviewEach runs review for every id, counting successes and collecting failures.
+// Ids answered with errAlreadyReviewed count as neither.
+func reviewEach(ids []string, review func(id string) error) (int, []SuggestionReviewFailure) {
+	successCount := 0
+	failures := []SuggestionReviewFailure{}
+	for _, id := range ids {
+		switch err := review(id); {
+		case err == errAlreadyReviewed:
+		case err != nil:
+			failures = append(failures, SuggestionReviewFailure{SuggestionID: id, Error: err.Error()})
+		default:
+			successCount++
+		}
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":       fmt.Sprintf("Reviewed %d suggestions", successCount),
-		"success_count": successCount,
-		"fail_count":    failCount,
-	})
+	return successCount, failures
 }
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// applyUpdates writes updates onto a stored document the way Firestore's Update would
+func applyUpdates(doc map[string]interface{}, pd *models.PersonData) map[string]interface{} {
+	for _, u := range editUpdates(pd, time.Now()) {
+		doc[u.Path] = u.Value
+	}
+	return doc
+}
+
+func TestEditClearsLocation(t *testing.T) {
+	pd := &models.PersonData{ClearedFields: []string{"location"}}
+	cleared, err := cleanClearedFields(pd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd.ClearedFields = cleared
+
+	doc := applyUpdates(map[string]interface{}{"name": "Ali", "location": "Tehran"}, pd)
+	if doc["location"] != "" {
+		t.Errorf("location = %q, want cleared", doc["location"])
+	}
+	if doc["name"] != "Ali" {
+		t.Errorf("name changed to %q", doc["name"])
+	}
+}
+
+func TestEditBlankLocationLeavesIt(t *testing.T) {
+	doc := applyUpdates(map[string]interface{}{"location": "Tehran"}, &models.PersonData{Name: "Ali"})
+	if doc["location"] != "Tehran" {
+		t.Errorf("location = %q, want unchanged", doc["location"])
+	}
+}
+
+func TestEditClearDeceasedRemovesDeath(t *testing.T) {
+	doc := applyUpdates(map[string]interface{}{"deceased": true, "death": "1990"},
+		&models.PersonData{ClearedFields: []string{"deceased"}})
+	if doc["deceased"] != false || doc["death"] != "" {
+		t.Errorf("deceased = %v, death = %q; want false and empty", doc["deceased"], doc["death"])
+	}
+}
+
+func TestCleanClearedFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		pd      models.PersonData
+		want    int
+		wantErr bool
+	}{
+		{"clear location", models.PersonData{ClearedFields: []string{"location"}}, 1, false},
+		{"duplicates collapse", models.PersonData{ClearedFields: []string{"bio", " bio"}}, 1, false},
+		{"set and cleared", models.PersonData{Location: "Tehran", ClearedFields: []string{"location"}}, 0, true},
+		{"required field", models.PersonData{ClearedFields: []string{"name"}}, 0, true},
+		{"deceased with death date", models.PersonData{Death: "1990", ClearedFields: []string{"deceased"}}, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := cleanClearedFields(&tt.pd)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: got %v, want %d fields", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDeleteEditConflictGroup(t *testing.T) {
+	h := &FirestoreSuggestionHandler{}
+	suggestions := []models.Suggestion{
+		{ID: "s1", Type: models.SuggestionDelete, TargetPersonID: "p1"},
+		{ID: "s2", Type: models.SuggestionDelete, TargetPersonID: "p1"},
+		{ID: "s3", Type: models.SuggestionEdit, TargetPersonID: "p1", PersonData: &models.PersonData{Name: "Ali"}},
+		{ID: "s4", Type: models.SuggestionEdit, TargetPersonID: "p2", PersonData: &models.PersonData{Name: "Reza"}},
+	}
+
+	// Both deletes share a group, so there is one delete and one edit group for p1
+	byKey := make(map[string]*models.GroupedSuggestion)
+	var groups []models.GroupedSuggestion
+	for _, s := range suggestions {
+		key := h.getSuggestionGroupKey(s)
+		if _, ok := byKey[key]; ok {
+			continue
+		}
+		groups = append(groups, models.GroupedSuggestion{GroupID: key, Type: s.Type, TargetPersonID: s.TargetPersonID, ConflictsWith: []string{}})
+		byKey[key] = &groups[len(groups)-1]
+	}
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3", len(groups))
+	}
+
+	h.detectConflicts(groups)
+
+	deleteKey, editKey := "delete:p1", h.getSuggestionGroupKey(suggestions[2])
+	for _, g := range groups {
+		switch g.GroupID {
+		case deleteKey:
+			if !g.HasConflicts || len(g.ConflictsWith) != 1 || g.ConflictsWith[0] != editKey {
+				t.Errorf("delete group: has_conflicts=%v conflicts_with=%v, want [%s]", g.HasConflicts, g.ConflictsWith, editKey)
+			}
+		case editKey:
+			if !g.HasConflicts || len(g.ConflictsWith) != 1 || g.ConflictsWith[0] != deleteKey {
+				t.Errorf("edit group: has_conflicts=%v conflicts_with=%v, want [%s]", g.HasConflicts, g.ConflictsWith, deleteKey)
+			}
+		default:
+			if g.HasConflicts {
+				t.Errorf("unrelated group %s flagged as conflicting with %v", g.GroupID, g.ConflictsWith)
+			}
+		}
+	}
+}
+
+func TestBatchReviewPartialFailure(t *testing.T) {
+	outcomes := map[string]error{
+		"ok1":     nil,
+		"broken":  errors.New("Failed to execute suggestion: person not found"),
+		"done":    errAlreadyReviewed,
+		"ok2":     nil,
+		"missing": errors.New("Suggestion not found"),
+	}
+	var reviewed []string
+	success, failures := reviewEach([]string{"ok1", "broken", "done", "ok2", "missing"}, func(id string) error {
+		reviewed = append(reviewed, id)
+		return outcomes[id]
+	})
+
+	if len(reviewed) != 5 {
+		t.Errorf("reviewed %v, want every id despite failures", reviewed)
+	}
+	if success != 2 {
+		t.Errorf("success = %d, want 2", success)
+	}
+	want := []SuggestionReviewFailure{
+		{SuggestionID: "broken", Error: "Failed to execute suggestion: person not found"},
+		{SuggestionID: "missing", Error: "Suggestion not found"},
+	}
+	if !reflect.DeepEqual(failures, want) {
+		t.Errorf("failures = %+v, want %+v", failures, want)
+	}
+}
@@ -2,17 +2,20 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/mamiri/findyourroot/internal/database"
 	"github.com/mamiri/findyourroot/internal/models"
 	"github.com/mamiri/findyourroot/internal/utils"
 	"google.golang.org/api/iterator"
@@ -26,6 +29,9 @@ func NewFirestoreTreeHandler(client *firestore.Client) *FirestoreTreeHandler {
 	return &FirestoreTreeHandler{client: client}
 }
 
+// defaultPersonRole is the placeholder role given to people created without one (imports, text population)
+const defaultPersonRole = "Family Member"
+
 // generateDefaultAvatar creates a default avatar URL based on the person's name
 func generateDefaultAvatar(name string) string {
 	// Use DiceBear Avataaars for consistent, reproducible avatars
@@ -43,27 +49,19 @@ func generateGenderAvatar(name string, gender string) string {
 	return fmt.Sprintf("https://api.dicebear.com/7.x/avataaars/svg?seed=%s&backgroundColor=b6e3f4&facialHairProbability=50", encodedName)
 }
 
-// GetAllPeople returns all people in the tree
-// Also validates references and cleans up any dangling ones
-func (h *FirestoreTreeHandler) GetAllPeople(c *gin.Context) {
-	ctx := context.Background()
-
-	iter := h.client.Collection("people").Documents(ctx)
+// fetchAllPeople loads every person document from Firestore
+func fetchAllPeople(ctx context.Context, client *firestore.Client) ([]models.Person, error) {
+	iter := database.TreeCollection(ctx, client, "people").Documents(ctx)
 	defer iter.Stop()
 
 	var people []models.Person
-	var allPersonIDs = make(map[string]bool)
-	var allUserIDs = make(map[string]bool)
-
-	// First pass: collect all people and build ID sets
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
-			return
+			return nil, fmt.Errorf("failed to fetch people: %v", err)
 		}
 
 		var person models.Person
@@ -71,88 +69,100 @@ func (h *FirestoreTreeHandler) GetAllPeople(c *gin.Context) {
 			continue
 		}
 		people = append(people, person)
-		allPersonIDs[person.ID] = true
 	}
 
-	// Fetch all valid user IDs for liked_by and linked_user_id validation
-	usersIter := h.client.Collection("users").Documents(ctx)
-	for {
-		doc, err := usersIter.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			break // Non-critical, continue without user validation
-		}
-		allUserIDs[doc.Ref.ID] = true
+	if people == nil {
+		people = []models.Person{}
 	}
-	usersIter.Stop()
+	return people, nil
+}
 
-	// Second pass: validate references and clean up in background
-	integrityService := NewReferentialIntegrityService(h.client)
-	for i := range people {
-		person := &people[i]
-		needsCleanup := false
-
-		// Check children references
-		validChildren := make([]string, 0)
-		for _, childID := range person.Children {
-			if allPersonIDs[childID] {
-				validChildren = append(validChildren, childID)
-			} else {
-				needsCleanup = true
-				log.Printf("[GetAllPeople] Found dangling child reference %s in person %s", childID, person.ID)
-			}
-		}
-		if needsCleanup {
-			person.Children = validChildren
-		}
+// maxUnpaginatedPeople caps GetAllPeople when no pagination params are given
+// (MAX_UNPAGINATED_PEOPLE, default 5000)
+var maxUnpaginatedPeople = envPositiveInt("MAX_UNPAGINATED_PEOPLE", 5000)
 
-		// Check liked_by references
-		validLikedBy := make([]string, 0)
-		likedByChanged := false
-		for _, userID := range person.LikedBy {
-			if allUserIDs[userID] {
-				validLikedBy = append(validLikedBy, userID)
-			} else {
-				likedByChanged = true
-				log.Printf("[GetAllPeople] Found dangling liked_by reference %s in person %s", userID, person.ID)
+// GetAllPeople returns the people in the tree in ID order. With ?limit= and/or ?cursor=
+// (the last ID of the previous page) it returns one page and the next cursor. Without them
+// it returns a plain array, as before, capped at maxUnpaginatedPeople; a capped response
+// carries the cursor to continue from in the X-Next-Cursor header.
+// Dangling references are cleaned up by the scheduled integrity sweep, not here.
+func (h *FirestoreTreeHandler) GetAllPeople(c *gin.Context) {
+	limitParam, cursor := c.Query("limit"), c.Query("cursor")
+	paginated := limitParam != "" || cursor != ""
+
+	limit := maxUnpaginatedPeople
+	if paginated {
+		limit = defaultPageSize
+		if limitParam != "" {
+			n, err := strconv.Atoi(limitParam)
+			if err != nil || n < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+				return
 			}
+			limit = n
 		}
-		if likedByChanged {
-			person.LikedBy = validLikedBy
-			person.LikesCount = len(validLikedBy)
-			needsCleanup = true
+		if limit > maxPageSize {
+			limit = maxPageSize
 		}
+	}
+	if cursor != "" && !validDocID(cursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 
-		// Check linked_user_id
-		if person.LinkedUserID != "" && !allUserIDs[person.LinkedUserID] {
-			log.Printf("[GetAllPeople] Found dangling linked_user_id %s in person %s", person.LinkedUserID, person.ID)
-			person.LinkedUserID = ""
-			needsCleanup = true
-		}
+	ctx := treeContext(c)
+	query := h.coll(ctx, "people").OrderBy(firestore.DocumentID, firestore.Asc)
+	if cursor != "" {
+		query = query.StartAfter(cursor)
+	}
+	// One extra document tells whether another page exists
+	docs, err := query.Limit(limit + 1).Documents(ctx).GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+	hasMore := len(docs) > limit
+	if hasMore {
+		docs = docs[:limit]
+	}
 
-		// Clean up in background if needed
-		if needsCleanup {
-			go func(personID string) {
-				integrityService.ValidatePersonReferences(context.Background(), personID)
-			}(person.ID)
+	people := make([]models.Person, 0, len(docs))
+	for _, doc := range docs {
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			continue
 		}
+		person.RelationshipType = person.ParentRelationship()
+		people = append(people, person)
+	}
+	nextCursor := ""
+	if hasMore {
+		nextCursor = docs[len(docs)-1].Ref.ID
 	}
 
-	if people == nil {
-		people = []models.Person{}
+	if !paginated {
+		if hasMore {
+			log.Printf("[GetAllPeople] Unpaginated response capped at %d people", limit)
+			c.Header("X-Next-Cursor", nextCursor)
+		}
+		c.JSON(http.StatusOK, people)
+		return
 	}
 
-	c.JSON(http.StatusOK, people)
+	c.JSON(http.StatusOK, gin.H{
+		"data":        people,
+		"limit":       limit,
+		"next_cursor": nextCursor,
+		"has_more":    hasMore,
+	})
 }
 
 // GetPerson returns a single person by ID
 func (h *FirestoreTreeHandler) GetPerson(c *gin.Context) {
 	id := c.Param("id")
-	ctx := context.Background()
+	ctx := treeContext(c)
 
-	doc, err := h.client.Collection("people").Doc(id).Get(ctx)
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
 		return
@@ -163,10 +173,20 @@ func (h *FirestoreTreeHandler) GetPerson(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
 		return
 	}
+	person.RelationshipType = person.ParentRelationship()
+	if age, ok := utils.Age(person.Birth, person.Death, person.Deceased, time.Now()); ok {
+		person.Age = &age
+	}
 
 	c.JSON(http.StatusOK, person)
 }
 
+// errParentNotFound is returned from the create transaction when a parent disappears
+var errParentNotFound = errors.New("parent not found")
+
+// maxParents is how many parents a new person may be created under
+const maxParents = 2
+
 // CreatePerson creates a new person in the tree
 func (h *FirestoreTreeHandler) CreatePerson(c *gin.Context) {
 	var req models.CreatePersonRequest
@@ -174,15 +194,34 @@ func (h *FirestoreTreeHandler) CreatePerson(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !sanitizeFields(c, &req.Bio) {
+		return
+	}
+
+	parentIDs := req.ParentIDs
+	if req.ParentID != nil && *req.ParentID != "" {
+		parentIDs = append([]string{*req.ParentID}, parentIDs...)
+	}
+	parentIDs = uniqueIDs(parentIDs)
+	if len(parentIDs) > maxParents {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A person can have at most %d parents", maxParents)})
+		return
+	}
+	for _, parentID := range parentIDs {
+		if !validDocID(parentID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent_id"})
+			return
+		}
+	}
 
 	// Debug logging
-	if req.ParentID != nil {
-		log.Printf("[CreatePerson] Creating child with parent_id: %s", *req.ParentID)
+	if len(parentIDs) > 0 {
+		log.Printf("[CreatePerson] Creating child with parents: %v", parentIDs)
 	} else {
 		log.Printf("[CreatePerson] Creating root person (no parent_id)")
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 	id := uuid.New().String()
 	now := time.Now()
 
@@ -201,34 +240,96 @@ func (h *FirestoreTreeHandler) CreatePerson(c *gin.Context) {
 		gender = "" // Unknown/unspecified
 	}
 
+	if err := validateBirth(req.Birth); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateDeath(req.Death, req.Birth); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	relationshipType := req.RelationshipType
+	if relationshipType == "" {
+		relationshipType = models.RelationshipBiological
+	}
+	if !models.ValidRelationshipType(relationshipType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "relationship_type must be biological, adopted, step or foster"})
+		return
+	}
+
+	altNames := []string{}
+	if req.AltNames != nil {
+		cleaned, err := utils.CleanAltNames(req.AltNames)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		altNames = cleaned
+	}
+
 	// Use children from request if provided, otherwise empty
 	children := req.Children
 	if children == nil {
 		children = []string{}
 	}
+	childrenWarning, err := validateChildren(ctx, h.client, id, children, true)
+	if err != nil {
+		respondChildrenError(c, err)
+		return
+	}
+	setChildrenWarning(c, childrenWarning)
+
+	// Refuse likely duplicates (Persian spelling and spacing variants included) unless forced
+	if c.Query("force") != "true" {
+		_, allNames, err := loadNameIndex(ctx, h.client)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicates"})
+			return
+		}
+		if matches := utils.FindSimilarNamesWithAliases(req.Name, allNames, createDuplicateThreshold); len(matches) > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "A person with a very similar name already exists",
+				"matches": matches,
+				"hint":    "Retry with ?force=true to create anyway",
+			})
+			return
+		}
+	}
 
 	person := models.Person{
-		ID:        id,
-		Name:      req.Name,
-		Role:      req.Role,
-		Gender:    gender,
-		Birth:     req.Birth,
-		Location:  req.Location,
-		Avatar:    avatar,
-		Bio:       req.Bio,
-		Children:  children,
-		CreatedBy: userID.(string),
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:               id,
+		Name:             req.Name,
+		AltNames:         altNames,
+		Role:             req.Role,
+		Occupation:       strings.TrimSpace(req.Occupation),
+		Gender:           gender,
+		Birth:            req.Birth,
+		Death:            strings.TrimSpace(req.Death),
+		Deceased:         req.Deceased || strings.TrimSpace(req.Death) != "",
+		Location:         req.Location,
+		Avatar:           avatar,
+		Bio:              req.Bio,
+		Children:         children,
+		ParentIDs:        parentIDs,
+		CreatedBy:        userID.(string),
+		TreeID:           requestTreeID(c),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		RelationshipType: relationshipType,
 	}
 
 	// If children are provided (adding as parent of existing nodes), handle the relationship
 	if len(children) > 0 {
+		if len(parentIDs) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A person cannot be created with both parents and children"})
+			return
+		}
 		err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
 			// First, remove these children from their current parents
 			for _, childID := range children {
 				// Find current parent of this child
-				iter := h.client.Collection("people").Where("children", "array-contains", childID).Documents(ctx)
+				iter := h.coll(ctx, "people").Where("children", "array-contains", childID).Documents(ctx)
 				for {
 					doc, err := iter.Next()
 					if err != nil {
@@ -244,10 +345,18 @@ func (h *FirestoreTreeHandler) CreatePerson(c *gin.Context) {
 					}
 					log.Printf("[CreatePerson] Removed child %s from old parent %s", childID, doc.Ref.ID)
 				}
+
+				// The new person replaces every old parent
+				if err := tx.Update(h.coll(ctx, "people").Doc(childID), []firestore.Update{
+					{Path: "parent_ids", Value: []string{id}},
+					{Path: "updated_at", Value: now},
+				}); err != nil {
+					return err
+				}
 			}
 
 			// Create the new parent person
-			personRef := h.client.Collection("people").Doc(id)
+			personRef := h.coll(ctx, "people").Doc(id)
 			if err := tx.Set(personRef, person); err != nil {
 				log.Printf("[CreatePerson] Error creating person: %v", err)
 				return err
@@ -268,45 +377,52 @@ func (h *FirestoreTreeHandler) CreatePerson(c *gin.Context) {
 		return
 	}
 
-	// If parentID is provided, use a transaction to create person and update parent atomically
-	if req.ParentID != nil && *req.ParentID != "" {
+	// If parents are provided, use a transaction to create person and update every parent atomically
+	if len(parentIDs) > 0 {
 		err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
-			// First, check if parent exists
-			parentRef := h.client.Collection("people").Doc(*req.ParentID)
-			parentDoc, err := tx.Get(parentRef)
+			// Read the parents inside the transaction so none can be deleted meanwhile
+			parentRefs := make([]*firestore.DocumentRef, len(parentIDs))
+			for i, parentID := range parentIDs {
+				parentRefs[i] = h.coll(ctx, "people").Doc(parentID)
+			}
+			parentDocs, err := tx.GetAll(parentRefs)
 			if err != nil {
-				log.Printf("[CreatePerson] Error getting parent: %v", err)
+				log.Printf("[CreatePerson] Error getting parents: %v", err)
 				return err
 			}
-
-			var parent models.Person
-			if err := parentDoc.DataTo(&parent); err != nil {
-				log.Printf("[CreatePerson] Error parsing parent data: %v", err)
-				return err
+			for _, parentDoc := range parentDocs {
+				if !parentDoc.Exists() {
+					return errParentNotFound
+				}
 			}
-			log.Printf("[CreatePerson] Found parent: %s, current children: %v", parent.Name, parent.Children)
 
 			// Create the child person
-			personRef := h.client.Collection("people").Doc(id)
+			personRef := h.coll(ctx, "people").Doc(id)
 			if err := tx.Set(personRef, person); err != nil {
 				log.Printf("[CreatePerson] Error creating child: %v", err)
 				return err
 			}
 			log.Printf("[CreatePerson] Created child: %s", person.Name)
 
-			// Update parent's children array using ArrayUnion (atomic, prevents duplicates)
-			err = tx.Update(parentRef, []firestore.Update{
-				{Path: "children", Value: firestore.ArrayUnion(id)},
-				{Path: "updated_at", Value: now},
-			})
-			if err != nil {
-				log.Printf("[CreatePerson] Error updating parent's children: %v", err)
-				return err
+			// Update each parent's children array using ArrayUnion (atomic, prevents duplicates)
+			for _, parentRef := range parentRefs {
+				err = tx.Update(parentRef, []firestore.Update{
+					{Path: "children", Value: firestore.ArrayUnion(id)},
+					{Path: "updated_at", Value: now},
+				})
+				if err != nil {
+					log.Printf("[CreatePerson] Error updating parent %s children: %v", parentRef.ID, err)
+					return err
+				}
 			}
-			log.Printf("[CreatePerson] Successfully updated parent's children array")
+			log.Printf("[CreatePerson] Successfully updated %d parents' children arrays", len(parentRefs))
 			return nil
 		})
 
+		if err == errParentNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent not found"})
+			return
+		}
 		if err != nil {
 			log.Printf("[CreatePerson] Transaction failed: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create person with parent relationship: %v", err)})
@@ -315,7 +431,7 @@ func (h *FirestoreTreeHandler) CreatePerson(c *gin.Context) {
 		log.Printf("[CreatePerson] Transaction completed successfully")
 	} else {
 		// No parent, just create the person
-		_, err := h.client.Collection("people").Doc(id).Set(ctx, person)
+		_, err := h.coll(ctx, "people").Doc(id).Set(ctx, person)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create person"})
 			return
@@ -334,11 +450,14 @@ func (h *FirestoreTreeHandler) UpdatePerson(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !sanitizeFields(c, req.Bio) {
+		return
+	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Check if person exists
-	doc, err := h.client.Collection("people").Doc(id).Get(ctx)
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
 		return
@@ -349,12 +468,14 @@ func (h *FirestoreTreeHandler) UpdatePerson(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
 		return
 	}
+	before := person
 
-	// Check ownership: only creator or admin can edit
+	// Check permission according to the tree's edit policy
 	userID, _ := c.Get("user_id")
 	role, _ := c.Get("role")
-	if person.CreatedBy != userID.(string) && role != string(models.RoleAdmin) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit nodes you created"})
+	settings := loadTreeSettings(ctx, h.client)
+	if !canModifyPerson(settings, person, userID.(string), role.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": modifyDeniedMessage(settings, "edit")})
 		return
 	}
 
@@ -367,14 +488,60 @@ func (h *FirestoreTreeHandler) UpdatePerson(c *gin.Context) {
 		updates = append(updates, firestore.Update{Path: "name", Value: *req.Name})
 		person.Name = *req.Name
 	}
+	if req.AltNames != nil {
+		altNames, err := utils.CleanAltNames(req.AltNames)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates = append(updates, firestore.Update{Path: "alt_names", Value: altNames})
+		person.AltNames = altNames
+	}
 	if req.Role != nil {
 		updates = append(updates, firestore.Update{Path: "role", Value: *req.Role})
 		person.Role = *req.Role
 	}
+	if req.Occupation != nil {
+		occupation := strings.TrimSpace(*req.Occupation)
+		updates = append(updates, firestore.Update{Path: "occupation", Value: occupation})
+		person.Occupation = occupation
+	}
 	if req.Birth != nil {
+		if err := validateBirth(*req.Birth); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		updates = append(updates, firestore.Update{Path: "birth", Value: *req.Birth})
 		person.Birth = *req.Birth
 	}
+	if req.Death != nil || req.Deceased != nil {
+		if req.Death != nil {
+			person.Death = strings.TrimSpace(*req.Death)
+			if person.Death != "" {
+				person.Deceased = true
+			}
+		}
+		if req.Deceased != nil {
+			if !*req.Deceased && req.Death != nil && person.Death != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "A person with a death date must be deceased"})
+				return
+			}
+			person.Deceased = *req.Deceased
+			if !person.Deceased {
+				person.Death = ""
+			}
+		}
+		updates = append(updates,
+			firestore.Update{Path: "death", Value: person.Death},
+			firestore.Update{Path: "deceased", Value: person.Deceased},
+		)
+	}
+	if req.Death != nil || req.Birth != nil {
+		if err := validateDeath(person.Death, person.Birth); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	if req.Location != nil {
 		updates = append(updates, firestore.Update{Path: "location", Value: *req.Location})
 		person.Location = *req.Location
@@ -387,16 +554,39 @@ func (h *FirestoreTreeHandler) UpdatePerson(c *gin.Context) {
 		updates = append(updates, firestore.Update{Path: "bio", Value: *req.Bio})
 		person.Bio = *req.Bio
 	}
+	if req.RelationshipType != nil {
+		if !models.ValidRelationshipType(*req.RelationshipType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "relationship_type must be biological, adopted, step or foster"})
+			return
+		}
+		updates = append(updates, firestore.Update{Path: "relationship_type", Value: *req.RelationshipType})
+		person.RelationshipType = *req.RelationshipType
+	}
 	if req.Children != nil {
+		childrenWarning, err := validateChildren(ctx, h.client, id, req.Children, true)
+		if err != nil {
+			respondChildrenError(c, err)
+			return
+		}
+		setChildrenWarning(c, childrenWarning)
 		updates = append(updates, firestore.Update{Path: "children", Value: req.Children})
 		person.Children = req.Children
 	}
 
-	_, err = h.client.Collection("people").Doc(id).Update(ctx, updates)
+	batch := h.client.Batch()
+	batch.Update(h.coll(ctx, "people").Doc(id), updates)
+	if req.Children != nil {
+		if err := syncParentIDs(ctx, h.client, batch, id, req.Children); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update person"})
+			return
+		}
+	}
+	_, err = batch.Commit(ctx)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update person"})
 		return
 	}
+	recordPersonHistoryFor(ctx, h.client, c, before, person, "update", "")
 
 	person.UpdatedAt = time.Now()
 	c.JSON(http.StatusOK, person)
@@ -405,10 +595,10 @@ func (h *FirestoreTreeHandler) UpdatePerson(c *gin.Context) {
 // DeletePerson deletes a person from the tree
 func (h *FirestoreTreeHandler) DeletePerson(c *gin.Context) {
 	id := c.Param("id")
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Check if person exists and verify ownership
-	doc, err := h.client.Collection("people").Doc(id).Get(ctx)
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
 		return
@@ -420,14 +610,23 @@ func (h *FirestoreTreeHandler) DeletePerson(c *gin.Context) {
 		return
 	}
 
-	// Check ownership: only creator or admin can delete
+	// Check permission according to the tree's edit policy
 	userID, _ := c.Get("user_id")
 	role, _ := c.Get("role")
-	if person.CreatedBy != userID.(string) && role != string(models.RoleAdmin) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete nodes you created"})
+	settings := loadTreeSettings(ctx, h.client)
+	if !canModifyPerson(settings, person, userID.(string), role.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": modifyDeniedMessage(settings, "delete")})
 		return
 	}
 
+	// Remember the parents for the tombstone before the cleanup detaches them
+	var parentIDs []string
+	if parents, err := findParents(ctx, h.client, id); err == nil {
+		for _, p := range parents {
+			parentIDs = append(parentIDs, p.ID)
+		}
+	}
+
 	// Use ReferentialIntegrityService to clean up all references BEFORE deleting
 	integrityService := NewReferentialIntegrityService(h.client)
 	if err := integrityService.OnPersonDeleted(ctx, id); err != nil {
@@ -436,21 +635,24 @@ func (h *FirestoreTreeHandler) DeletePerson(c *gin.Context) {
 	}
 
 	// Now delete the person
-	_, err = h.client.Collection("people").Doc(id).Delete(ctx)
+	_, err = h.coll(ctx, "people").Doc(id).Delete(ctx)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete person"})
 		return
 	}
+	recordDeletion(ctx, h.client, newTombstone(person, parentIDs, userID.(string), ""))
 
 	c.JSON(http.StatusOK, gin.H{"message": "Person deleted successfully"})
 }
 
 // DeleteAllPeople deletes all people from the tree (for testing)
 func (h *FirestoreTreeHandler) DeleteAllPeople(c *gin.Context) {
-	ctx := context.Background()
+	ctx := treeContext(c)
+	userID, _ := c.Get("user_id")
+	deletedBy, _ := userID.(string)
 
 	// Get all documents
-	iter := h.client.Collection("people").Documents(ctx)
+	iter := h.coll(ctx, "people").Documents(ctx)
 	defer iter.Stop()
 
 	batch := h.client.Batch()
@@ -466,11 +668,16 @@ func (h *FirestoreTreeHandler) DeleteAllPeople(c *gin.Context) {
 			return
 		}
 
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			person = models.Person{ID: doc.Ref.ID}
+		}
 		batch.Delete(doc.Ref)
+		batch.Set(h.coll(ctx, "deleted_people").Doc(doc.Ref.ID), newTombstone(person, nil, deletedBy, ""))
 		count++
 
-		// Firestore batch limit is 500
-		if count%500 == 0 {
+		// Firestore batch limit is 500 writes (two per person)
+		if count%250 == 0 {
 			if _, err := batch.Commit(ctx); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete people"})
 				return
@@ -480,7 +687,7 @@ func (h *FirestoreTreeHandler) DeleteAllPeople(c *gin.Context) {
 	}
 
 	// Commit remaining
-	if count%500 != 0 {
+	if count%250 != 0 {
 		if _, err := batch.Commit(ctx); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete people"})
 			return
@@ -499,11 +706,11 @@ func (h *FirestoreTreeHandler) LikePerson(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Use a transaction to atomically update likes
 	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
-		docRef := h.client.Collection("people").Doc(id)
+		docRef := h.coll(ctx, "people").Doc(id)
 		doc, err := tx.Get(docRef)
 		if err != nil {
 			return err
@@ -514,23 +721,21 @@ func (h *FirestoreTreeHandler) LikePerson(c *gin.Context) {
 			return err
 		}
 
-		// Check if user already liked
-		for _, uid := range person.LikedBy {
-			if uid == userID.(string) {
-				return fmt.Errorf("already liked")
-			}
+		likedBy, err := applyLike(person.LikedBy, userID.(string), true)
+		if err != nil {
+			return err
 		}
 
-		// Add user to liked_by array and increment likes_count
+		// Add user to liked_by array; likes_count follows the array so earlier drift is corrected
 		return tx.Update(docRef, []firestore.Update{
 			{Path: "liked_by", Value: firestore.ArrayUnion(userID.(string))},
-			{Path: "likes_count", Value: person.LikesCount + 1},
+			{Path: "likes_count", Value: likesCount(likedBy)},
 			{Path: "updated_at", Value: time.Now()},
 		})
 	})
 
 	if err != nil {
-		if err.Error() == "already liked" {
+		if err == errAlreadyLiked {
 			c.JSON(http.StatusConflict, gin.H{"error": "You have already liked this person"})
 			return
 		}
@@ -550,11 +755,11 @@ func (h *FirestoreTreeHandler) UnlikePerson(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Use a transaction to atomically update likes
 	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
-		docRef := h.client.Collection("people").Doc(id)
+		docRef := h.coll(ctx, "people").Doc(id)
 		doc, err := tx.Get(docRef)
 		if err != nil {
 			return err
@@ -565,33 +770,21 @@ func (h *FirestoreTreeHandler) UnlikePerson(c *gin.Context) {
 			return err
 		}
 
-		// Check if user has liked
-		found := false
-		for _, uid := range person.LikedBy {
-			if uid == userID.(string) {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return fmt.Errorf("not liked")
-		}
-
-		// Remove user from liked_by array and decrement likes_count
-		newCount := person.LikesCount - 1
-		if newCount < 0 {
-			newCount = 0
+		likedBy, err := applyLike(person.LikedBy, userID.(string), false)
+		if err != nil {
+			return err
 		}
 
+		// Remove user from liked_by array; likes_count follows the array, so it can't go negative
 		return tx.Update(docRef, []firestore.Update{
 			{Path: "liked_by", Value: firestore.ArrayRemove(userID.(string))},
-			{Path: "likes_count", Value: newCount},
+			{Path: "likes_count", Value: likesCount(likedBy)},
 			{Path: "updated_at", Value: time.Now()},
 		})
 	})
 
 	if err != nil {
-		if err.Error() == "not liked" {
+		if err == errNotLiked {
 			c.JSON(http.StatusConflict, gin.H{"error": "You have not liked this person"})
 			return
 		}
@@ -609,6 +802,25 @@ type CheckDuplicateNameRequest struct {
 	UseAI     bool    `json:"use_ai"`    // Whether to use Gemini AI for matching
 }
 
+// createDuplicateThreshold is the similarity at which CreatePerson refuses a new name
+// as a likely duplicate unless ?force=true
+const createDuplicateThreshold = 0.9
+
+// loadNameIndex reads every person's name, keyed by id, alone and with alternate names
+func loadNameIndex(ctx context.Context, client *firestore.Client) (map[string]string, map[string][]string, error) {
+	people, err := fetchAllPeople(ctx, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	existingNames := make(map[string]string, len(people)) // personID -> name
+	allNames := make(map[string][]string, len(people))    // personID -> name + alternate names
+	for _, person := range people {
+		existingNames[person.ID] = person.Name
+		allNames[person.ID] = append([]string{person.Name}, person.AltNames...)
+	}
+	return existingNames, allNames, nil
+}
+
 // CheckDuplicateName checks if a name already exists or is similar to existing names
 func (h *FirestoreTreeHandler) CheckDuplicateName(c *gin.Context) {
 	var req CheckDuplicateNameRequest
@@ -623,32 +835,16 @@ func (h *FirestoreTreeHandler) CheckDuplicateName(c *gin.Context) {
 		threshold = 0.75 // 75% similarity
 	}
 
-	ctx := context.Background()
-
-	// Get all existing names
-	iter := h.client.Collection("people").Documents(ctx)
-	defer iter.Stop()
+	ctx := treeContext(c)
 
-	existingNames := make(map[string]string) // personID -> name
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
-			return
-		}
-
-		var person models.Person
-		if err := doc.DataTo(&person); err != nil {
-			continue
-		}
-		existingNames[person.ID] = person.Name
+	existingNames, allNames, err := loadNameIndex(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
 	}
 
-	// Find similar names using traditional algorithm
-	matches := utils.FindSimilarNames(req.Name, existingNames, threshold)
+	// Find similar names using traditional algorithm (matches on any alternate name too)
+	matches := utils.FindSimilarNamesWithAliases(req.Name, allNames, threshold)
 
 	// Optionally enhance with AI matching (if enabled and API key available)
 	aiUsed := false
@@ -761,6 +957,21 @@ func parsePersonInfo(text string) (name string, gender string, birth string) {
 	return
 }
 
+// takeMarkerValue removes a "marker:value" token (e.g. " b:1985") from text, returning the
+// value (up to the next space) and the remaining text. Text without the marker is unchanged.
+func takeMarkerValue(text, marker string) (string, string) {
+	idx := strings.Index(text, marker)
+	if idx == -1 {
+		return "", text
+	}
+	rest := text[idx+len(marker):]
+	endIdx := strings.Index(rest, " ")
+	if endIdx == -1 {
+		return strings.TrimSpace(rest), strings.TrimSpace(text[:idx])
+	}
+	return strings.TrimSpace(rest[:endIdx]), strings.TrimSpace(text[:idx]) + " " + strings.TrimSpace(rest[endIdx:])
+}
+
 // isNumeric checks if a string contains only digits
 func isNumeric(s string) bool {
 	for _, c := range s {
@@ -771,55 +982,61 @@ func isNumeric(s string) bool {
 	return len(s) > 0
 }
 
-// PopulateTreeFromText parses indentation-based text and creates the tree (admin only)
-func (h *FirestoreTreeHandler) PopulateTreeFromText(c *gin.Context) {
-	var req PopulateTreeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+// leadingIndent measures a line's leading whitespace, counting a tab as 4 spaces
+func leadingIndent(line string) int {
+	spaces := 0
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\t' {
+			spaces += 4
+		} else if line[i] == ' ' {
+			spaces++
+		} else {
+			break
+		}
 	}
+	return spaces
+}
 
-	// Get user ID from context
-	userID, _ := c.Get("user_id")
-
-	// Parse the text into tree structure
-	lines := strings.Split(req.Text, "\n")
+// populateNode is one parsed line of PopulateTreeFromText input
+type populateNode struct {
+	Name            string
+	Gender          string // "male", "female", or ""
+	GenderDefaulted bool   // No (m)/(f) marker was given
+	Birth           string // Birth year or date
+	Death           string // Death year or date
+	Location        string // Birthplace or location
+	Level           int
+	ID              string
+	Children        []string
+	ParentIDs       []string
+}
 
-	type PersonNode struct {
-		Name     string
-		Gender   string // "male", "female", or ""
-		Birth    string // Birth year or date
-		Location string // Birthplace or location
-		Level    int
-		ID       string
-		Children []string
-	}
+// parsePopulateText parses indentation-based text into people linked to their parents,
+// in input order, and returns the indentation unit used. One indentation step is the
+// smallest indent used; a line deeper than the one before it becomes that line's child.
+func parsePopulateText(text string) ([]populateNode, int) {
+	lines := strings.Split(text, "\n")
 
-	var nodes []PersonNode
-	indentUnit := 0 // Will be set from first indented line
+	var nodes []populateNode
 
+	// The indentation unit is the smallest indent used, so a deeper first indented
+	// line doesn't make every shallower one a root
+	indentUnit := 0
 	for _, line := range lines {
-		// Skip empty lines
 		if strings.TrimSpace(line) == "" {
 			continue
 		}
-
-		// Count leading whitespace
-		spaces := 0
-		for i := 0; i < len(line); i++ {
-			if line[i] == '\t' {
-				spaces += 4 // Treat tab as 4 spaces
-			} else if line[i] == ' ' {
-				spaces++
-			} else {
-				break
-			}
+		if spaces := leadingIndent(line); spaces > 0 && (indentUnit == 0 || spaces < indentUnit) {
+			indentUnit = spaces
 		}
+	}
 
-		// Detect indentation unit from first indented line
-		if spaces > 0 && indentUnit == 0 {
-			indentUnit = spaces
+	for _, line := range lines {
+		// Skip empty lines
+		if strings.TrimSpace(line) == "" {
+			continue
 		}
+		spaces := leadingIndent(line)
 
 		// Calculate level
 		level := 0
@@ -836,17 +1053,21 @@ func (h *FirestoreTreeHandler) PopulateTreeFromText(c *gin.Context) {
 		// Examples:
 		//   "John Smith (m) 1985"
 		//   "Jane Doe (f) b:1990 l:New York"
+		//   "Ali Rezaei (m) b:1920 d:1995"
 		//   "Alex Johnson (m) l:Chicago"
-		//   "Mary Williams" - defaults to female if no marker
+		//   "Mary Williams" - defaults to male if no marker (reported as defaulted_gender)
 
 		// Parse gender from name: "John (m)" or "Mary (f)" or "Alex (M)" or "Jane (F)"
 		gender := "male" // Default to male
+		genderDefaulted := true
 		if strings.Contains(name, "(m)") || strings.Contains(name, "(M)") {
 			name = strings.TrimSpace(strings.Replace(strings.Replace(name, "(m)", "", 1), "(M)", "", 1))
 			gender = "male"
+			genderDefaulted = false
 		} else if strings.Contains(name, "(f)") || strings.Contains(name, "(F)") {
 			name = strings.TrimSpace(strings.Replace(strings.Replace(name, "(f)", "", 1), "(F)", "", 1))
 			gender = "female"
+			genderDefaulted = false
 		}
 
 		// Parse location - look for "l:Location" or "loc:Location"
@@ -859,20 +1080,13 @@ func (h *FirestoreTreeHandler) PopulateTreeFromText(c *gin.Context) {
 			name = strings.TrimSpace(name[:idx])
 		}
 
+		// Parse death year - look for "d:YYYY" (before the birth fallback can take the year)
+		death, name := takeMarkerValue(name, " d:")
+
 		// Parse birth year - look for "b:YYYY" or standalone 4-digit year
 		birth := ""
-		if idx := strings.Index(name, " b:"); idx != -1 {
-			// Extract birth after "b:"
-			rest := name[idx+3:]
-			// Get just the year part (up to next space or end)
-			endIdx := strings.Index(rest, " ")
-			if endIdx == -1 {
-				birth = strings.TrimSpace(rest)
-				name = strings.TrimSpace(name[:idx])
-			} else {
-				birth = strings.TrimSpace(rest[:endIdx])
-				name = strings.TrimSpace(name[:idx]) + " " + strings.TrimSpace(rest[endIdx:])
-			}
+		if strings.Contains(name, " b:") {
+			birth, name = takeMarkerValue(name, " b:")
 		} else {
 			// Look for standalone 4-digit year (1900-2099)
 			birthPattern := regexp.MustCompile(`\b(19\d{2}|20\d{2})\b`)
@@ -885,31 +1099,28 @@ func (h *FirestoreTreeHandler) PopulateTreeFromText(c *gin.Context) {
 		// Clean up any double spaces
 		name = strings.Join(strings.Fields(name), " ")
 
-		nodes = append(nodes, PersonNode{
-			Name:     name,
-			Gender:   gender,
-			Birth:    birth,
-			Location: location,
-			Level:    level,
-			ID:       uuid.New().String(),
-			Children: []string{},
+		nodes = append(nodes, populateNode{
+			Name:            name,
+			Gender:          gender,
+			GenderDefaulted: genderDefaulted,
+			Birth:           birth,
+			Death:           death,
+			Location:        location,
+			Level:           level,
+			ID:              uuid.New().String(),
+			Children:        []string{},
+			ParentIDs:       []string{},
 		})
 	}
 
-	if len(nodes) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid entries found in text"})
-		return
-	}
-
 	// Debug: Log parsed nodes with levels
-	log.Printf("[PopulateTree] Parsed %d nodes, indentUnit=%d", len(nodes), indentUnit)
 	for i, n := range nodes {
 		log.Printf("[PopulateTree] Node %d: name=%q level=%d", i, n.Name, n.Level)
 	}
 
 	// Build parent-child relationships
 	// Use a stack to track parents at each level
-	stack := make([]*PersonNode, 0)
+	stack := make([]*populateNode, 0)
 
 	for i := range nodes {
 		node := &nodes[i]
@@ -923,6 +1134,7 @@ func (h *FirestoreTreeHandler) PopulateTreeFromText(c *gin.Context) {
 		if len(stack) > 0 {
 			parent := stack[len(stack)-1]
 			parent.Children = append(parent.Children, node.ID)
+			node.ParentIDs = []string{parent.ID}
 			log.Printf("[PopulateTree] %q (level %d) is child of %q (level %d)", node.Name, node.Level, parent.Name, parent.Level)
 		} else {
 			log.Printf("[PopulateTree] %q (level %d) has no parent (root)", node.Name, node.Level)
@@ -931,42 +1143,131 @@ func (h *FirestoreTreeHandler) PopulateTreeFromText(c *gin.Context) {
 		// Push this node onto the stack
 		stack = append(stack, node)
 	}
+	return nodes, indentUnit
+}
+
+// PopulatePreviewNode is one person of a dry-run populate hierarchy
+type PopulatePreviewNode struct {
+	Name            string                 `json:"name"`
+	Gender          string                 `json:"gender"`
+	GenderDefaulted bool                   `json:"gender_defaulted"`
+	Birth           string                 `json:"birth,omitempty"`
+	Death           string                 `json:"death,omitempty"`
+	Location        string                 `json:"location,omitempty"`
+	Children        []*PopulatePreviewNode `json:"children"`
+}
+
+// PopulateTreeFromText parses indentation-based text and creates the tree (admin only).
+// One indentation step is the smallest indent used. Names without an (m)/(f) marker
+// default to male; the response counts them as defaulted_gender for review.
+// ?dry_run=true returns the parsed hierarchy without writing anything.
+func (h *FirestoreTreeHandler) PopulateTreeFromText(c *gin.Context) {
+	var req PopulateTreeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	// Get user ID from context
+	userID, _ := c.Get("user_id")
 
-	// Create all people in Firestore
-	ctx := context.Background()
+	nodes, indentUnit := parsePopulateText(req.Text)
+	if len(nodes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid entries found in text"})
+		return
+	}
+	log.Printf("[PopulateTree] Parsed %d nodes, indentUnit=%d", len(nodes), indentUnit)
+
+	defaultedGender := 0
+	for _, node := range nodes {
+		if node.GenderDefaulted {
+			defaultedGender++
+		}
+	}
+
+	if dryRun {
+		preview := make(map[string]*PopulatePreviewNode, len(nodes))
+		for _, node := range nodes {
+			preview[node.ID] = &PopulatePreviewNode{
+				Name:            node.Name,
+				Gender:          node.Gender,
+				GenderDefaulted: node.GenderDefaulted,
+				Birth:           node.Birth,
+				Death:           node.Death,
+				Location:        node.Location,
+				Children:        []*PopulatePreviewNode{},
+			}
+		}
+		roots := []*PopulatePreviewNode{}
+		for _, node := range nodes {
+			for _, childID := range node.Children {
+				preview[node.ID].Children = append(preview[node.ID].Children, preview[childID])
+			}
+			if len(node.ParentIDs) == 0 {
+				roots = append(roots, preview[node.ID])
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run":          true,
+			"count":            len(nodes),
+			"defaulted_gender": defaultedGender,
+			"hierarchy":        roots,
+			"tree_name":        req.TreeName,
+		})
+		return
+	}
+
+	// Create all people in Firestore (batch limit is 500)
+	ctx := treeContext(c)
 	now := time.Now()
 	batch := h.client.Batch()
 	createdPeople := make([]models.Person, 0, len(nodes))
 
 	for _, node := range nodes {
 		person := models.Person{
-			ID:        node.ID,
-			Name:      node.Name,
-			Gender:    node.Gender,
-			Role:      "Family Member",
-			Birth:     node.Birth,
-			Location:  node.Location,
-			Avatar:    generateGenderAvatar(node.Name, node.Gender),
-			Children:  node.Children,
-			CreatedBy: userID.(string),
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
-
-		ref := h.client.Collection("people").Doc(node.ID)
+			ID:              node.ID,
+			Name:            node.Name,
+			Gender:          node.Gender,
+			GenderDefaulted: node.GenderDefaulted,
+			Role:            defaultPersonRole,
+			Birth:           node.Birth,
+			Death:           node.Death,
+			Deceased:        node.Death != "",
+			Location:        node.Location,
+			Avatar:          generateGenderAvatar(node.Name, node.Gender),
+			Children:        node.Children,
+			TreeID:          requestTreeID(c),
+			ParentIDs:       node.ParentIDs,
+			CreatedBy:       userID.(string),
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+
+		ref := h.coll(ctx, "people").Doc(node.ID)
 		batch.Set(ref, person)
 		createdPeople = append(createdPeople, person)
+
+		if len(createdPeople)%500 == 0 {
+			if _, err := batch.Commit(ctx); err != nil {
+				log.Printf("[PopulateTree] Batch commit failed: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create people", "created_count": len(createdPeople) - 500})
+				return
+			}
+			batch = h.client.Batch()
+		}
 	}
 
-	// Commit all at once
-	if _, err := batch.Commit(ctx); err != nil {
-		log.Printf("[PopulateTree] Batch commit failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create people"})
-		return
+	if len(createdPeople)%500 != 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			log.Printf("[PopulateTree] Batch commit failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create people", "created_count": len(createdPeople) - len(createdPeople)%500})
+			return
+		}
 	}
 
 	// Save tree name to settings
-	_, err := h.client.Collection("settings").Doc("tree").Set(ctx, map[string]interface{}{
+	_, err := h.coll(ctx, "settings").Doc("tree").Set(ctx, map[string]interface{}{
 		"tree_name":  req.TreeName,
 		"updated_at": now,
 		"updated_by": userID.(string),
@@ -979,46 +1280,108 @@ func (h *FirestoreTreeHandler) PopulateTreeFromText(c *gin.Context) {
 	log.Printf("[PopulateTree] Created %d people from text with tree name: %s", len(createdPeople), req.TreeName)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"created_count": len(createdPeople),
-		"people":        createdPeople,
-		"tree_name":     req.TreeName,
+		"created_count":    len(createdPeople),
+		"defaulted_gender": defaultedGender,
+		"people":           createdPeople,
+		"tree_name":        req.TreeName,
 	})
 }
 
+// Edit policies controlling who may edit/delete existing people directly
+const (
+	EditPolicyOwner = "owner" // Only the creator (or admin) can edit a node
+	EditPolicyRole  = "role"  // Anyone whose role CanEditDirectly can edit any node
+)
+
 // TreeSettings represents the tree configuration
 type TreeSettings struct {
-	TreeName  string    `json:"tree_name" firestore:"tree_name"`
-	UpdatedAt time.Time `json:"updated_at" firestore:"updated_at"`
-	UpdatedBy string    `json:"updated_by" firestore:"updated_by"`
+	TreeName   string `json:"tree_name" firestore:"tree_name"`
+	EditPolicy string `json:"edit_policy" firestore:"edit_policy"`
+	// ContributorFields lists the PersonData fields contributors may include in suggestions; empty allows all
+	ContributorFields []string `json:"contributor_fields" firestore:"contributor_fields"`
+	// RequireIdentityLink limits suggesting and editing to users linked to a person in the tree
+	RequireIdentityLink bool `json:"require_identity_link" firestore:"require_identity_link"`
+	// ReadOnly freezes tree writes, e.g. during a migration; ReadOnlyExemptAdmins lets admins keep writing
+	ReadOnly             bool      `json:"read_only" firestore:"read_only"`
+	ReadOnlyExemptAdmins bool      `json:"read_only_exempt_admins" firestore:"read_only_exempt_admins"`
+	UpdatedAt            time.Time `json:"updated_at" firestore:"updated_at"`
+	UpdatedBy            string    `json:"updated_by" firestore:"updated_by"`
 }
 
-// GetTreeSettings returns the tree settings
-func (h *FirestoreTreeHandler) GetTreeSettings(c *gin.Context) {
-	ctx := context.Background()
+// defaultTreeSettings returns the settings used when none are stored
+func defaultTreeSettings() TreeSettings {
+	return TreeSettings{
+		TreeName:          "Family Tree",
+		EditPolicy:        EditPolicyRole,
+		ContributorFields: []string{},
+	}
+}
+
+// loadTreeSettings reads the tree settings, filling in defaults for missing fields
+func loadTreeSettings(ctx context.Context, client *firestore.Client) TreeSettings {
+	defaults := defaultTreeSettings()
 
-	doc, err := h.client.Collection("settings").Doc("tree").Get(ctx)
+	doc, err := database.TreeCollection(ctx, client, "settings").Doc("tree").Get(ctx)
 	if err != nil {
-		// Return default settings if not found
-		c.JSON(http.StatusOK, gin.H{
-			"tree_name": "Family Tree",
-		})
-		return
+		return defaults
 	}
 
 	var settings TreeSettings
 	if err := doc.DataTo(&settings); err != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"tree_name": "Family Tree",
-		})
-		return
+		return defaults
+	}
+
+	if settings.TreeName == "" {
+		settings.TreeName = defaults.TreeName
+	}
+	if settings.EditPolicy != EditPolicyOwner && settings.EditPolicy != EditPolicyRole {
+		settings.EditPolicy = defaults.EditPolicy
+	}
+	if settings.ContributorFields == nil {
+		settings.ContributorFields = []string{}
+	}
+	return settings
+}
+
+// canModifyPerson reports whether a user may edit/delete a person under the tree's edit policy
+// Admins and the node's creator always can; contributors use the suggestion flow instead
+func canModifyPerson(settings TreeSettings, person models.Person, userID, role string) bool {
+	userRole := models.UserRole(role)
+	if userRole == models.RoleAdmin || person.CreatedBy == userID {
+		return true
+	}
+	if settings.EditPolicy == EditPolicyRole {
+		return userRole.CanEditDirectly()
 	}
+	return false
+}
 
-	c.JSON(http.StatusOK, settings)
+// modifyDeniedMessage explains a canModifyPerson refusal under the tree's edit policy.
+// verb is "edit" or "delete".
+func modifyDeniedMessage(settings TreeSettings, verb string) string {
+	if settings.EditPolicy == EditPolicyRole {
+		return fmt.Sprintf("Your role cannot %s nodes created by others", verb)
+	}
+	return fmt.Sprintf("You can only %s nodes you created", verb)
+}
+
+// GetTreeSettings returns the tree settings
+func (h *FirestoreTreeHandler) GetTreeSettings(c *gin.Context) {
+	ctx := treeContext(c)
+	c.JSON(http.StatusOK, loadTreeSettings(ctx, h.client))
 }
 
 // UpdateTreeSettingsRequest represents the request to update tree settings
+// Only provided fields are changed
 type UpdateTreeSettingsRequest struct {
-	TreeName string `json:"tree_name" binding:"required"`
+	TreeName   *string `json:"tree_name"`
+	EditPolicy *string `json:"edit_policy"` // "owner" or "role"
+	// ContributorFields restricts which PersonData fields contributors may suggest; [] allows all
+	ContributorFields *[]string `json:"contributor_fields"`
+	// RequireIdentityLink, when true, only lets users linked to a person suggest or edit
+	RequireIdentityLink  *bool `json:"require_identity_link"`
+	ReadOnly             *bool `json:"read_only"`
+	ReadOnlyExemptAdmins *bool `json:"read_only_exempt_admins"`
 }
 
 // UpdateTreeSettings updates the tree settings (admin only)
@@ -1030,20 +1393,59 @@ func (h *FirestoreTreeHandler) UpdateTreeSettings(c *gin.Context) {
 	}
 
 	userID, _ := c.Get("user_id")
-	ctx := context.Background()
+	ctx := treeContext(c)
 
-	settings := TreeSettings{
-		TreeName:  req.TreeName,
-		UpdatedAt: time.Now(),
-		UpdatedBy: userID.(string),
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+		"updated_by": userID.(string),
+	}
+
+	if req.TreeName != nil {
+		name := strings.TrimSpace(*req.TreeName)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tree_name cannot be empty"})
+			return
+		}
+		updates["tree_name"] = name
+	}
+	if req.EditPolicy != nil {
+		if *req.EditPolicy != EditPolicyOwner && *req.EditPolicy != EditPolicyRole {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "edit_policy must be 'owner' or 'role'"})
+			return
+		}
+		updates["edit_policy"] = *req.EditPolicy
+	}
+	if req.ContributorFields != nil {
+		known := make(map[string]bool, len(suggestionFields))
+		for _, field := range suggestionFields {
+			known[field] = true
+		}
+		fields := []string{}
+		for _, field := range *req.ContributorFields {
+			if !known[field] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown contributor field %q", field), "valid_fields": suggestionFields})
+				return
+			}
+			fields = append(fields, field)
+		}
+		updates["contributor_fields"] = fields
+	}
+	if req.RequireIdentityLink != nil {
+		updates["require_identity_link"] = *req.RequireIdentityLink
+	}
+	if req.ReadOnly != nil {
+		updates["read_only"] = *req.ReadOnly
+	}
+	if req.ReadOnlyExemptAdmins != nil {
+		updates["read_only_exempt_admins"] = *req.ReadOnlyExemptAdmins
 	}
 
-	_, err := h.client.Collection("settings").Doc("tree").Set(ctx, settings)
+	_, err := h.coll(ctx, "settings").Doc("tree").Set(ctx, updates, firestore.MergeAll)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update settings"})
 		return
 	}
 
-	log.Printf("[TreeSettings] Tree name updated to %q by user %s", req.TreeName, userID)
-	c.JSON(http.StatusOK, settings)
+	log.Printf("[TreeSettings] Settings updated by user %s: %v", userID, updates)
+	c.JSON(http.StatusOK, loadTreeSettings(ctx, h.client))
 }
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+func TestCanModifyPerson(t *testing.T) {
+	owner := TreeSettings{EditPolicy: EditPolicyOwner}
+	role := TreeSettings{EditPolicy: EditPolicyRole}
+	mine := models.Person{ID: "p1", CreatedBy: "u1"}
+	theirs := models.Person{ID: "p2", CreatedBy: "u2"}
+
+	tests := []struct {
+		name     string
+		settings TreeSettings
+		person   models.Person
+		role     models.UserRole
+		want     bool
+	}{
+		{"owner policy: admin edits others' node", owner, theirs, models.RoleAdmin, true},
+		{"owner policy: co-admin edits own node", owner, mine, models.RoleCoAdmin, true},
+		{"owner policy: co-admin edits others' node", owner, theirs, models.RoleCoAdmin, false},
+		{"owner policy: contributor edits others' node", owner, theirs, models.RoleContributor, false},
+		{"role policy: admin edits others' node", role, theirs, models.RoleAdmin, true},
+		{"role policy: co-admin edits others' node", role, theirs, models.RoleCoAdmin, true},
+		{"role policy: contributor edits own node", role, mine, models.RoleContributor, true},
+		{"role policy: contributor edits others' node", role, theirs, models.RoleContributor, false},
+		{"role policy: viewer edits others' node", role, theirs, models.RoleViewer, false},
+	}
+	for _, tt := range tests {
+		if got := canModifyPerson(tt.settings, tt.person, "u1", string(tt.role)); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestModifyDeniedMessage(t *testing.T) {
+	if got := modifyDeniedMessage(TreeSettings{EditPolicy: EditPolicyOwner}, "delete"); got != "You can only delete nodes you created" {
+		t.Errorf("owner policy: got %q", got)
+	}
+	if got := modifyDeniedMessage(TreeSettings{EditPolicy: EditPolicyRole}, "edit"); got != "Your role cannot edit nodes created by others" {
+		t.Errorf("role policy: got %q", got)
+	}
+}
+
+// populateShape describes a parsed node by name: its level and its parent's name
+type populateShape struct {
+	level  int
+	parent string
+}
+
+func populateShapes(nodes []populateNode) map[string]populateShape {
+	names := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		names[n.ID] = n.Name
+	}
+	shapes := make(map[string]populateShape, len(nodes))
+	for _, n := range nodes {
+		parent := ""
+		if len(n.ParentIDs) > 0 {
+			parent = names[n.ParentIDs[0]]
+		}
+		shapes[n.Name] = populateShape{level: n.Level, parent: parent}
+	}
+	return shapes
+}
+
+func TestParsePopulateTextIndentation(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantUnit int
+		want     map[string]populateShape
+	}{
+		{
+			name:     "two-space indent",
+			text:     "Grandpa\n  Dad\n    Me\n  Uncle\n",
+			wantUnit: 2,
+			want: map[string]populateShape{
+				"Grandpa": {0, ""}, "Dad": {1, "Grandpa"}, "Me": {2, "Dad"}, "Uncle": {1, "Grandpa"},
+			},
+		},
+		{
+			name:     "tabs count as four spaces",
+			text:     "Grandpa\n\tDad\n\t\tMe\n",
+			wantUnit: 4,
+			want:     map[string]populateShape{"Grandpa": {0, ""}, "Dad": {1, "Grandpa"}, "Me": {2, "Dad"}},
+		},
+		{
+			name:     "deeper first indent does not set the unit",
+			text:     "Grandpa\n    Dad\n  Aunt\n",
+			wantUnit: 2,
+			want:     map[string]populateShape{"Grandpa": {0, ""}, "Dad": {2, "Grandpa"}, "Aunt": {1, "Grandpa"}},
+		},
+		{
+			name:     "blank lines and several roots",
+			text:     "First\n  Child\n\nSecond\n  Other\n",
+			wantUnit: 2,
+			want: map[string]populateShape{
+				"First": {0, ""}, "Child": {1, "First"}, "Second": {0, ""}, "Other": {1, "Second"},
+			},
+		},
+		{
+			name:     "no indentation",
+			text:     "A\nB\n",
+			wantUnit: 0,
+			want:     map[string]populateShape{"A": {0, ""}, "B": {0, ""}},
+		},
+	}
+	for _, tt := range tests {
+		nodes, unit := parsePopulateText(tt.text)
+		if unit != tt.wantUnit {
+			t.Errorf("%s: indent unit = %d, want %d", tt.name, unit, tt.wantUnit)
+		}
+		if got := populateShapes(nodes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parsed %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParsePopulateTextLinksChildren(t *testing.T) {
+	nodes, _ := parsePopulateText("Dad\n  Son\n  Daughter\n")
+	if len(nodes) != 3 {
+		t.Fatalf("parsed %d nodes, want 3", len(nodes))
+	}
+	if !reflect.DeepEqual(nodes[0].Children, []string{nodes[1].ID, nodes[2].ID}) {
+		t.Errorf("children of Dad = %v, want Son and Daughter in order", nodes[0].Children)
+	}
+}
+
+func TestParsePopulateTextMarkers(t *testing.T) {
+	nodes, _ := parsePopulateText("Ali Rezaei (m) b:1920 d:1995 l:Tehran\n  Maryam (f) 1950\n  Reza\n")
+	if len(nodes) != 3 {
+		t.Fatalf("parsed %d nodes, want 3", len(nodes))
+	}
+	ali, maryam, reza := nodes[0], nodes[1], nodes[2]
+	if ali.Name != "Ali Rezaei" || ali.Gender != "male" || ali.Birth != "1920" || ali.Death != "1995" || ali.Location != "Tehran" {
+		t.Errorf("Ali parsed as %+v", ali)
+	}
+	if maryam.Name != "Maryam" || maryam.Gender != "female" || maryam.Birth != "1950" || maryam.GenderDefaulted {
+		t.Errorf("Maryam parsed as %+v", maryam)
+	}
+	if reza.Gender != "male" || !reza.GenderDefaulted {
+		t.Errorf("Reza without a marker should default to male, got %+v", reza)
+	}
+}
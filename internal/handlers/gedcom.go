@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// gedcomLineLimit keeps lines under the 255 character limit of GEDCOM 5.5.1
+const gedcomLineLimit = 200
+
+// gedcomPedigree maps relationship types to GEDCOM PEDI values. Step children have no
+// pedigree value in 5.5.1 and are written without one.
+var gedcomPedigree = map[string]string{
+	models.RelationshipBiological: "birth",
+	models.RelationshipAdopted:    "adopted",
+	models.RelationshipFoster:     "foster",
+}
+
+// gedcomFamily is one FAM record: a set of parents and the children they share
+type gedcomFamily struct {
+	xref     string
+	parents  []string
+	children []string
+}
+
+// writeGEDCOM renders people as a GEDCOM 5.5.1 file. Only relations between the given
+// people are written, so a subset produces a self-contained file. Families are formed
+// by grouping each child with every listed parent, plus one childless family for each
+// spouse pair without one; HUSB/WIFE follow the parents' gender. SEX is omitted when the
+// gender is unknown.
+// title, when set, is written as a header note.
+func writeGEDCOM(people []models.Person, title string) []byte {
+	byID := make(map[string]models.Person, len(people))
+	xrefs := make(map[string]string, len(people))
+	for i, p := range people {
+		byID[p.ID] = p
+		xrefs[p.ID] = fmt.Sprintf("@I%d@", i+1)
+	}
+
+	// Parents of each child, limited to the people being written
+	parentsOf := make(map[string][]string)
+	for _, p := range people {
+		for _, childID := range p.Children {
+			if _, ok := byID[childID]; ok && childID != p.ID && !containsString(parentsOf[childID], p.ID) {
+				parentsOf[childID] = append(parentsOf[childID], p.ID)
+			}
+		}
+	}
+
+	// One family per distinct parent set, in the order the parents are first seen
+	var families []*gedcomFamily
+	familyByKey := make(map[string]*gedcomFamily)
+	famc := make(map[string]string)
+	for _, p := range people {
+		parentIDs := parentsOf[p.ID]
+		if len(parentIDs) == 0 {
+			continue
+		}
+		sorted := append([]string(nil), parentIDs...)
+		sort.Strings(sorted)
+		key := strings.Join(sorted, ",")
+		family, ok := familyByKey[key]
+		if !ok {
+			family = &gedcomFamily{xref: fmt.Sprintf("@F%d@", len(families)+1), parents: sorted}
+			familyByKey[key] = family
+			families = append(families, family)
+		}
+		family.children = append(family.children, p.ID)
+		famc[p.ID] = family.xref
+	}
+	for _, p := range people {
+		for _, spouseID := range p.Spouses {
+			if _, ok := byID[spouseID]; !ok || spouseID <= p.ID {
+				continue
+			}
+			couple := []string{p.ID, spouseID}
+			key := strings.Join(couple, ",")
+			if _, ok := familyByKey[key]; !ok {
+				family := &gedcomFamily{xref: fmt.Sprintf("@F%d@", len(families)+1), parents: couple}
+				familyByKey[key] = family
+				families = append(families, family)
+			}
+		}
+	}
+	fams := make(map[string][]string)
+	for _, family := range families {
+		for _, parentID := range family.parents {
+			fams[parentID] = append(fams[parentID], family.xref)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("0 HEAD\n")
+	buf.WriteString("1 SOUR FindYourRoot\n")
+	buf.WriteString(fmt.Sprintf("1 DATE %s\n", strings.ToUpper(time.Now().Format("2 Jan 2006"))))
+	buf.WriteString("1 GEDC\n2 VERS 5.5.1\n2 FORM LINEAGE-LINKED\n")
+	buf.WriteString("1 CHAR UTF-8\n")
+	if title != "" {
+		writeGEDCOMText(&buf, 1, "NOTE", title)
+	}
+
+	for _, p := range people {
+		buf.WriteString(fmt.Sprintf("0 %s INDI\n", xrefs[p.ID]))
+		writeGEDCOMText(&buf, 1, "NAME", p.Name)
+		for _, alt := range p.AltNames {
+			writeGEDCOMText(&buf, 1, "NAME", alt)
+			buf.WriteString("2 TYPE aka\n")
+		}
+		switch p.Gender {
+		case "male":
+			buf.WriteString("1 SEX M\n")
+		case "female":
+			buf.WriteString("1 SEX F\n")
+		}
+		if date := utils.GEDCOMDate(p.Birth); date != "" || p.Location != "" {
+			buf.WriteString("1 BIRT\n")
+			if date != "" {
+				writeGEDCOMText(&buf, 2, "DATE", date)
+			}
+			if p.Location != "" {
+				writeGEDCOMText(&buf, 2, "PLAC", p.Location)
+			}
+		}
+		if date := utils.GEDCOMDate(p.Death); date != "" {
+			buf.WriteString("1 DEAT\n")
+			writeGEDCOMText(&buf, 2, "DATE", date)
+		} else if p.Deceased {
+			buf.WriteString("1 DEAT Y\n")
+		}
+		if p.Occupation != "" {
+			writeGEDCOMText(&buf, 1, "OCCU", p.Occupation)
+		}
+		if p.Bio != "" {
+			writeGEDCOMText(&buf, 1, "NOTE", p.Bio)
+		}
+		if xref, ok := famc[p.ID]; ok {
+			buf.WriteString(fmt.Sprintf("1 FAMC %s\n", xref))
+			if pedigree, ok := gedcomPedigree[p.ParentRelationship()]; ok {
+				buf.WriteString(fmt.Sprintf("2 PEDI %s\n", pedigree))
+			}
+		}
+		for _, xref := range fams[p.ID] {
+			buf.WriteString(fmt.Sprintf("1 FAMS %s\n", xref))
+		}
+	}
+
+	for _, family := range families {
+		buf.WriteString(fmt.Sprintf("0 %s FAM\n", family.xref))
+		husbandWritten, wifeWritten := false, false
+		for _, parentID := range family.parents {
+			// GEDCOM allows one HUSB and one WIFE; parents of unknown or repeated gender fill the free slot
+			gender := byID[parentID].Gender
+			switch {
+			case gender == "female" && !wifeWritten:
+				buf.WriteString(fmt.Sprintf("1 WIFE %s\n", xrefs[parentID]))
+				wifeWritten = true
+			case !husbandWritten:
+				buf.WriteString(fmt.Sprintf("1 HUSB %s\n", xrefs[parentID]))
+				husbandWritten = true
+			case !wifeWritten:
+				buf.WriteString(fmt.Sprintf("1 WIFE %s\n", xrefs[parentID]))
+				wifeWritten = true
+			}
+		}
+		for _, childID := range family.children {
+			buf.WriteString(fmt.Sprintf("1 CHIL %s\n", xrefs[childID]))
+		}
+	}
+
+	buf.WriteString("0 TRLR\n")
+	return buf.Bytes()
+}
+
+// writeGEDCOMText writes a tagged value, splitting newlines into CONT lines and
+// long lines into CONC lines
+func writeGEDCOMText(buf *bytes.Buffer, level int, tag, value string) {
+	for i, line := range strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n") {
+		runes := []rune(line)
+		first := runes
+		if len(first) > gedcomLineLimit {
+			first = runes[:gedcomLineLimit]
+		}
+		if i == 0 {
+			buf.WriteString(fmt.Sprintf("%d %s %s\n", level, tag, string(first)))
+		} else {
+			buf.WriteString(fmt.Sprintf("%d CONT %s\n", level+1, string(first)))
+		}
+		for rest := runes[len(first):]; len(rest) > 0; {
+			chunk := rest
+			if len(chunk) > gedcomLineLimit {
+				chunk = rest[:gedcomLineLimit]
+			}
+			buf.WriteString(fmt.Sprintf("%d CONC %s\n", level+1, string(chunk)))
+			rest = rest[len(chunk):]
+		}
+	}
+}
+
+// ExportGEDCOM exports the whole tree as a GEDCOM 5.5.1 file. People are written in ID
+// order so repeated exports of an unchanged tree only differ in the header date.
+func (h *FirestoreExportHandler) ExportGEDCOM(c *gin.Context) {
+	people, err := h.exportPeople(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// people may be the shared snapshot cache entry, so sort a copy
+	people = append([]models.Person(nil), people...)
+	sort.Slice(people, func(i, j int) bool { return people[i].ID < people[j].ID })
+
+	settings := loadTreeSettings(treeContext(c), h.client)
+	filename := fmt.Sprintf("family-tree-%s.ged", time.Now().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "application/x-gedcom", writeGEDCOM(people, settings.TreeName))
+}
+
+// ExportFamilyGEDCOM exports a person's immediate family as a GEDCOM fragment:
+// the person, their parents, their children and the other parents of those children.
+func (h *FirestoreExportHandler) ExportFamilyGEDCOM(c *gin.Context) {
+	id := c.Param("id")
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+	var person models.Person
+	if err := doc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+
+	parents, err := findParents(ctx, h.client, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parents"})
+		return
+	}
+	children, err := fetchPeopleByIDs(ctx, h.client, person.Children)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch children"})
+		return
+	}
+
+	partners, err := findPartners(ctx, h.client, person)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch partners"})
+		return
+	}
+
+	family := []models.Person{person}
+	seen := map[string]bool{person.ID: true}
+	for _, group := range [][]models.Person{parents, partners, children} {
+		for _, p := range group {
+			if !seen[p.ID] {
+				seen[p.ID] = true
+				family = append(family, p)
+			}
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=person-%s.ged", person.ID))
+	c.Data(http.StatusOK, "application/x-gedcom", writeGEDCOM(family, "Immediate family of "+person.Name))
+}
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// gedcomLinePattern matches "level [@xref@] TAG [value]"
+var gedcomLinePattern = regexp.MustCompile(`^(\d{1,2})(?:\s+(@[^@\s]+@))?\s+([A-Za-z0-9_]+)(?:\s(.*))?$`)
+
+// gedcomImportPedigree maps GEDCOM PEDI values back to relationship types
+var gedcomImportPedigree = map[string]string{
+	"birth":   models.RelationshipBiological,
+	"adopted": models.RelationshipAdopted,
+	"foster":  models.RelationshipFoster,
+}
+
+// gedcomNode is one GEDCOM line with its nested lines. CONT/CONC lines are folded into
+// the value of the line they continue.
+type gedcomNode struct {
+	xref     string
+	tag      string
+	value    string
+	children []*gedcomNode
+}
+
+// first returns the first nested line with the given tag, or nil
+func (n *gedcomNode) first(tag string) *gedcomNode {
+	for _, child := range n.children {
+		if child.tag == tag {
+			return child
+		}
+	}
+	return nil
+}
+
+// parseGEDCOM reads a GEDCOM file into its level-0 records. The file must start with a
+// HEAD record and every line must be well formed, with levels increasing one at a time.
+func parseGEDCOM(r io.Reader) ([]*gedcomNode, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var records []*gedcomNode
+	var stack []*gedcomNode
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if lineNum == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		match := gedcomLinePattern.FindStringSubmatch(strings.TrimLeft(text, " \t"))
+		if match == nil {
+			return nil, fmt.Errorf("line %d is not a valid GEDCOM line", lineNum)
+		}
+		level, _ := strconv.Atoi(match[1])
+		node := &gedcomNode{xref: match[2], tag: strings.ToUpper(match[3]), value: match[4]}
+
+		if len(stack) == 0 && level > 0 {
+			return nil, fmt.Errorf("line %d must be level 0", lineNum)
+		}
+		if level > len(stack) {
+			return nil, fmt.Errorf("line %d jumps from level %d to %d", lineNum, len(stack)-1, level)
+		}
+		stack = stack[:level]
+		if level == 0 {
+			if len(records) == 0 && node.tag != "HEAD" {
+				return nil, errors.New("file does not start with a HEAD record")
+			}
+			records = append(records, node)
+		} else {
+			parent := stack[level-1]
+			switch node.tag {
+			case "CONT":
+				parent.value += "\n" + node.value
+				continue
+			case "CONC":
+				parent.value += node.value
+				continue
+			}
+			parent.children = append(parent.children, node)
+		}
+		stack = append(stack, node)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+	return records, nil
+}
+
+// gedcomPersonName turns a GEDCOM name ("John /Smith/") into a display name
+func gedcomPersonName(value string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(value, "/", " ")), " ")
+}
+
+// GEDCOMImportRecord is the result for one INDI record of an imported GEDCOM file
+type GEDCOMImportRecord struct {
+	Xref     string `json:"xref"`
+	Name     string `json:"name"`
+	PersonID string `json:"person_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// GEDCOMImportReport summarizes a GEDCOM import
+type GEDCOMImportReport struct {
+	Created  []GEDCOMImportRecord `json:"created"`
+	Skipped  []GEDCOMImportRecord `json:"skipped"`
+	Families int                  `json:"families"` // FAM records read
+	Links    int                  `json:"links"`    // Parent-child links created
+}
+
+// ImportGEDCOM creates people from an uploaded GEDCOM file (form field "file") and rebuilds
+// their children arrays and spouse links from its FAM records. Every individual becomes a new person;
+// people without family links become roots. Repeated INDI ids keep the first record.
+func (h *FirestoreTreeHandler) ImportGEDCOM(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "GEDCOM file is required (form field 'file')"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	records, err := parseGEDCOM(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Malformed GEDCOM file: " + err.Error()})
+		return
+	}
+
+	order, people, report, err := gedcomPeople(records, userID.(string), requestTreeID(c), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Malformed GEDCOM file: " + err.Error()})
+		return
+	}
+
+	// Write in batches (Firestore batch limit is 500)
+	ctx := treeContext(c)
+	batch := h.client.Batch()
+	count := 0
+	for _, xref := range order {
+		person := people[xref]
+		batch.Set(h.coll(ctx, "people").Doc(person.ID), *person)
+		count++
+
+		if count%500 == 0 {
+			if _, err := batch.Commit(ctx); err != nil {
+				log.Printf("[ImportGEDCOM] Batch commit failed: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create people", "report": report})
+				return
+			}
+			batch = h.client.Batch()
+		}
+		report.Created = append(report.Created, GEDCOMImportRecord{Xref: xref, Name: person.Name, PersonID: person.ID})
+	}
+	if count%500 != 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			log.Printf("[ImportGEDCOM] Batch commit failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create people"})
+			return
+		}
+	}
+
+	log.Printf("[ImportGEDCOM] Imported GEDCOM by %s: created=%d skipped=%d families=%d links=%d",
+		userID, len(report.Created), len(report.Skipped), report.Families, report.Links)
+	c.JSON(http.StatusOK, report)
+}
+
+// gedcomPeople turns parsed GEDCOM records into new people, in file order, keyed by xref.
+// Children arrays and spouse links are rebuilt from the FAM records; the report lists
+// skipped individuals and the families and links read.
+func gedcomPeople(records []*gedcomNode, createdBy, treeID string, now time.Time) ([]string, map[string]*models.Person, GEDCOMImportReport, error) {
+	report := GEDCOMImportReport{
+		Created: []GEDCOMImportRecord{},
+		Skipped: []GEDCOMImportRecord{},
+	}
+
+	// Individuals, keyed by their GEDCOM xref
+	people := make(map[string]*models.Person)
+	pedigree := make(map[string]map[string]string) // individual xref -> family xref -> relationship type
+	var order []string
+	individuals := 0
+	for _, record := range records {
+		if record.tag != "INDI" {
+			continue
+		}
+		individuals++
+
+		var names []string
+		for _, child := range record.children {
+			if child.tag == "NAME" {
+				if name := gedcomPersonName(child.value); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+		skip := func(reason string) {
+			name := ""
+			if len(names) > 0 {
+				name = names[0]
+			}
+			report.Skipped = append(report.Skipped, GEDCOMImportRecord{Xref: record.xref, Name: name, Reason: reason})
+		}
+		switch {
+		case record.xref == "":
+			skip("Individual has no ID")
+			continue
+		case people[record.xref] != nil:
+			skip("Duplicate INDI ID")
+			continue
+		case len(names) == 0:
+			skip("Individual has no name")
+			continue
+		}
+
+		person := &models.Person{
+			ID:        uuid.New().String(),
+			Name:      names[0],
+			AltNames:  []string{},
+			Role:      defaultPersonRole,
+			Children:  []string{},
+			ParentIDs: []string{},
+			Spouses:   []string{},
+			CreatedBy: createdBy,
+			TreeID:    treeID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if len(names) > 1 {
+			altNames, err := utils.CleanAltNames(names[1:])
+			if err != nil {
+				skip(err.Error())
+				continue
+			}
+			person.AltNames = altNames
+		}
+		if sex := record.first("SEX"); sex != nil {
+			switch strings.ToUpper(strings.TrimSpace(sex.value)) {
+			case "M":
+				person.Gender = "male"
+			case "F":
+				person.Gender = "female"
+			}
+		}
+		if birth := record.first("BIRT"); birth != nil {
+			if date := birth.first("DATE"); date != nil {
+				person.Birth = utils.ParseGEDCOMDate(date.value)
+			}
+			if place := birth.first("PLAC"); place != nil {
+				person.Location = strings.TrimSpace(place.value)
+			}
+		}
+		if death := record.first("DEAT"); death != nil {
+			person.Deceased = true
+			if date := death.first("DATE"); date != nil {
+				person.Death = utils.ParseGEDCOMDate(date.value)
+			}
+		}
+		if occupation := record.first("OCCU"); occupation != nil {
+			person.Occupation = strings.TrimSpace(occupation.value)
+		}
+		// Only inline notes are imported; pointers to NOTE records are ignored
+		if note := record.first("NOTE"); note != nil && !strings.HasPrefix(note.value, "@") {
+			bio, err := utils.SanitizeText(strings.TrimSpace(note.value))
+			if err != nil {
+				skip("HTML is not allowed in note")
+				continue
+			}
+			person.Bio = bio
+		}
+		person.Avatar = generateGenderAvatar(person.Name, person.Gender)
+
+		for _, child := range record.children {
+			if child.tag != "FAMC" || child.value == "" {
+				continue
+			}
+			if pedi := child.first("PEDI"); pedi != nil {
+				if relationship, ok := gedcomImportPedigree[strings.ToLower(strings.TrimSpace(pedi.value))]; ok {
+					if pedigree[record.xref] == nil {
+						pedigree[record.xref] = map[string]string{}
+					}
+					pedigree[record.xref][child.value] = relationship
+				}
+			}
+		}
+
+		people[record.xref] = person
+		order = append(order, record.xref)
+	}
+	if individuals == 0 {
+		return nil, nil, report, errors.New("no INDI records found")
+	}
+
+	// Rebuild children arrays from FAM records; links to unknown or skipped individuals are dropped
+	for _, record := range records {
+		if record.tag != "FAM" {
+			continue
+		}
+		report.Families++
+		var parents []*models.Person
+		for _, child := range record.children {
+			if child.tag == "HUSB" || child.tag == "WIFE" {
+				if parent := people[child.value]; parent != nil {
+					parents = append(parents, parent)
+				}
+			}
+		}
+		// A FAM record with both partners is a couple, matching how ExportGEDCOM writes spouses
+		if len(parents) == 2 && parents[0].ID != parents[1].ID && !containsString(parents[0].Spouses, parents[1].ID) {
+			parents[0].Spouses = append(parents[0].Spouses, parents[1].ID)
+			parents[1].Spouses = append(parents[1].Spouses, parents[0].ID)
+		}
+		for _, child := range record.children {
+			if child.tag != "CHIL" {
+				continue
+			}
+			kid := people[child.value]
+			if kid == nil {
+				continue
+			}
+			for _, parent := range parents {
+				if parent.ID == kid.ID || containsString(parent.Children, kid.ID) {
+					continue
+				}
+				parent.Children = append(parent.Children, kid.ID)
+				kid.ParentIDs = append(kid.ParentIDs, parent.ID)
+				report.Links++
+			}
+			if relationship, ok := pedigree[child.value][record.xref]; ok && kid.RelationshipType == "" {
+				kid.RelationshipType = relationship
+			}
+		}
+	}
+	for _, person := range people {
+		if person.RelationshipType == "" {
+			person.RelationshipType = models.RelationshipBiological
+		}
+	}
+	return order, people, report, nil
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+func TestGEDCOMRoundTrip(t *testing.T) {
+	longBio := strings.Repeat("a", gedcomLineLimit+50)
+	exported := []models.Person{
+		{ID: "dad", Name: "Ali Rezaei", AltNames: []string{"Aly"}, Gender: "male", Birth: "1950", Location: "Tehran",
+			Occupation: "Teacher", Children: []string{"son", "daughter"}, Spouses: []string{"mom"}},
+		{ID: "mom", Name: "Maryam Ahmadi", Gender: "female", Birth: "1955-03", Death: "2010-01-02", Deceased: true,
+			Children: []string{"son", "daughter"}, Spouses: []string{"dad"}},
+		{ID: "son", Name: "Reza Rezaei", Gender: "male", Birth: "05/06/1980", Bio: "First line\nSecond line"},
+		{ID: "daughter", Name: "Sara Rezaei", Gender: "female", Bio: longBio, RelationshipType: models.RelationshipAdopted},
+		{ID: "uncle", Name: "Hassan Rezaei", Deceased: true},
+	}
+
+	records, err := parseGEDCOM(bytes.NewReader(writeGEDCOM(exported, "Test tree")))
+	if err != nil {
+		t.Fatalf("exported file does not parse: %v", err)
+	}
+	_, imported, report, err := gedcomPeople(records, "importer", "", time.Now())
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if len(report.Skipped) != 0 {
+		t.Fatalf("skipped records: %+v", report.Skipped)
+	}
+	if len(imported) != len(exported) {
+		t.Fatalf("imported %d people, want %d", len(imported), len(exported))
+	}
+
+	// New IDs are assigned on import; compare through names
+	byName := make(map[string]*models.Person)
+	nameOf := make(map[string]string)
+	for _, p := range imported {
+		byName[p.Name] = p
+		nameOf[p.ID] = p.Name
+	}
+	names := func(ids []string) []string {
+		out := []string{}
+		for _, id := range ids {
+			out = append(out, nameOf[id])
+		}
+		sort.Strings(out)
+		return out
+	}
+	exportedNames := make(map[string]string)
+	for _, p := range exported {
+		exportedNames[p.ID] = p.Name
+	}
+
+	for _, want := range exported {
+		got := byName[want.Name]
+		if got == nil {
+			t.Errorf("%s missing after round trip", want.Name)
+			continue
+		}
+		// Dates come back in the stored ISO form, so compare their GEDCOM rendering
+		if got.Gender != want.Gender || utils.GEDCOMDate(got.Birth) != utils.GEDCOMDate(want.Birth) ||
+			utils.GEDCOMDate(got.Death) != utils.GEDCOMDate(want.Death) || got.Deceased != want.Deceased {
+			t.Errorf("%s: gender/birth/death = %q/%q/%q/%v, want %q/%q/%q/%v", want.Name,
+				got.Gender, got.Birth, got.Death, got.Deceased, want.Gender, want.Birth, want.Death, want.Deceased)
+		}
+		if got.Location != want.Location || got.Occupation != want.Occupation || got.Bio != want.Bio {
+			t.Errorf("%s: location/occupation/bio = %q/%q/%q, want %q/%q/%q", want.Name,
+				got.Location, got.Occupation, got.Bio, want.Location, want.Occupation, want.Bio)
+		}
+		if len(want.AltNames) > 0 && !reflect.DeepEqual(got.AltNames, want.AltNames) {
+			t.Errorf("%s: alt names = %v, want %v", want.Name, got.AltNames, want.AltNames)
+		}
+		if got.ParentRelationship() != want.ParentRelationship() {
+			t.Errorf("%s: relationship = %q, want %q", want.Name, got.ParentRelationship(), want.ParentRelationship())
+		}
+
+		var wantChildren, wantSpouses []string
+		for _, id := range want.Children {
+			wantChildren = append(wantChildren, exportedNames[id])
+		}
+		for _, id := range want.Spouses {
+			wantSpouses = append(wantSpouses, exportedNames[id])
+		}
+		sort.Strings(wantChildren)
+		sort.Strings(wantSpouses)
+		if wantChildren == nil {
+			wantChildren = []string{}
+		}
+		if wantSpouses == nil {
+			wantSpouses = []string{}
+		}
+		if got := names(got.Children); !reflect.DeepEqual(got, wantChildren) {
+			t.Errorf("%s: children = %v, want %v", want.Name, got, wantChildren)
+		}
+		if got := names(got.Spouses); !reflect.DeepEqual(got, wantSpouses) {
+			t.Errorf("%s: spouses = %v, want %v", want.Name, got, wantSpouses)
+		}
+	}
+}
+
+func TestGEDCOMPeopleRequiresIndividuals(t *testing.T) {
+	records, err := parseGEDCOM(strings.NewReader("0 HEAD\n1 CHAR UTF-8\n0 TRLR\n"))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if _, _, _, err := gedcomPeople(records, "importer", "", time.Now()); err == nil {
+		t.Error("expected a file without INDI records to be rejected")
+	}
+}
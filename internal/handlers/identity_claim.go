@@ -2,7 +2,11 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 	"time"
@@ -32,14 +36,29 @@ func (h *FirestoreIdentityClaimHandler) ClaimIdentity(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !sanitizeFields(c, &req.Message) {
+		return
+	}
+
+	evidence, err := cleanClaimEvidence(req.Evidence)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	instagramUsername := strings.TrimPrefix(strings.TrimSpace(req.InstagramUsername), "@")
+	if instagramUsername != "" && !utils.ValidateInstagramUsername(instagramUsername) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Instagram username format"})
+		return
+	}
 
 	userID, _ := c.Get("user_id")
 	userEmail, _ := c.Get("email")
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Check if user already has a linked person (Person owns this relationship)
 	// Query the Person collection to find if any person links to this user
-	existingLinkIter := h.client.Collection("people").Where("linked_user_id", "==", userID.(string)).Limit(1).Documents(ctx)
+	existingLinkIter := h.coll(ctx, "people").Where("linked_user_id", "==", userID.(string)).Limit(1).Documents(ctx)
 	existingLinkDoc, err := existingLinkIter.Next()
 	existingLinkIter.Stop()
 	if err == nil && existingLinkDoc != nil {
@@ -48,7 +67,7 @@ func (h *FirestoreIdentityClaimHandler) ClaimIdentity(c *gin.Context) {
 	}
 
 	// Check if the person exists
-	personDoc, err := h.client.Collection("people").Doc(req.PersonID).Get(ctx)
+	personDoc, err := h.coll(ctx, "people").Doc(req.PersonID).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found in the tree"})
 		return
@@ -67,7 +86,7 @@ func (h *FirestoreIdentityClaimHandler) ClaimIdentity(c *gin.Context) {
 	}
 
 	// Check if user already has a pending claim
-	iter := h.client.Collection("identity_claims").
+	iter := h.coll(ctx, "identity_claims").
 		Where("user_id", "==", userID.(string)).
 		Where("status", "==", "pending").
 		Limit(1).
@@ -84,18 +103,20 @@ func (h *FirestoreIdentityClaimHandler) ClaimIdentity(c *gin.Context) {
 	now := time.Now()
 
 	claim := models.IdentityClaimRequest{
-		ID:         claimID,
-		UserID:     userID.(string),
-		UserEmail:  userEmail.(string),
-		PersonID:   req.PersonID,
-		PersonName: person.Name,
-		Message:    req.Message,
-		Status:     "pending",
-		CreatedAt:  now,
-		UpdatedAt:  now,
-	}
-
-	_, err = h.client.Collection("identity_claims").Doc(claimID).Set(ctx, claim)
+		ID:                claimID,
+		UserID:            userID.(string),
+		UserEmail:         userEmail.(string),
+		PersonID:          req.PersonID,
+		PersonName:        person.Name,
+		Message:           req.Message,
+		Evidence:          evidence,
+		InstagramUsername: instagramUsername,
+		Status:            "pending",
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	_, err = h.coll(ctx, "identity_claims").Doc(claimID).Set(ctx, claim)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create identity claim"})
 		return
@@ -107,14 +128,39 @@ func (h *FirestoreIdentityClaimHandler) ClaimIdentity(c *gin.Context) {
 	})
 }
 
+// maxClaimEvidence is the maximum number of evidence links on one identity claim
+const maxClaimEvidence = 5
+
+// cleanClaimEvidence trims and validates evidence links: absolute http(s) URLs, deduplicated
+func cleanClaimEvidence(evidence []string) ([]string, error) {
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, raw := range evidence {
+		link := strings.TrimSpace(raw)
+		if link == "" || seen[link] {
+			continue
+		}
+		parsed, err := url.Parse(link)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(link) > 2048 {
+			return nil, fmt.Errorf("invalid evidence URL: %s", link)
+		}
+		seen[link] = true
+		cleaned = append(cleaned, link)
+	}
+	if len(cleaned) > maxClaimEvidence {
+		return nil, fmt.Errorf("at most %d evidence links are allowed", maxClaimEvidence)
+	}
+	return cleaned, nil
+}
+
 // GetMyIdentityClaim returns the current user's identity claim status
 func (h *FirestoreIdentityClaimHandler) GetMyIdentityClaim(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Check if user is already linked (Person owns this relationship)
 	// Query the Person collection to find if any person links to this user
-	linkedPersonIter := h.client.Collection("people").Where("linked_user_id", "==", userID.(string)).Limit(1).Documents(ctx)
+	linkedPersonIter := h.coll(ctx, "people").Where("linked_user_id", "==", userID.(string)).Limit(1).Documents(ctx)
 	linkedPersonDoc, err := linkedPersonIter.Next()
 	linkedPersonIter.Stop()
 	if err == nil && linkedPersonDoc != nil {
@@ -130,7 +176,7 @@ func (h *FirestoreIdentityClaimHandler) GetMyIdentityClaim(c *gin.Context) {
 	}
 
 	// Find any pending or recent claims - query without OrderBy to avoid index requirement
-	iter := h.client.Collection("identity_claims").
+	iter := h.coll(ctx, "identity_claims").
 		Where("user_id", "==", userID.(string)).
 		Documents(ctx)
 
@@ -174,10 +220,10 @@ func (h *FirestoreIdentityClaimHandler) GetMyIdentityClaim(c *gin.Context) {
 // GetIdentityClaims returns all identity claims (admin only)
 func (h *FirestoreIdentityClaimHandler) GetIdentityClaims(c *gin.Context) {
 	status := c.DefaultQuery("status", "pending")
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Query without OrderBy to avoid needing composite index
-	iter := h.client.Collection("identity_claims").
+	iter := h.coll(ctx, "identity_claims").
 		Where("status", "==", status).
 		Documents(ctx)
 	defer iter.Stop()
@@ -197,6 +243,9 @@ func (h *FirestoreIdentityClaimHandler) GetIdentityClaims(c *gin.Context) {
 		if err := doc.DataTo(&claim); err != nil {
 			continue
 		}
+		if claim.Evidence == nil {
+			claim.Evidence = []string{} // Claims created before evidence was supported
+		}
 		claims = append(claims, claim)
 	}
 
@@ -221,12 +270,15 @@ func (h *FirestoreIdentityClaimHandler) ReviewIdentityClaim(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !sanitizeFields(c, &req.ReviewNotes) {
+		return
+	}
 
 	adminID, _ := c.Get("user_id")
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Get the claim
-	claimDoc, err := h.client.Collection("identity_claims").Doc(claimID).Get(ctx)
+	claimDoc, err := h.coll(ctx, "identity_claims").Doc(claimID).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Claim not found"})
 		return
@@ -243,70 +295,127 @@ func (h *FirestoreIdentityClaimHandler) ReviewIdentityClaim(c *gin.Context) {
 		return
 	}
 
+	err = h.applyClaimReview(ctx, claimID, claim, req.Approved, req.ReviewNotes, adminID.(string))
+	if err == errPersonAlreadyLinked {
+		c.JSON(http.StatusConflict, gin.H{"error": "This person is already linked to another user"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process review"})
+		return
+	}
+
+	// Enrich the newly linked person like LinkUserToPerson does; failures don't undo the approval
+	if req.Approved && claim.InstagramUsername != "" && !req.DeferEnrichment {
+		profile, err := utils.FetchInstagramProfileCached(claim.InstagramUsername)
+		if err == nil && profile != nil {
+			updates := append(instagramProfileUpdates(profile), firestore.Update{Path: "updated_at", Value: time.Now()})
+			if _, err := h.coll(ctx, "people").Doc(claim.PersonID).Update(ctx, updates); err != nil {
+				log.Printf("[IdentityClaim] Failed to store Instagram data for %s: %v", claim.PersonID, err)
+			}
+		}
+	}
+
+	message := "Identity claim rejected"
+	if req.Approved {
+		message = "Identity claim approved. User is now linked to the tree node."
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// errPersonAlreadyLinked is returned when approving a claim for, or creating a user linked
+// to, a person linked to someone else
+var errPersonAlreadyLinked = errors.New("person already linked to another user")
+
+// applyClaimReview records the decision on a pending claim and, when approved, verifies the
+// user and links the person in one transaction
+// NOTE: Person owns the link (Person.LinkedUserID), User does NOT store person_id
+func (h *FirestoreIdentityClaimHandler) applyClaimReview(ctx context.Context, claimID string, claim models.IdentityClaimRequest, approved bool, notes, adminID string) error {
 	now := time.Now()
 	newStatus := "rejected"
-	if req.Approved {
+	if approved {
 		newStatus = "approved"
 	}
 
-	// Use a transaction to update claim and person atomically
-	// NOTE: Person owns the link (Person.LinkedUserID), User does NOT store person_id
-	err = h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
-		// Update the claim
-		claimRef := h.client.Collection("identity_claims").Doc(claimID)
-		if err := tx.Update(claimRef, []firestore.Update{
-			{Path: "status", Value: newStatus},
-			{Path: "reviewed_by", Value: adminID.(string)},
-			{Path: "review_notes", Value: req.ReviewNotes},
-			{Path: "updated_at", Value: now},
-		}); err != nil {
-			return err
-		}
-
-		if req.Approved {
-			// Update user verification status (but NOT person_id - Person owns that)
-			userRef := h.client.Collection("users").Doc(claim.UserID)
-			if err := tx.Update(userRef, []firestore.Update{
-				{Path: "is_verified", Value: true},
-				{Path: "updated_at", Value: now},
-			}); err != nil {
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		personRef := h.coll(ctx, "people").Doc(claim.PersonID)
+		if approved {
+			// Reads must happen before writes inside a transaction
+			personDoc, err := tx.Get(personRef)
+			if err != nil {
 				return err
 			}
-
-			// Link the person to the user - Person is the OWNER of this relationship
-			personRef := h.client.Collection("people").Doc(claim.PersonID)
-			if err := tx.Update(personRef, []firestore.Update{
-				{Path: "linked_user_id", Value: claim.UserID},
-				{Path: "updated_at", Value: now},
-			}); err != nil {
+			var person models.Person
+			if err := personDoc.DataTo(&person); err != nil {
 				return err
 			}
+			if person.LinkedUserID != "" && person.LinkedUserID != claim.UserID {
+				return errPersonAlreadyLinked
+			}
 		}
 
-		return nil
+		return h.writeClaimDecision(ctx, tx, claimID, claim, approved, notes, adminID, now)
 	})
-
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process review"})
-		return
+		return err
 	}
+	notifyClaimReviewed(h.client, claim, newStatus, notes)
+	return nil
+}
 
-	message := "Identity claim rejected"
-	if req.Approved {
-		message = "Identity claim approved. User is now linked to the tree node."
+// writeClaimDecision stages the writes for a claim decision inside a transaction: the claim's
+// status and, when approved, the user's verification and the person's link. Callers must do
+// their reads (and the already-linked check) first.
+func (h *FirestoreIdentityClaimHandler) writeClaimDecision(ctx context.Context, tx *firestore.Transaction, claimID string, claim models.IdentityClaimRequest, approved bool, notes, adminID string, now time.Time) error {
+	newStatus := "rejected"
+	if approved {
+		newStatus = "approved"
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": message})
+	// Update the claim
+	claimRef := h.coll(ctx, "identity_claims").Doc(claimID)
+	if err := tx.Update(claimRef, []firestore.Update{
+		{Path: "status", Value: newStatus},
+		{Path: "reviewed_by", Value: adminID},
+		{Path: "review_notes", Value: notes},
+		{Path: "updated_at", Value: now},
+	}); err != nil {
+		return err
+	}
+
+	if !approved {
+		return nil
+	}
+
+	// Update user verification status (but NOT person_id - Person owns that)
+	userRef := h.coll(ctx, "users").Doc(claim.UserID)
+	if err := tx.Update(userRef, []firestore.Update{
+		{Path: "is_verified", Value: true},
+		{Path: "updated_at", Value: now},
+	}); err != nil {
+		return err
+	}
+
+	// Link the person to the user - Person is the OWNER of this relationship
+	personUpdates := []firestore.Update{
+		{Path: "linked_user_id", Value: claim.UserID},
+		{Path: "updated_at", Value: now},
+	}
+	if claim.InstagramUsername != "" {
+		personUpdates = append(personUpdates, firestore.Update{Path: "instagram_username", Value: claim.InstagramUsername})
+	}
+	return tx.Update(h.coll(ctx, "people").Doc(claim.PersonID), personUpdates)
 }
 
 // UnlinkIdentity allows admin to unlink a user from a tree node
 // Person is the OWNER of the link, so we find the person that links to this user and clear it
 func (h *FirestoreIdentityClaimHandler) UnlinkIdentity(c *gin.Context) {
 	userID := c.Param("user_id")
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Find the person that links to this user (Person owns the relationship)
-	iter := h.client.Collection("people").Where("linked_user_id", "==", userID).Limit(1).Documents(ctx)
+	iter := h.coll(ctx, "people").Where("linked_user_id", "==", userID).Limit(1).Documents(ctx)
 	personDoc, err := iter.Next()
 	iter.Stop()
 
@@ -322,7 +431,7 @@ func (h *FirestoreIdentityClaimHandler) UnlinkIdentity(c *gin.Context) {
 	now := time.Now()
 
 	// Only update Person - Person is the single source of truth for the link
-	_, err = h.client.Collection("people").Doc(personDoc.Ref.ID).Update(ctx, []firestore.Update{
+	_, err = h.coll(ctx, "people").Doc(personDoc.Ref.ID).Update(ctx, []firestore.Update{
 		{Path: "linked_user_id", Value: ""},
 		{Path: "updated_at", Value: now},
 	})
@@ -335,11 +444,190 @@ func (h *FirestoreIdentityClaimHandler) UnlinkIdentity(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User unlinked from tree node successfully"})
 }
 
+// GetLinkedPeople lists every person linked to a user account, with that user's
+// email and role (admin/co-admin only)
+func (h *FirestoreIdentityClaimHandler) GetLinkedPeople(c *gin.Context) {
+	ctx := treeContext(c)
+
+	iter := h.coll(ctx, "people").Where("linked_user_id", "!=", "").Documents(ctx)
+	defer iter.Stop()
+
+	var people []models.Person
+	var userRefs []*firestore.DocumentRef
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked people"})
+			return
+		}
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			continue
+		}
+		people = append(people, person)
+		userRefs = append(userRefs, h.coll(ctx, "users").Doc(person.LinkedUserID))
+	}
+
+	linked := make([]models.LinkedPersonResponse, len(people))
+	if len(userRefs) > 0 {
+		userDocs, err := h.client.GetAll(ctx, userRefs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked users"})
+			return
+		}
+		for i, person := range people {
+			linked[i] = models.LinkedPersonResponse{
+				PersonID:   person.ID,
+				PersonName: person.Name,
+				UserID:     person.LinkedUserID,
+			}
+			if !userDocs[i].Exists() {
+				continue
+			}
+			var user models.User
+			if err := userDocs[i].DataTo(&user); err == nil {
+				linked[i].UserEmail = user.Email
+				linked[i].UserRole = user.Role
+			}
+		}
+	}
+
+	sort.Slice(linked, func(i, j int) bool {
+		return linked[i].PersonName < linked[j].PersonName
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"linked": linked,
+		"total":  len(linked),
+	})
+}
+
+// TransferIdentityRequest moves a person's link to a different user
+type TransferIdentityRequest struct {
+	PersonID  string `json:"person_id" binding:"required"`
+	NewUserID string `json:"new_user_id" binding:"required"`
+}
+
+// Transfer failures mapped to response codes
+var (
+	errPersonNotFound    = errors.New("person not found")
+	errUserNotFound      = errors.New("user not found")
+	errUserAlreadyLinked = errors.New("user already linked to another person")
+)
+
+// TransferIdentityLink atomically moves a person's link from its current user to another
+// one (admin only). The previous user loses verification; the new user gains it. Running
+// in one transaction means the node is never unlinked and open to self-claims.
+func (h *FirestoreIdentityClaimHandler) TransferIdentityLink(c *gin.Context) {
+	var req TransferIdentityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := treeContext(c)
+	var previousUserID string
+
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		personRef := h.coll(ctx, "people").Doc(req.PersonID)
+		personDoc, err := tx.Get(personRef)
+		if err != nil {
+			return errPersonNotFound
+		}
+		var person models.Person
+		if err := personDoc.DataTo(&person); err != nil {
+			return err
+		}
+		previousUserID = person.LinkedUserID
+		if previousUserID == req.NewUserID {
+			return nil // Already linked to this user
+		}
+
+		newUserRef := h.coll(ctx, "users").Doc(req.NewUserID)
+		if newUserDoc, err := tx.Get(newUserRef); err != nil || !userInTree(ctx, newUserDoc) {
+			return errUserNotFound
+		}
+
+		existing, err := tx.Documents(h.coll(ctx, "people").Where("linked_user_id", "==", req.NewUserID).Limit(1)).GetAll()
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return errUserAlreadyLinked
+		}
+
+		// The previous user may have been deleted - only update it if it still exists
+		var previousUserRef *firestore.DocumentRef
+		if previousUserID != "" {
+			ref := h.coll(ctx, "users").Doc(previousUserID)
+			if prevDoc, err := tx.Get(ref); err == nil && prevDoc.Exists() {
+				previousUserRef = ref
+			}
+		}
+
+		now := time.Now()
+		// Person is the OWNER of the link; User only carries verification
+		if err := tx.Update(personRef, []firestore.Update{
+			{Path: "linked_user_id", Value: req.NewUserID},
+			{Path: "updated_at", Value: now},
+		}); err != nil {
+			return err
+		}
+		if err := tx.Update(newUserRef, []firestore.Update{
+			{Path: "is_verified", Value: true},
+			{Path: "updated_at", Value: now},
+		}); err != nil {
+			return err
+		}
+		if previousUserRef != nil {
+			if err := tx.Update(previousUserRef, []firestore.Update{
+				{Path: "is_verified", Value: false},
+				{Path: "updated_at", Value: now},
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	switch err {
+	case nil:
+	case errPersonNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	case errUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	case errUserAlreadyLinked:
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already linked to another person"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer identity link"})
+		return
+	}
+
+	recordAudit(ctx, h.client, c, "identity_transfer", req.PersonID, map[string]interface{}{
+		"previous_user_id": previousUserID,
+		"new_user_id":      req.NewUserID,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Identity link transferred",
+		"person_id":        req.PersonID,
+		"previous_user_id": previousUserID,
+		"new_user_id":      req.NewUserID,
+	})
+}
+
 // LinkUserToPersonRequest represents a request to link a user to a tree node by admin
 type LinkUserToPersonRequest struct {
 	UserID            string `json:"user_id" binding:"required"`
 	PersonID          string `json:"person_id" binding:"required"`
 	InstagramUsername string `json:"instagram_username"`
+	DeferEnrichment   bool   `json:"defer_enrichment"` // Store the username only, enrich separately
 }
 
 // LinkUserToPerson allows admin to directly link a user to a tree node (without user request)
@@ -364,22 +652,22 @@ func (h *FirestoreIdentityClaimHandler) LinkUserToPerson(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Verify user exists
-	userDoc, err := h.client.Collection("users").Doc(req.UserID).Get(ctx)
+	userDoc, err := h.coll(ctx, "users").Doc(req.UserID).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
-	if !userDoc.Exists() {
+	if !userDoc.Exists() || !userInTree(ctx, userDoc) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
 	// Check if user is already linked (Person owns this relationship)
 	// Query the Person collection to find if any person links to this user
-	existingLinkIter := h.client.Collection("people").Where("linked_user_id", "==", req.UserID).Limit(1).Documents(ctx)
+	existingLinkIter := h.coll(ctx, "people").Where("linked_user_id", "==", req.UserID).Limit(1).Documents(ctx)
 	existingLinkDoc, err := existingLinkIter.Next()
 	existingLinkIter.Stop()
 	if err == nil && existingLinkDoc != nil {
@@ -388,7 +676,7 @@ func (h *FirestoreIdentityClaimHandler) LinkUserToPerson(c *gin.Context) {
 	}
 
 	// Get person
-	personDoc, err := h.client.Collection("people").Doc(req.PersonID).Get(ctx)
+	personDoc, err := h.coll(ctx, "people").Doc(req.PersonID).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
 		return
@@ -408,9 +696,11 @@ func (h *FirestoreIdentityClaimHandler) LinkUserToPerson(c *gin.Context) {
 	now := time.Now()
 
 	// If Instagram username provided, try to fetch the profile
+	// With defer_enrichment the username is stored as-is and the profile is fetched
+	// later through EnrichPersonInstagram, so the admin can review it first
 	instagramUsername := strings.TrimPrefix(req.InstagramUsername, "@")
 	var instagramProfile *utils.InstagramProfile
-	if instagramUsername != "" {
+	if instagramUsername != "" && !req.DeferEnrichment {
 		profile, err := utils.FetchInstagramProfile(instagramUsername)
 		if err == nil && profile != nil {
 			instagramProfile = profile
@@ -420,7 +710,7 @@ func (h *FirestoreIdentityClaimHandler) LinkUserToPerson(c *gin.Context) {
 
 	// Person is the OWNER of the link relationship
 	// Only update Person.linked_user_id - User does NOT store person_id
-	personRef := h.client.Collection("people").Doc(req.PersonID)
+	personRef := h.coll(ctx, "people").Doc(req.PersonID)
 	updates := []firestore.Update{
 		{Path: "linked_user_id", Value: req.UserID},
 		{Path: "updated_at", Value: now},
@@ -429,16 +719,7 @@ func (h *FirestoreIdentityClaimHandler) LinkUserToPerson(c *gin.Context) {
 		updates = append(updates, firestore.Update{Path: "instagram_username", Value: instagramUsername})
 	}
 	if instagramProfile != nil {
-		if instagramProfile.AvatarURL != "" {
-			updates = append(updates, firestore.Update{Path: "instagram_avatar_url", Value: instagramProfile.AvatarURL})
-		}
-		if instagramProfile.FullName != "" {
-			updates = append(updates, firestore.Update{Path: "instagram_full_name", Value: instagramProfile.FullName})
-		}
-		if instagramProfile.Bio != "" {
-			updates = append(updates, firestore.Update{Path: "instagram_bio", Value: instagramProfile.Bio})
-		}
-		updates = append(updates, firestore.Update{Path: "instagram_is_verified", Value: instagramProfile.IsVerified})
+		updates = append(updates, instagramProfileUpdates(instagramProfile)...)
 	}
 	_, err = personRef.Update(ctx, updates)
 
@@ -469,10 +750,10 @@ func (h *FirestoreIdentityClaimHandler) UpdatePersonInstagram(c *gin.Context) {
 	userRole, _ := c.Get("role")
 	isAdmin := userRole == string(models.RoleAdmin)
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Get person
-	personDoc, err := h.client.Collection("people").Doc(personID).Get(ctx)
+	personDoc, err := h.coll(ctx, "people").Doc(personID).Get(ctx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
 		return
@@ -500,7 +781,7 @@ func (h *FirestoreIdentityClaimHandler) UpdatePersonInstagram(c *gin.Context) {
 	now := time.Now()
 
 	// Update person
-	_, err = h.client.Collection("people").Doc(personID).Update(ctx, []firestore.Update{
+	_, err = h.coll(ctx, "people").Doc(personID).Update(ctx, []firestore.Update{
 		{Path: "instagram_username", Value: req.InstagramUsername},
 		{Path: "updated_at", Value: now},
 	})
@@ -554,10 +835,10 @@ func (h *FirestoreIdentityClaimHandler) UpdateMyInstagram(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := treeContext(c)
 
 	// Find the person linked to this user
-	iter := h.client.Collection("people").Where("linked_user_id", "==", userID.(string)).Limit(1).Documents(ctx)
+	iter := h.coll(ctx, "people").Where("linked_user_id", "==", userID.(string)).Limit(1).Documents(ctx)
 	doc, err := iter.Next()
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "You don't have a linked tree node. Please claim your identity first."})
@@ -588,7 +869,7 @@ func (h *FirestoreIdentityClaimHandler) UpdateMyInstagram(c *gin.Context) {
 	now := time.Now()
 
 	// Update person
-	_, err = h.client.Collection("people").Doc(person.ID).Update(ctx, []firestore.Update{
+	_, err = h.coll(ctx, "people").Doc(person.ID).Update(ctx, []firestore.Update{
 		{Path: "instagram_username", Value: username},
 		{Path: "updated_at", Value: now},
 	})
@@ -603,3 +884,186 @@ func (h *FirestoreIdentityClaimHandler) UpdateMyInstagram(c *gin.Context) {
 		"username": username,
 	})
 }
+
+// instagramProfileUpdates builds the person field updates for a fetched Instagram profile
+func instagramProfileUpdates(profile *utils.InstagramProfile) []firestore.Update {
+	var updates []firestore.Update
+	if profile.AvatarURL != "" {
+		updates = append(updates, firestore.Update{Path: "instagram_avatar_url", Value: profile.AvatarURL})
+	}
+	if profile.FullName != "" {
+		updates = append(updates, firestore.Update{Path: "instagram_full_name", Value: profile.FullName})
+	}
+	if profile.Bio != "" {
+		updates = append(updates, firestore.Update{Path: "instagram_bio", Value: profile.Bio})
+	}
+	updates = append(updates, firestore.Update{Path: "instagram_is_verified", Value: profile.IsVerified})
+	return updates
+}
+
+// overrideUpdates replaces the entries of updates that share a path with overrides and
+// appends the rest; Firestore rejects an update naming the same path twice
+func overrideUpdates(updates, overrides []firestore.Update) []firestore.Update {
+	index := make(map[string]int, len(updates))
+	for i, u := range updates {
+		index[u.Path] = i
+	}
+	for _, o := range overrides {
+		if i, ok := index[o.Path]; ok {
+			updates[i] = o
+			continue
+		}
+		index[o.Path] = len(updates)
+		updates = append(updates, o)
+	}
+	return updates
+}
+
+// EnrichInstagramRequest optionally overrides the username stored on the person
+type EnrichInstagramRequest struct {
+	InstagramUsername string `json:"instagram_username"`
+}
+
+// EnrichPersonInstagram fetches Instagram profile data for a person and returns the fields
+// that would be stored. Nothing is written unless ?confirm=true is passed.
+func (h *FirestoreIdentityClaimHandler) EnrichPersonInstagram(c *gin.Context) {
+	personID := c.Param("person_id")
+	confirm := c.Query("confirm") == "true"
+
+	var req EnrichInstagramRequest
+	// Body is optional - ignore EOF for bodiless requests
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx := treeContext(c)
+
+	personDoc, err := h.coll(ctx, "people").Doc(personID).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+
+	var person models.Person
+	if err := personDoc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+
+	username := strings.TrimPrefix(req.InstagramUsername, "@")
+	if username == "" {
+		username = person.InstagramUsername
+	}
+	if username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Person has no Instagram username. Provide instagram_username in the request body."})
+		return
+	}
+	if !utils.ValidateInstagramUsername(username) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Instagram username format"})
+		return
+	}
+
+	profile, err := utils.FetchInstagramProfile(username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Instagram profile not found or unavailable"})
+		return
+	}
+
+	updates := []firestore.Update{
+		{Path: "instagram_username", Value: username},
+	}
+	updates = append(updates, instagramProfileUpdates(profile)...)
+
+	fields := make(map[string]interface{}, len(updates))
+	for _, u := range updates {
+		fields[u.Path] = u.Value
+	}
+
+	if !confirm {
+		c.JSON(http.StatusOK, gin.H{
+			"person_id": personID,
+			"confirmed": false,
+			"fields":    fields,
+			"message":   "Preview only. Re-send with ?confirm=true to store these fields.",
+		})
+		return
+	}
+
+	updates = append(updates, firestore.Update{Path: "updated_at", Value: time.Now()})
+	if _, err := h.coll(ctx, "people").Doc(personID).Update(ctx, updates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store Instagram data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"person_id": personID,
+		"confirmed": true,
+		"fields":    fields,
+		"message":   "Instagram data stored successfully",
+	})
+}
+
+// ClearPersonInstagram removes all cached Instagram data from a person (admin only).
+// If a new instagram_username is supplied the profile is fetched again and stored.
+func (h *FirestoreIdentityClaimHandler) ClearPersonInstagram(c *gin.Context) {
+	personID := c.Param("person_id")
+
+	var req EnrichInstagramRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.InstagramUsername == "" {
+		req.InstagramUsername = c.Query("instagram_username")
+	}
+
+	newUsername := strings.TrimPrefix(strings.TrimSpace(req.InstagramUsername), "@")
+	if newUsername != "" && !utils.ValidateInstagramUsername(newUsername) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Instagram username format"})
+		return
+	}
+
+	ctx := treeContext(c)
+
+	personRef := h.coll(ctx, "people").Doc(personID)
+	if _, err := personRef.Get(ctx); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+
+	updates := []firestore.Update{
+		{Path: "instagram_username", Value: ""},
+		{Path: "instagram_avatar_url", Value: ""},
+		{Path: "instagram_full_name", Value: ""},
+		{Path: "instagram_bio", Value: ""},
+		{Path: "instagram_is_verified", Value: false},
+		{Path: "updated_at", Value: time.Now()},
+	}
+
+	refetched := false
+	if newUsername != "" {
+		updates[0].Value = newUsername
+		profile, err := utils.FetchInstagramProfile(newUsername)
+		if err == nil && profile != nil {
+			updates = overrideUpdates(updates, instagramProfileUpdates(profile))
+			refetched = true
+		}
+		// Don't fail if the fetch fails - the cache is still cleared
+	}
+
+	if _, err := personRef.Update(ctx, updates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear Instagram data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Instagram data cleared",
+		"instagram_username": newUsername,
+		"refetched":          refetched,
+	})
+}
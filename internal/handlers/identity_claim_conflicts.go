@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// ClaimConflict is a person with more than one pending identity claim
+type ClaimConflict struct {
+	PersonID   string                        `json:"person_id"`
+	PersonName string                        `json:"person_name"`
+	Claims     []models.IdentityClaimRequest `json:"claims"` // Oldest first
+}
+
+// errClaimNotPending is returned when the chosen claim is not a pending claim for the person
+var errClaimNotPending = errors.New("claim is not pending for this person")
+
+// pendingClaimsQuery selects the pending claims for one person
+func (h *FirestoreIdentityClaimHandler) pendingClaimsQuery(ctx context.Context, personID string) firestore.Query {
+	return h.coll(ctx, "identity_claims").Where("person_id", "==", personID).Where("status", "==", "pending")
+}
+
+// GetClaimConflicts lists people that more than one user has a pending claim on
+func (h *FirestoreIdentityClaimHandler) GetClaimConflicts(c *gin.Context) {
+	ctx := treeContext(c)
+	iter := h.coll(ctx, "identity_claims").Where("status", "==", "pending").Documents(ctx)
+	defer iter.Stop()
+
+	byPerson := make(map[string][]models.IdentityClaimRequest)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch claims"})
+			return
+		}
+		var claim models.IdentityClaimRequest
+		if err := doc.DataTo(&claim); err != nil {
+			continue
+		}
+		byPerson[claim.PersonID] = append(byPerson[claim.PersonID], claim)
+	}
+
+	conflicts := []ClaimConflict{}
+	for personID, claims := range byPerson {
+		if len(claims) < 2 {
+			continue
+		}
+		sort.Slice(claims, func(i, j int) bool {
+			return claims[i].CreatedAt.Before(claims[j].CreatedAt)
+		})
+		conflicts = append(conflicts, ClaimConflict{PersonID: personID, PersonName: claims[0].PersonName, Claims: claims})
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if len(conflicts[i].Claims) != len(conflicts[j].Claims) {
+			return len(conflicts[i].Claims) > len(conflicts[j].Claims)
+		}
+		return conflicts[i].Claims[0].CreatedAt.Before(conflicts[j].Claims[0].CreatedAt)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"conflicts": conflicts,
+		"count":     len(conflicts),
+	})
+}
+
+// ResolveClaimConflict approves one pending claim for a person and rejects every other
+// pending claim for that person in the same transaction
+func (h *FirestoreIdentityClaimHandler) ResolveClaimConflict(c *gin.Context) {
+	personID := c.Param("person_id")
+
+	var req models.ResolveClaimConflictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !sanitizeFields(c, &req.ReviewNotes) {
+		return
+	}
+	rejectNotes := req.ReviewNotes
+	if rejectNotes == "" {
+		rejectNotes = "Another claim for this person was approved"
+	}
+
+	adminID, _ := c.Get("user_id")
+	ctx := treeContext(c)
+	now := time.Now()
+
+	var approved models.IdentityClaimRequest
+	var rejected []models.IdentityClaimRequest
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		approved, rejected = models.IdentityClaimRequest{}, nil
+
+		// Reads must happen before writes inside a transaction
+		personDoc, err := tx.Get(h.coll(ctx, "people").Doc(personID))
+		if err != nil {
+			return errPersonNotFound
+		}
+		var person models.Person
+		if err := personDoc.DataTo(&person); err != nil {
+			return err
+		}
+		docs, err := tx.Documents(h.pendingClaimsQuery(ctx, personID)).GetAll()
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, doc := range docs {
+			var claim models.IdentityClaimRequest
+			if err := doc.DataTo(&claim); err != nil {
+				return err
+			}
+			claim.ID = doc.Ref.ID
+			if claim.ID == req.ClaimID {
+				approved, found = claim, true
+			} else {
+				rejected = append(rejected, claim)
+			}
+		}
+		if !found {
+			return errClaimNotPending
+		}
+		if person.LinkedUserID != "" && person.LinkedUserID != approved.UserID {
+			return errPersonAlreadyLinked
+		}
+
+		if err := h.writeClaimDecision(ctx, tx, approved.ID, approved, true, req.ReviewNotes, adminID.(string), now); err != nil {
+			return err
+		}
+		for _, claim := range rejected {
+			if err := h.writeClaimDecision(ctx, tx, claim.ID, claim, false, rejectNotes, adminID.(string), now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	switch err {
+	case nil:
+	case errPersonNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	case errClaimNotPending:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "claim_id is not a pending claim for this person"})
+		return
+	case errPersonAlreadyLinked:
+		c.JSON(http.StatusConflict, gin.H{"error": "This person is already linked to another user"})
+		return
+	default:
+		log.Printf("[IdentityClaim] Failed to resolve conflict on %s: %v", personID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve claims"})
+		return
+	}
+
+	notifyClaimReviewed(h.client, approved, "approved", req.ReviewNotes)
+	rejectedIDs := make([]string, len(rejected))
+	for i, claim := range rejected {
+		notifyClaimReviewed(h.client, claim, "rejected", rejectNotes)
+		rejectedIDs[i] = claim.ID
+	}
+	recordAudit(ctx, h.client, c, "identity_claim_conflict_resolve", personID, map[string]interface{}{
+		"approved_claim":  approved.ID,
+		"approved_user":   approved.UserID,
+		"rejected_claims": rejectedIDs,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Claim approved and competing claims rejected",
+		"approved_claim":  approved.ID,
+		"rejected_claims": rejectedIDs,
+	})
+}
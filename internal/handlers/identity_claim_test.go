@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+func TestClearAndRefetchInstagramUpdatesAreUnique(t *testing.T) {
+	updates := []firestore.Update{
+		{Path: "instagram_username", Value: "new"},
+		{Path: "instagram_avatar_url", Value: ""},
+		{Path: "instagram_full_name", Value: ""},
+		{Path: "instagram_bio", Value: ""},
+		{Path: "instagram_is_verified", Value: false},
+	}
+	profile := &utils.InstagramProfile{AvatarURL: "https://example.com/a.jpg", FullName: "Sara", IsVerified: true}
+
+	merged := overrideUpdates(updates, instagramProfileUpdates(profile))
+
+	values := make(map[string]interface{})
+	for _, u := range merged {
+		if _, dup := values[u.Path]; dup {
+			t.Fatalf("path %s appears twice", u.Path)
+		}
+		values[u.Path] = u.Value
+	}
+	want := map[string]interface{}{
+		"instagram_username":    "new",
+		"instagram_avatar_url":  "https://example.com/a.jpg",
+		"instagram_full_name":   "Sara",
+		"instagram_bio":         "",
+		"instagram_is_verified": true,
+	}
+	for path, v := range want {
+		if values[path] != v {
+			t.Errorf("%s = %v, want %v", path, values[path], v)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mamiri/findyourroot/internal/middleware"
+	"github.com/mamiri/findyourroot/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// impersonationTTL is how long an impersonation token is valid
+const impersonationTTL = 15 * time.Minute
+
+// ImpersonateUser issues a short-lived token acting as another user so support can see
+// their view (admin only). The admin must re-enter their password and give a reason;
+// the session is audited and sensitive writes are blocked for the token.
+func (h *FirestoreAuthHandler) ImpersonateUser(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+	adminEmail, _ := c.Get("email")
+	targetUserID := c.Param("id")
+
+	if adminID.(string) == targetUserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot impersonate yourself"})
+		return
+	}
+
+	var req models.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password and reason are required"})
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason cannot be empty"})
+		return
+	}
+
+	ctx := treeContext(c)
+
+	// Re-confirm the admin's identity
+	adminDoc, err := h.coll(ctx, "users").Doc(adminID.(string)).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	var admin models.User
+	if err := adminDoc.DataTo(&admin); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
+		return
+	}
+	if admin.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required", "required_role": "admin"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(req.Password)); err != nil {
+		recordAudit(ctx, h.client, c, "impersonation_denied", targetUserID, map[string]interface{}{"reason": "invalid password"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+
+	doc, err := h.coll(ctx, "users").Doc(targetUserID).Get(ctx)
+	if err != nil || !userInTree(ctx, doc) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var target models.User
+	if err := doc.DataTo(&target); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
+		return
+	}
+	target.ID = doc.Ref.ID
+
+	if target.Role == models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot impersonate another admin"})
+		return
+	}
+
+	sessionID := uuid.New().String()
+	expiresAt := time.Now().Add(impersonationTTL)
+	token, err := generateImpersonationToken(target, adminID.(string), sessionID, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	recordAudit(ctx, h.client, c, "impersonation_start", target.ID, map[string]interface{}{
+		"session_id":   sessionID,
+		"target_email": target.Email,
+		"reason":       req.Reason,
+		"expires_at":   expiresAt,
+	})
+	log.Printf("[Impersonation] %s started impersonating %s (session %s)", adminEmail, target.Email, sessionID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":           token,
+		"session_id":      sessionID,
+		"expires_at":      expiresAt.Format(time.RFC3339),
+		"impersonated_by": adminID,
+		"user": gin.H{
+			"id":          target.ID,
+			"email":       target.Email,
+			"role":        target.Role,
+			"is_admin":    false,
+			"tree_name":   target.TreeName,
+			"is_verified": target.IsVerified,
+			"permissions": target.Role.Permissions(),
+		},
+	})
+}
+
+// EndImpersonation records the end of an impersonation session. Called with the
+// impersonation token; the client should discard the token afterwards.
+func (h *FirestoreAuthHandler) EndImpersonation(c *gin.Context) {
+	impersonatedBy, _ := c.Get("impersonated_by")
+	if impersonatedBy == nil || impersonatedBy == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Not an impersonation session"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	sessionID := ""
+	if claims, ok := c.Get("claims"); ok {
+		sessionID = claims.(*middleware.Claims).ID
+	}
+
+	ctx := treeContext(c)
+	recordAudit(ctx, h.client, c, "impersonation_end", userID.(string), map[string]interface{}{
+		"session_id":      sessionID,
+		"impersonated_by": impersonatedBy,
+	})
+	log.Printf("[Impersonation] Session %s by %v ended", sessionID, impersonatedBy)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Impersonation session ended"})
+}
+
+// generateImpersonationToken issues a short-lived token for target flagged with the admin's ID
+func generateImpersonationToken(target models.User, adminID, sessionID string, expiresAt time.Time) (string, error) {
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		return "", jwt.ErrInvalidKey
+	}
+
+	claims := jwt.MapClaims{
+		"user_id":         target.ID,
+		"email":           target.Email,
+		"is_admin":        false,
+		"role":            string(target.Role),
+		"tree_id":         target.TreeID,
+		"impersonated_by": adminID,
+		"iss":             "findyourroot-api",
+		"sub":             target.ID,
+		"jti":             sessionID,
+		"exp":             expiresAt.Unix(),
+		"nbf":             time.Now().Unix(),
+		"iat":             time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(jwtSecret))
+}
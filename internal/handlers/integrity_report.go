@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DanglingReference is one document field pointing at a document that doesn't exist
+type DanglingReference struct {
+	DocumentID string `json:"document_id"`
+	Field      string `json:"field"` // e.g. "children", "linked_user_id", "target_person_id"
+	MissingID  string `json:"missing_id"`
+}
+
+// LikesMismatch is a person whose likes_count disagrees with their valid liked_by entries
+type LikesMismatch struct {
+	PersonID   string `json:"person_id"`
+	LikesCount int    `json:"likes_count"`
+	Expected   int    `json:"expected"`
+}
+
+// IntegrityReport is the result of BuildIntegrityReport. Nothing in it has been fixed.
+type IntegrityReport struct {
+	PeopleChecked       int                 `json:"people_checked"`
+	UsersChecked        int                 `json:"users_checked"`
+	DanglingRelations   []DanglingReference `json:"dangling_relations"`    // children, spouses, parent_ids
+	OrphanedLinkedUsers []DanglingReference `json:"orphaned_linked_users"` // linked_user_id, liked_by
+	OrphanedUserPersons []DanglingReference `json:"orphaned_user_persons"` // users.person_id
+	LikesMismatches     []LikesMismatch     `json:"likes_mismatches"`
+	OrphanedSuggestions []DanglingReference `json:"orphaned_suggestions"` // Pending, target person missing
+	OrphanedClaims      []DanglingReference `json:"orphaned_claims"`      // Pending, claimed person missing
+	Counts              map[string]int      `json:"counts"`
+}
+
+// BuildIntegrityReport finds the issues SweepDanglingReferences would fix, plus pending
+// suggestions and identity claims whose person is gone, using the same detection as
+// ValidatePersonReferences. It only reads.
+func (s *ReferentialIntegrityService) BuildIntegrityReport(ctx context.Context) (*IntegrityReport, error) {
+	people, err := fetchAllPeople(ctx, s.client)
+	if err != nil {
+		return nil, err
+	}
+	personIDs := make(map[string]bool, len(people))
+	for _, p := range people {
+		personIDs[p.ID] = true
+	}
+	userIDs, userDocs, err := s.fetchTreeUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IntegrityReport{
+		PeopleChecked:       len(people),
+		UsersChecked:        len(userDocs),
+		DanglingRelations:   []DanglingReference{},
+		OrphanedLinkedUsers: []DanglingReference{},
+		OrphanedUserPersons: []DanglingReference{},
+		LikesMismatches:     []LikesMismatch{},
+		OrphanedSuggestions: []DanglingReference{},
+		OrphanedClaims:      []DanglingReference{},
+	}
+	sort.Slice(people, func(i, j int) bool { return people[i].ID < people[j].ID })
+	for _, p := range people {
+		issues := detectPersonIssues(p, personIDs, userIDs)
+		for _, field := range []struct {
+			name    string
+			missing []string
+		}{{"children", issues.Children}, {"spouses", issues.Spouses}, {"parent_ids", issues.ParentIDs}} {
+			for _, id := range field.missing {
+				report.DanglingRelations = append(report.DanglingRelations, DanglingReference{DocumentID: p.ID, Field: field.name, MissingID: id})
+			}
+		}
+		if issues.LinkedUserID != "" {
+			report.OrphanedLinkedUsers = append(report.OrphanedLinkedUsers, DanglingReference{DocumentID: p.ID, Field: "linked_user_id", MissingID: issues.LinkedUserID})
+		}
+		for _, id := range issues.LikedBy {
+			report.OrphanedLinkedUsers = append(report.OrphanedLinkedUsers, DanglingReference{DocumentID: p.ID, Field: "liked_by", MissingID: id})
+		}
+		if issues.LikesCountMismatch {
+			report.LikesMismatches = append(report.LikesMismatches, LikesMismatch{
+				PersonID:   p.ID,
+				LikesCount: p.LikesCount,
+				Expected:   likesCount(withoutIDs(p.LikedBy, issues.LikedBy)),
+			})
+		}
+	}
+
+	for _, doc := range userDocs {
+		personID, _ := doc.Data()["person_id"].(string)
+		if personID != "" && !personIDs[personID] {
+			report.OrphanedUserPersons = append(report.OrphanedUserPersons, DanglingReference{DocumentID: doc.Ref.ID, Field: "person_id", MissingID: personID})
+		}
+	}
+
+	// Add suggestions target the parent, which may legitimately be empty
+	suggestionDocs, err := s.coll(ctx, "suggestions").Where("status", "==", "pending").Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range suggestionDocs {
+		targetID, _ := doc.Data()["target_person_id"].(string)
+		if targetID != "" && !personIDs[targetID] {
+			report.OrphanedSuggestions = append(report.OrphanedSuggestions, DanglingReference{DocumentID: doc.Ref.ID, Field: "target_person_id", MissingID: targetID})
+		}
+	}
+
+	claimDocs, err := s.coll(ctx, "identity_claims").Where("status", "==", "pending").Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range claimDocs {
+		personID, _ := doc.Data()["person_id"].(string)
+		if personID != "" && !personIDs[personID] {
+			report.OrphanedClaims = append(report.OrphanedClaims, DanglingReference{DocumentID: doc.Ref.ID, Field: "person_id", MissingID: personID})
+		}
+	}
+
+	report.Counts = map[string]int{
+		"dangling_relations":    len(report.DanglingRelations),
+		"orphaned_linked_users": len(report.OrphanedLinkedUsers),
+		"orphaned_user_persons": len(report.OrphanedUserPersons),
+		"likes_mismatches":      len(report.LikesMismatches),
+		"orphaned_suggestions":  len(report.OrphanedSuggestions),
+		"orphaned_claims":       len(report.OrphanedClaims),
+	}
+	return report, nil
+}
+
+// GetIntegrityReport returns the dangling references across people, users, suggestions
+// and identity claims without changing anything (admin only). POST /integrity/sweep fixes
+// the people and user issues.
+func (h *FirestoreTreeHandler) GetIntegrityReport(c *gin.Context) {
+	report, err := NewReferentialIntegrityService(h.client).BuildIntegrityReport(treeContext(c))
+	if err != nil {
+		log.Printf("[RefIntegrity] Failed to build report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build integrity report"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// errInvalidInvite covers unknown, expired and used-up invite codes alike
+var errInvalidInvite = errors.New("invalid or expired invite code")
+
+// checkInvite reports whether the invite can still be redeemed at now
+func checkInvite(invite models.Invite, now time.Time) error {
+	if invite.Uses >= invite.MaxUses || (!invite.ExpiresAt.IsZero() && now.After(invite.ExpiresAt)) {
+		return errInvalidInvite
+	}
+	return nil
+}
+
+// consumeInvite uses up one use of the invite inside tx, so two registrations can't both
+// take its last use
+func consumeInvite(tx *firestore.Transaction, ref *firestore.DocumentRef) (models.Invite, error) {
+	doc, err := tx.Get(ref)
+	if err != nil {
+		return models.Invite{}, errInvalidInvite
+	}
+	var invite models.Invite
+	if err := doc.DataTo(&invite); err != nil {
+		return models.Invite{}, err
+	}
+	if err := checkInvite(invite, time.Now()); err != nil {
+		return models.Invite{}, err
+	}
+	err = tx.Update(ref, []firestore.Update{{Path: "uses", Value: invite.Uses + 1}})
+	return invite, err
+}
+
+// CreateInvite generates an invite code into the admin's tree (admin only). The code is
+// returned once; only its hash is stored. Invites can't grant the admin role.
+func (h *FirestoreAuthHandler) CreateInvite(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	if req.Role != models.RoleViewer && req.Role != models.RoleContributor && req.Role != models.RoleCoAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be viewer, contributor, or co-admin"})
+		return
+	}
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+	if req.MaxUses < 0 || req.ExpiresInHours < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_uses and expires_in_hours cannot be negative"})
+		return
+	}
+
+	code, err := utils.GenerateSecureToken(9)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate invite code"})
+		return
+	}
+
+	ctx := treeContext(c)
+	now := time.Now()
+	invite := models.Invite{
+		Role:      req.Role,
+		TreeID:    requestTreeID(c),
+		MaxUses:   req.MaxUses,
+		CreatedBy: userID.(string),
+		CreatedAt: now,
+	}
+	if invite.TreeID != "" {
+		if doc, err := h.coll(ctx, "trees").Doc(invite.TreeID).Get(ctx); err == nil {
+			invite.TreeName, _ = doc.Data()["name"].(string)
+		}
+	} else {
+		invite.TreeName = loadTreeSettings(ctx, h.client).TreeName
+	}
+	if req.ExpiresInHours > 0 {
+		invite.ExpiresAt = now.Add(time.Duration(req.ExpiresInHours) * time.Hour)
+	}
+
+	if _, err := h.coll(ctx, "invites").Doc(hashToken(code)).Create(ctx, invite); err != nil {
+		log.Printf("[Invites] Failed to create invite: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+
+	recordAudit(ctx, h.client, c, "invite_create", "", map[string]interface{}{
+		"role":     string(invite.Role),
+		"max_uses": invite.MaxUses,
+		"tree_id":  invite.TreeID,
+	})
+	c.JSON(http.StatusCreated, gin.H{
+		"code":   code, // Shown only here
+		"invite": invite,
+	})
+}
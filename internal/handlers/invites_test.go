@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+func TestCheckInvite(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		invite models.Invite
+		want   error
+	}{
+		{"unused", models.Invite{MaxUses: 1}, nil},
+		{"uses left", models.Invite{MaxUses: 3, Uses: 2, ExpiresAt: now.Add(time.Hour)}, nil},
+		{"exhausted", models.Invite{MaxUses: 3, Uses: 3}, errInvalidInvite},
+		{"expired", models.Invite{MaxUses: 1, ExpiresAt: now.Add(-time.Second)}, errInvalidInvite},
+		{"expired and exhausted", models.Invite{MaxUses: 1, Uses: 1, ExpiresAt: now.Add(-time.Hour)}, errInvalidInvite},
+		{"other tree", models.Invite{MaxUses: 1, TreeID: "t1"}, nil},
+	}
+	for _, tt := range tests {
+		if got := checkInvite(tt.invite, now); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/middleware"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+var (
+	// loginLockoutThreshold is how many consecutive wrong passwords lock an account
+	// (LOGIN_LOCKOUT_THRESHOLD, default 5)
+	loginLockoutThreshold = envPositiveInt("LOGIN_LOCKOUT_THRESHOLD", 5)
+
+	// loginLockoutDuration is how long a locked account refuses logins (LOGIN_LOCKOUT_MINUTES, default 15)
+	loginLockoutDuration = time.Duration(envPositiveInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute
+)
+
+// respondAccountLocked writes the response for a login attempt on a locked account
+func respondAccountLocked(c *gin.Context, lockedUntil time.Time) {
+	retryAfter := int(time.Until(lockedUntil).Seconds()) + 1
+	c.Header("Retry-After", fmt.Sprintf("%d", retryAfter))
+	c.JSON(http.StatusLocked, gin.H{
+		"error":        "Account temporarily locked due to repeated failed logins",
+		"locked_until": lockedUntil,
+		"retry_after":  retryAfter,
+	})
+}
+
+// notifyAccountLocked emails the account owner about a lockout, unless they opted out
+func notifyAccountLocked(user models.User, lockedUntil time.Time) {
+	body := fmt.Sprintf("Your FindYourRoot account was locked after %d failed login attempts.\n\n"+
+		"You can try again after %s. If this wasn't you, consider changing your password.\n",
+		loginLockoutThreshold, lockedUntil.Format(time.RFC1123))
+	sendUserEmail(user, notifyAccountSecurity, "Your account was temporarily locked", body)
+}
+
+// recordFailedLogin counts a wrong password against the user and locks the account once the
+// threshold is reached. It returns the lock expiry when this attempt caused a lockout.
+func recordFailedLogin(ctx context.Context, client *firestore.Client, userID string) (time.Time, bool, error) {
+	ref := database.Collection(client, "users").Doc(userID)
+	var lockedUntil time.Time
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		lockedUntil = time.Time{}
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			return err
+		}
+
+		attempts := user.FailedLoginAttempts + 1
+		updates := []firestore.Update{{Path: "failed_login_attempts", Value: attempts}}
+		if attempts >= loginLockoutThreshold {
+			lockedUntil = time.Now().Add(loginLockoutDuration)
+			updates = []firestore.Update{
+				{Path: "failed_login_attempts", Value: 0},
+				{Path: "locked_until", Value: lockedUntil},
+			}
+		}
+		return tx.Update(ref, updates)
+	})
+	return lockedUntil, !lockedUntil.IsZero(), err
+}
+
+// UnlockUser clears a login lockout, the failed attempt counter and any login rate limit
+// on the user's email
+func (h *FirestoreAuthHandler) UnlockUser(c *gin.Context) {
+	targetUserID := c.Param("id")
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "users").Doc(targetUserID).Get(ctx)
+	if err != nil || !userInTree(ctx, doc) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var user models.User
+	if err := doc.DataTo(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
+		return
+	}
+
+	_, err = doc.Ref.Update(ctx, []firestore.Update{
+		{Path: "failed_login_attempts", Value: 0},
+		{Path: "locked_until", Value: time.Time{}},
+		{Path: "updated_at", Value: time.Now()},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+
+	middleware.ClearLoginAttempts(user.Email)
+
+	wasLocked := user.LockedUntil.After(time.Now())
+	recordAudit(ctx, h.client, c, "account_unlock", targetUserID, map[string]interface{}{
+		"email":      user.Email,
+		"was_locked": wasLocked,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "User unlocked successfully",
+		"was_locked": wasLocked,
+	})
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// maxNameFrequencyLimit caps how many names are listed overall and per bucket
+const maxNameFrequencyLimit = 100
+
+// NameCount is one first name with how many people carry it
+type NameCount struct {
+	Name  string `json:"name"` // Most common spelling among the matching people
+	Count int    `json:"count"`
+}
+
+// NameBucket is the name frequency within one generation or decade
+type NameBucket struct {
+	Key   string      `json:"key"` // Generation number (roots are 1) or decade such as "1950s"
+	Total int         `json:"total"`
+	Names []NameCount `json:"names"`
+}
+
+// firstNameKey returns the display form and comparison key of a person's first name.
+// Spellings that differ only in Arabic/Persian letter variants or diacritics share a key.
+func firstNameKey(name string) (string, string) {
+	fields := strings.Fields(utils.NormalizePersianCharacters(name))
+	if len(fields) == 0 {
+		return "", ""
+	}
+	return fields[0], utils.NormalizePersianName(fields[0])
+}
+
+// nameTally counts first names by key while remembering each key's spellings
+type nameTally struct {
+	counts    map[string]int
+	spellings map[string]map[string]int
+	total     int
+}
+
+func newNameTally() *nameTally {
+	return &nameTally{counts: map[string]int{}, spellings: map[string]map[string]int{}}
+}
+
+func (t *nameTally) add(display, key string) {
+	t.total++
+	t.counts[key]++
+	if t.spellings[key] == nil {
+		t.spellings[key] = map[string]int{}
+	}
+	t.spellings[key][display]++
+}
+
+// top returns the limit most common names, ties broken alphabetically
+func (t *nameTally) top(limit int) []NameCount {
+	names := make([]NameCount, 0, len(t.counts))
+	for key, count := range t.counts {
+		best, bestCount := "", 0
+		for spelling, n := range t.spellings[key] {
+			if n > bestCount || (n == bestCount && spelling < best) {
+				best, bestCount = spelling, n
+			}
+		}
+		names = append(names, NameCount{Name: best, Count: count})
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].Count != names[j].Count {
+			return names[i].Count > names[j].Count
+		}
+		return names[i].Name < names[j].Name
+	})
+	if len(names) > limit {
+		names = names[:limit]
+	}
+	return names
+}
+
+// generationNumbers assigns each person their generation: roots are 1 and everyone else is
+// one more than their shallowest parent. People only reachable through cycles are left out.
+func generationNumbers(people []models.Person) map[string]int {
+	byID := make(map[string]models.Person, len(people))
+	hasParent := make(map[string]bool)
+	for _, p := range people {
+		byID[p.ID] = p
+		for _, childID := range p.Children {
+			hasParent[childID] = true
+		}
+	}
+
+	generation := make(map[string]int, len(people))
+	var queue []string
+	for _, p := range people {
+		if !hasParent[p.ID] {
+			generation[p.ID] = 1
+			queue = append(queue, p.ID)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, childID := range byID[id].Children {
+			if _, ok := byID[childID]; !ok {
+				continue
+			}
+			if _, seen := generation[childID]; !seen {
+				generation[childID] = generation[id] + 1
+				queue = append(queue, childID)
+			}
+		}
+	}
+	return generation
+}
+
+// GetNameFrequency returns the most common first names in the tree, matched on their
+// normalized form. ?limit= sets how many names to list (default 20, max 100) and
+// ?by=generation|decade adds a per-generation or per-birth-decade breakdown.
+// Results are cached briefly; ?refresh=true forces a recompute.
+func (h *FirestoreTreeHandler) GetNameFrequency(c *gin.Context) {
+	limit := 20
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+	if limit > maxNameFrequencyLimit {
+		limit = maxNameFrequencyLimit
+	}
+	by := c.Query("by")
+	if by != "" && by != "generation" && by != "decade" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "by must be 'generation' or 'decade'"})
+		return
+	}
+
+	cacheKey := fmt.Sprintf("name-frequency:%s:%d", by, limit)
+	ctx := treeContext(c)
+	if c.Query("refresh") != "true" {
+		if cached, ok := treeStatsCache.Get(treeCacheKey(ctx, cacheKey)); ok {
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+	}
+
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	var generation map[string]int
+	if by == "generation" {
+		generation = generationNumbers(people)
+	}
+
+	overall := newNameTally()
+	buckets := map[string]*nameTally{}
+	for _, p := range people {
+		display, key := firstNameKey(p.Name)
+		if key == "" {
+			continue
+		}
+		overall.add(display, key)
+
+		bucket := ""
+		switch by {
+		case "generation":
+			if g, ok := generation[p.ID]; ok {
+				bucket = strconv.Itoa(g)
+			}
+		case "decade":
+			if year, ok := utils.ParseBirthYear(p.Birth); ok {
+				bucket = fmt.Sprintf("%ds", year/10*10)
+			}
+		}
+		if bucket == "" {
+			continue
+		}
+		if buckets[bucket] == nil {
+			buckets[bucket] = newNameTally()
+		}
+		buckets[bucket].add(display, key)
+	}
+
+	response := gin.H{
+		"names":          overall.top(limit),
+		"total_people":   overall.total,
+		"distinct_names": len(overall.counts),
+		"computed_at":    time.Now().Format(time.RFC3339),
+	}
+	if by != "" {
+		breakdown := make([]NameBucket, 0, len(buckets))
+		for key, tally := range buckets {
+			breakdown = append(breakdown, NameBucket{Key: key, Total: tally.total, Names: tally.top(limit)})
+		}
+		// Generations and decades both sort naturally by their leading number
+		sort.Slice(breakdown, func(i, j int) bool {
+			a, _ := strconv.Atoi(strings.TrimSuffix(breakdown[i].Key, "s"))
+			b, _ := strconv.Atoi(strings.TrimSuffix(breakdown[j].Key, "s"))
+			return a < b
+		})
+		response["by"] = by
+		response["breakdown"] = breakdown
+	}
+
+	treeStatsCache.Set(treeCacheKey(ctx, cacheKey), response)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// notificationMailer sends every user-facing email; nil when SMTP is not configured
+var notificationMailer = utils.NewMailerFromEnv()
+
+// Kinds of user notification, each gated by one preference
+const (
+	notifyReviewOutcome     = "review_outcome"
+	notifyClaimOutcome      = "claim_outcome"
+	notifyPermissionOutcome = "permission_outcome"
+	notifyAccountSecurity   = "account_security"
+)
+
+// notificationAllowed reports whether the preferences let a notification of this kind through
+func notificationAllowed(prefs models.NotificationPrefs, kind string) bool {
+	switch kind {
+	case notifyReviewOutcome:
+		return prefs.EmailReviewOutcome
+	case notifyClaimOutcome:
+		return prefs.EmailClaimOutcome
+	case notifyPermissionOutcome:
+		return prefs.EmailPermissionOutcome
+	case notifyAccountSecurity:
+		return prefs.EmailAccountSecurity
+	}
+	return false
+}
+
+// sendUserEmail emails the user in the background if a mailer is configured and
+// their preferences allow this kind of notification
+func sendUserEmail(user models.User, kind, subject, body string) {
+	if notificationMailer == nil || user.Email == "" || !notificationAllowed(user.Notifications(), kind) {
+		return
+	}
+	go func() {
+		if err := notificationMailer.Send(user.Email, subject, body); err != nil {
+			log.Printf("[Notify] Failed to send %s email to %s: %v", kind, user.Email, err)
+		}
+	}()
+}
+
+// notifyUser looks up the user and emails them via sendUserEmail. The lookup runs in the
+// background so callers never wait on it.
+func notifyUser(client *firestore.Client, userID, kind, subject, body string) {
+	if notificationMailer == nil || userID == "" {
+		return
+	}
+	go func() {
+		doc, err := database.Collection(client, "users").Doc(userID).Get(context.Background())
+		if err != nil {
+			log.Printf("[Notify] User %s not found for %s email: %v", userID, kind, err)
+			return
+		}
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			return
+		}
+		sendUserEmail(user, kind, subject, body)
+	}()
+}
+
+// GetNotificationPrefs returns the current user's notification preferences
+func (h *FirestoreAuthHandler) GetNotificationPrefs(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	ctx := treeContext(c)
+	doc, err := h.coll(ctx, "users").Doc(userID.(string)).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var user models.User
+	if err := doc.DataTo(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.Notifications())
+}
+
+// UpdateNotificationPrefs changes the current user's notification preferences
+func (h *FirestoreAuthHandler) UpdateNotificationPrefs(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.UpdateNotificationPrefsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	ctx := treeContext(c)
+	ref := h.coll(ctx, "users").Doc(userID.(string))
+	var prefs models.NotificationPrefs
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			return err
+		}
+
+		prefs = user.Notifications()
+		if req.EmailReviewOutcome != nil {
+			prefs.EmailReviewOutcome = *req.EmailReviewOutcome
+		}
+		if req.EmailClaimOutcome != nil {
+			prefs.EmailClaimOutcome = *req.EmailClaimOutcome
+		}
+		if req.EmailPermissionOutcome != nil {
+			prefs.EmailPermissionOutcome = *req.EmailPermissionOutcome
+		}
+		if req.EmailAccountSecurity != nil {
+			prefs.EmailAccountSecurity = *req.EmailAccountSecurity
+		}
+
+		return tx.Update(ref, []firestore.Update{
+			{Path: "notification_prefs", Value: prefs},
+			{Path: "updated_at", Value: time.Now()},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// reviewOutcomeNote formats the reviewer's notes for an outcome email
+func reviewOutcomeNote(notes string) string {
+	if notes == "" {
+		return ""
+	}
+	return "\nReviewer notes: " + notes + "\n"
+}
+
+// notifySuggestionReviewed tells the contributor their suggestion was approved or rejected
+func notifySuggestionReviewed(client *firestore.Client, s models.Suggestion, status, notes string) {
+	name := s.TargetPersonID
+	if s.PersonData != nil && s.PersonData.Name != "" {
+		name = s.PersonData.Name
+	}
+	body := fmt.Sprintf("Your %s suggestion for %s was %s.\n%s", s.Type, name, status, reviewOutcomeNote(notes))
+	notifyUser(client, s.UserID, notifyReviewOutcome, "Your suggestion was "+status, body)
+}
+
+// notifyClaimReviewed tells the user their identity claim was approved or rejected
+func notifyClaimReviewed(client *firestore.Client, claim models.IdentityClaimRequest, status, notes string) {
+	body := fmt.Sprintf("Your claim to be %s in the family tree was %s.\n%s", claim.PersonName, status, reviewOutcomeNote(notes))
+	notifyUser(client, claim.UserID, notifyClaimOutcome, "Your identity claim was "+status, body)
+}
+
+// notifyPermissionReviewed tells the user their role request was approved or rejected
+func notifyPermissionReviewed(client *firestore.Client, req models.PermissionRequest, status, notes string) {
+	body := fmt.Sprintf("Your request for the %s role was %s.\n%s", req.RequestedRole, status, reviewOutcomeNote(notes))
+	notifyUser(client, req.UserID, notifyPermissionOutcome, "Your role request was "+status, body)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// buildOnboarding computes the user's first-run checklist from existing data.
+// linked reports whether a person is already linked to the user.
+func buildOnboarding(ctx context.Context, client *firestore.Client, user models.User, linked bool) models.OnboardingState {
+	state := models.OnboardingState{
+		IdentityClaimed: linked,
+		RoleRequested:   user.Role != models.RoleViewer && user.Role != "",
+		Dismissed:       user.OnboardingDismissed,
+	}
+
+	if !state.IdentityClaimed {
+		iter := database.TreeCollection(ctx, client, "identity_claims").
+			Where("user_id", "==", user.ID).
+			Where("status", "==", "pending").
+			Limit(1).
+			Documents(ctx)
+		if _, err := iter.Next(); err == nil {
+			state.IdentityClaimed = true
+		}
+		iter.Stop()
+	}
+
+	if !state.RoleRequested {
+		iter := database.TreeCollection(ctx, client, "permission_requests").
+			Where("user_id", "==", user.ID).
+			Limit(1).
+			Documents(ctx)
+		if _, err := iter.Next(); err == nil {
+			state.RoleRequested = true
+		}
+		iter.Stop()
+	}
+
+	state.Completed = state.IdentityClaimed && state.RoleRequested
+	return state
+}
+
+// DismissOnboarding hides the first-run checklist for the current user
+func (h *FirestoreAuthHandler) DismissOnboarding(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	ctx := treeContext(c)
+
+	_, err := h.coll(ctx, "users").Doc(userID.(string)).Update(ctx, []firestore.Update{
+		{Path: "onboarding_dismissed", Value: true},
+		{Path: "updated_at", Value: time.Now()},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss onboarding"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Onboarding dismissed"})
+}
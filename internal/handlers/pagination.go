@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// envPositiveInt reads a positive integer from the environment, falling back to def
+func envPositiveInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return def
+}
+
+var (
+	// maxPageSize is the hard upper bound for any paginated list (MAX_PAGE_SIZE, default 100)
+	maxPageSize = envPositiveInt("MAX_PAGE_SIZE", 100)
+
+	// defaultPageSize applies when page_size is missing or invalid (DEFAULT_PAGE_SIZE, default 50)
+	defaultPageSize = func() int {
+		size := envPositiveInt("DEFAULT_PAGE_SIZE", 50)
+		if size > maxPageSize {
+			return maxPageSize
+		}
+		return size
+	}()
+)
+
+// parsePagination reads ?page= and ?page_size= (1-based page), applying the defaults
+// and clamping page_size to maxPageSize
+func parsePagination(c *gin.Context) (page, pageSize int) {
+	page, _ = strconv.Atoi(c.Query("page"))
+	pageSize, _ = strconv.Atoi(c.Query("page_size"))
+	return normalizePagination(page, pageSize)
+}
+
+// normalizePagination applies the shared defaults and maximum to already-parsed values
+func normalizePagination(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
+
+// pageBounds returns the slice bounds of a page within total items, and the page count
+func pageBounds(total, page, pageSize int) (start, end, totalPages int) {
+	totalPages = (total + pageSize - 1) / pageSize
+	// Compare before multiplying so a huge ?page= can't overflow into negative bounds
+	start = total
+	if page-1 <= total/pageSize {
+		if start = (page - 1) * pageSize; start > total {
+			start = total
+		}
+	}
+	end = start + pageSize
+	if end > total {
+		end = total
+	}
+	return start, end, totalPages
+}
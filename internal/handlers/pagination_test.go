@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		name                   string
+		total, page, pageSize  int
+		start, end, totalPages int
+	}{
+		{"first page", 120, 1, 50, 0, 50, 3},
+		{"last partial page", 120, 3, 50, 100, 120, 3},
+		{"past the end", 120, 4, 50, 120, 120, 3},
+		{"empty", 0, 1, 50, 0, 0, 0},
+		{"huge page", 120, math.MaxInt, 50, 120, 120, 3},
+		{"huge page, max size", 120, math.MaxInt/2 + 2, 100, 120, 120, 2},
+	}
+	for _, tt := range tests {
+		start, end, totalPages := pageBounds(tt.total, tt.page, tt.pageSize)
+		if start != tt.start || end != tt.end || totalPages != tt.totalPages {
+			t.Errorf("%s: got %d, %d, %d; want %d, %d, %d", tt.name, start, end, totalPages, tt.start, tt.end, tt.totalPages)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/api/iterator"
+)
+
+// passwordResetTTL is how long an emailed reset token stays valid
+// (PASSWORD_RESET_MINUTES, default 60)
+var passwordResetTTL = time.Duration(envPositiveInt("PASSWORD_RESET_MINUTES", 60)) * time.Minute
+
+// passwordResetMailer sends reset links. Unlike notifications these ignore the user's
+// preferences, since they are only sent on request.
+var passwordResetMailer utils.Mailer = notificationMailer
+
+// errInvalidResetToken covers unknown, expired and already used tokens alike
+var errInvalidResetToken = errors.New("invalid or expired reset token")
+
+// hashToken is the document ID an opaque token is stored under (password_resets,
+// refresh_tokens), so the token itself is never persisted
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// passwordResetLink is the link emailed to the user: PASSWORD_RESET_URL with the token
+// appended, or just the token when no URL is configured
+func passwordResetLink(token string) string {
+	base := os.Getenv("PASSWORD_RESET_URL")
+	if base == "" {
+		return token
+	}
+	return base + "?token=" + token
+}
+
+// validatePasswordReset checks that a reset token can still be used at now. reset is nil
+// when no reset matches the token.
+func validatePasswordReset(reset *models.PasswordReset, now time.Time) error {
+	if reset == nil || !reset.UsedAt.IsZero() || now.After(reset.ExpiresAt) {
+		return errInvalidResetToken
+	}
+	return nil
+}
+
+// ForgotPassword emails a reset link to the account with this email. The response is the
+// same whether or not the account exists, so it can't be used to discover emails.
+func (h *FirestoreAuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A valid email is required"})
+		return
+	}
+
+	if err := h.sendPasswordReset(treeContext(c), req.Email); err != nil {
+		log.Printf("[PasswordReset] Failed to start reset for %s: %v", req.Email, err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for that email, a reset link has been sent"})
+}
+
+// sendPasswordReset stores a new reset token for the user with this email and mails it.
+// An unknown email is not an error.
+func (h *FirestoreAuthHandler) sendPasswordReset(ctx context.Context, email string) error {
+	iter := h.coll(ctx, "users").Where("email", "==", email).Limit(1).Documents(ctx)
+	doc, err := iter.Next()
+	if err == iterator.Done {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if passwordResetMailer == nil {
+		return errors.New("no mailer configured")
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	reset := models.PasswordReset{
+		UserID:    doc.Ref.ID,
+		ExpiresAt: now.Add(passwordResetTTL),
+		CreatedAt: now,
+	}
+	if _, err := h.coll(ctx, "password_resets").Doc(hashToken(token)).Set(ctx, reset); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Someone asked to reset the password for your FindYourRoot account.\n\n"+
+		"Use this link within %d minutes to choose a new password:\n%s\n\n"+
+		"If this wasn't you, you can ignore this email.\n",
+		int(passwordResetTTL.Minutes()), passwordResetLink(token))
+	go func() {
+		if err := passwordResetMailer.Send(email, "Reset your password", body); err != nil {
+			log.Printf("[PasswordReset] Failed to send reset email to %s: %v", email, err)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword. Each token works
+// once and only until it expires; a successful reset also clears any login lockout and
+// revokes the user's refresh tokens.
+func (h *FirestoreAuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token and password are required"})
+		return
+	}
+	if len(req.Password) < minPasswordLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Password must be at least %d characters", minPasswordLength)})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), h.auth.BcryptCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	ctx := treeContext(c)
+	resetRef := h.coll(ctx, "password_resets").Doc(hashToken(req.Token))
+	var user models.User
+	err = h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		now := time.Now()
+		doc, err := tx.Get(resetRef)
+		if err != nil {
+			return validatePasswordReset(nil, now)
+		}
+		var reset models.PasswordReset
+		if err := doc.DataTo(&reset); err != nil {
+			return err
+		}
+		if err := validatePasswordReset(&reset, now); err != nil {
+			return err
+		}
+
+		userRef := h.coll(ctx, "users").Doc(reset.UserID)
+		userDoc, err := tx.Get(userRef)
+		if err != nil {
+			return errInvalidResetToken
+		}
+		if err := userDoc.DataTo(&user); err != nil {
+			return err
+		}
+		user.ID = userDoc.Ref.ID
+
+		if err := tx.Update(userRef, []firestore.Update{
+			{Path: "password_hash", Value: string(hashedPassword)},
+			{Path: "failed_login_attempts", Value: 0},
+			{Path: "locked_until", Value: time.Time{}},
+			{Path: "updated_at", Value: now},
+		}); err != nil {
+			return err
+		}
+		return tx.Update(resetRef, []firestore.Update{{Path: "used_at", Value: now}})
+	})
+	if errors.Is(err, errInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+	if err != nil {
+		log.Printf("[PasswordReset] Failed to reset password: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	if err := revokeRefreshTokens(ctx, h.client, "user_id", user.ID); err != nil {
+		log.Printf("[PasswordReset] Failed to revoke refresh tokens for %s: %v", user.ID, err)
+	}
+	recordAudit(ctx, h.client, c, "password_reset", user.ID, map[string]interface{}{
+		"email": user.Email,
+	})
+	sendUserEmail(user, notifyAccountSecurity, "Your password was changed",
+		"The password for your FindYourRoot account was just reset.\n\n"+
+			"If this wasn't you, request a new reset link right away.\n")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset. You can now log in."})
+}
+
+// Change-password refusals, worded for the client
+var (
+	errPasswordTooShort     = fmt.Errorf("Password must be at least %d characters", minPasswordLength)
+	errPasswordUnchanged    = errors.New("New password must be different from the current one")
+	errWrongCurrentPassword = errors.New("Current password is incorrect")
+)
+
+// validatePasswordChange checks the new password before the current one is verified
+func validatePasswordChange(req models.ChangePasswordRequest) error {
+	if len(req.NewPassword) < minPasswordLength {
+		return errPasswordTooShort
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return errPasswordUnchanged
+	}
+	return nil
+}
+
+// verifyCurrentPassword checks password against the user's stored hash
+func verifyCurrentPassword(user models.User, password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return errWrongCurrentPassword
+	}
+	return nil
+}
+
+// ChangePassword sets a new password for the logged-in user after checking the current one
+func (h *FirestoreAuthHandler) ChangePassword(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "current_password and new_password are required"})
+		return
+	}
+	if err := validatePasswordChange(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := treeContext(c)
+	ref := h.coll(ctx, "users").Doc(userID.(string))
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var user models.User
+	if err := doc.DataTo(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
+		return
+	}
+	user.ID = doc.Ref.ID
+
+	if err := verifyCurrentPassword(user, req.CurrentPassword); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), h.auth.BcryptCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+	if _, err := ref.Update(ctx, []firestore.Update{
+		{Path: "password_hash", Value: string(hashedPassword)},
+		{Path: "updated_at", Value: time.Now()},
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	// Refresh tokens issued before the change stop working, this session's included
+	if err := revokeRefreshTokens(ctx, h.client, "user_id", user.ID); err != nil {
+		log.Printf("[PasswordReset] Failed to revoke refresh tokens for %s: %v", user.ID, err)
+	}
+	recordAudit(ctx, h.client, c, "password_change", user.ID, nil)
+	sendUserEmail(user, notifyAccountSecurity, "Your password was changed",
+		"The password for your FindYourRoot account was just changed.\n\n"+
+			"If this wasn't you, reset your password right away.\n")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mamiri/findyourroot/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestValidatePasswordReset(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		reset   *models.PasswordReset
+		wantErr bool
+	}{
+		{"unknown token", nil, true},
+		{"valid", &models.PasswordReset{UserID: "u1", ExpiresAt: now.Add(time.Minute)}, false},
+		{"expired", &models.PasswordReset{UserID: "u1", ExpiresAt: now.Add(-time.Second)}, true},
+		{"reused", &models.PasswordReset{UserID: "u1", ExpiresAt: now.Add(time.Minute), UsedAt: now.Add(-time.Minute)}, true},
+	}
+	for _, tt := range tests {
+		err := validatePasswordReset(tt.reset, now)
+		if tt.wantErr && !errors.Is(err, errInvalidResetToken) {
+			t.Errorf("%s: got %v, want errInvalidResetToken", tt.name, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	if hashToken("abc") != hashToken("abc") {
+		t.Error("hashToken is not deterministic")
+	}
+	if hashToken("abc") == hashToken("abd") {
+		t.Error("different tokens hashed to the same ID")
+	}
+	if got := hashToken("abc"); got == "abc" || len(got) != 64 {
+		t.Errorf("hashToken(%q) = %q, want a hex SHA-256", "abc", got)
+	}
+}
+
+func TestValidatePasswordChange(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.ChangePasswordRequest
+		want error
+	}{
+		{"ok", models.ChangePasswordRequest{CurrentPassword: "old-secret", NewPassword: "new-secret"}, nil},
+		{"too short", models.ChangePasswordRequest{CurrentPassword: "old-secret", NewPassword: "abc"}, errPasswordTooShort},
+		{"unchanged", models.ChangePasswordRequest{CurrentPassword: "same-secret", NewPassword: "same-secret"}, errPasswordUnchanged},
+	}
+	for _, tt := range tests {
+		if got := validatePasswordChange(tt.req); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyCurrentPassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{ID: "u1", PasswordHash: string(hash)}
+
+	if err := verifyCurrentPassword(user, "correct-horse"); err != nil {
+		t.Errorf("correct password: unexpected error %v", err)
+	}
+	if err := verifyCurrentPassword(user, "wrong-horse"); !errors.Is(err, errWrongCurrentPassword) {
+		t.Errorf("wrong password: got %v, want errWrongCurrentPassword", err)
+	}
+}
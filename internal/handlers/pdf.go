@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// pdfFontCandidates are the TrueType fonts tried for PDF output, in order. PDF_FONT_PATH takes
+// precedence; the font needs Arabic presentation forms for Persian names to render.
+var pdfFontCandidates = []string{
+	os.Getenv("PDF_FONT_PATH"),
+	"/usr/share/fonts/dejavu/DejaVuSans.ttf",
+	"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+}
+
+// pdfFontBytes is the loaded Unicode font, or nil when none was found (core fonts are used instead)
+var pdfFontBytes = func() []byte {
+	for _, path := range pdfFontCandidates {
+		if path == "" {
+			continue
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			return data
+		}
+	}
+	log.Printf("[PDF] No Unicode font found; set PDF_FONT_PATH. Non-Latin text will not render.")
+	return nil
+}()
+
+// pdfDoc wraps an A4 document with direction-aware text helpers
+type pdfDoc struct {
+	pdf       *fpdf.Fpdf
+	family    string
+	translate func(string) string
+	rtl       bool // Layout direction: labels on the right, text right-aligned
+}
+
+// newPDFDoc starts an A4 portrait document with the Unicode font when available
+func newPDFDoc(rtl bool) *pdfDoc {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(false, 15)
+	pdf.AddPage()
+
+	doc := &pdfDoc{pdf: pdf, rtl: rtl, family: "Helvetica"}
+	if pdfFontBytes != nil {
+		pdf.AddUTF8FontFromBytes("body", "", pdfFontBytes)
+		doc.family = "body"
+		doc.translate = func(s string) string { return s }
+	} else {
+		doc.translate = pdf.UnicodeTranslatorFromDescriptor("")
+	}
+	pdf.SetFont(doc.family, "", 11)
+	return doc
+}
+
+// text prepares s for drawing: Persian is shaped and reordered, everything is translated for the font
+func (d *pdfDoc) text(s string) string {
+	return d.translate(utils.VisualRTL(s))
+}
+
+// align returns the alignment for s: right for Persian text or an RTL layout
+func (d *pdfDoc) align(s string) string {
+	if d.rtl || utils.ContainsPersianCharacters(s) {
+		return "R"
+	}
+	return "L"
+}
+
+// remaining returns the vertical space left on the page
+func (d *pdfDoc) remaining() float64 {
+	_, pageH := d.pdf.GetPageSize()
+	_, _, _, bottom := d.pdf.GetMargins()
+	return pageH - bottom - d.pdf.GetY()
+}
+
+// line writes one full-width line of text at the given size
+func (d *pdfDoc) line(s string, size, height float64) {
+	d.pdf.SetFont(d.family, "", size)
+	d.pdf.CellFormat(0, height, d.text(s), "", 1, d.align(s), false, 0, "")
+}
+
+// centered writes one centered line of text at the given size
+func (d *pdfDoc) centered(s string, size, height float64) {
+	d.pdf.SetFont(d.family, "", size)
+	d.pdf.CellFormat(0, height, d.text(s), "", 1, "C", false, 0, "")
+}
+
+// field writes a label/value pair; in RTL layouts the label sits on the right
+func (d *pdfDoc) field(label, value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	const labelW = 40.0
+	pageW, _ := d.pdf.GetPageSize()
+	left, _, right, _ := d.pdf.GetMargins()
+	valueW := pageW - left - right - labelW
+
+	d.pdf.SetFont(d.family, "", 11)
+	if d.rtl {
+		d.pdf.CellFormat(valueW, 7, d.text(value), "", 0, d.align(value), false, 0, "")
+		d.pdf.SetTextColor(100, 100, 100)
+		d.pdf.CellFormat(labelW, 7, d.text(label), "", 1, "R", false, 0, "")
+	} else {
+		d.pdf.SetTextColor(100, 100, 100)
+		d.pdf.CellFormat(labelW, 7, d.text(label), "", 0, "L", false, 0, "")
+		d.pdf.SetTextColor(0, 0, 0)
+		d.pdf.CellFormat(valueW, 7, d.text(value), "", 1, d.align(value), false, 0, "")
+	}
+	d.pdf.SetTextColor(0, 0, 0)
+}
+
+// rule draws a horizontal separator and leaves some space
+func (d *pdfDoc) rule() {
+	pageW, _ := d.pdf.GetPageSize()
+	left, _, right, _ := d.pdf.GetMargins()
+	y := d.pdf.GetY() + 2
+	d.pdf.SetDrawColor(180, 180, 180)
+	d.pdf.Line(left, y, pageW-right, y)
+	d.pdf.SetY(y + 4)
+}
+
+// output renders the document
+func (d *pdfDoc) output() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// maxPDFImageBytes caps the size of a downloaded photo
+const maxPDFImageBytes = 2 << 20
+
+// pdfImageClient fetches photos for PDFs. Avatar URLs are user-supplied, so it only
+// connects to public addresses (checked after DNS resolution, on every redirect too)
+// and never goes through a proxy.
+var pdfImageClient = &http.Client{
+	Timeout: 5 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: rejectNonPublicDial,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 3 {
+			return errors.New("too many redirects")
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+		}
+		return nil
+	},
+}
+
+// rejectNonPublicDial refuses connections to loopback, private, link-local (including
+// cloud metadata), multicast and unspecified addresses
+func rejectNonPublicDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || cgnatRange.Contains(ip) || thisNetworkRange.Contains(ip))
+}
+
+// cgnatRange is the shared address space (RFC 6598), which IsPrivate doesn't cover
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// thisNetworkRange is 0.0.0.0/8, which Linux routes to the local host
+var thisNetworkRange = &net.IPNet{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)}
+
+// registerRemoteImage downloads a PNG/JPEG and registers it under name.
+// Returns false for other formats (e.g. generated SVG avatars) or on any failure.
+func (d *pdfDoc) registerRemoteImage(name, url string) bool {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return false
+	}
+	resp, err := pdfImageClient.Get(url)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	var imageType string
+	switch ct := resp.Header.Get("Content-Type"); {
+	case strings.HasPrefix(ct, "image/png"):
+		imageType = "PNG"
+	case strings.HasPrefix(ct, "image/jpeg"), strings.HasPrefix(ct, "image/jpg"):
+		imageType = "JPG"
+	default:
+		return false
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPDFImageBytes+1))
+	if err != nil || len(data) > maxPDFImageBytes {
+		return false
+	}
+	d.pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(data))
+	if err := d.pdf.Error(); err != nil {
+		log.Printf("[PDF] Failed to embed image %s: %v", url, err)
+		d.pdf.ClearError()
+		return false
+	}
+	return true
+}
+
+// pdfFilename builds an attachment filename for a generated PDF
+func pdfFilename(base string) string {
+	return fmt.Sprintf("%s-%s.pdf", base, time.Now().Format("2006-01-02"))
+}
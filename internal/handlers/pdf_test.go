@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"151.101.1.140", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false}, // Cloud metadata
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestRejectNonPublicDial(t *testing.T) {
+	if err := rejectNonPublicDial("tcp", "169.254.169.254:80", nil); err == nil {
+		t.Error("expected the metadata address to be rejected")
+	}
+	if err := rejectNonPublicDial("tcp6", "[::1]:443", nil); err == nil {
+		t.Error("expected loopback to be rejected")
+	}
+	if err := rejectNonPublicDial("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("expected a public address to be allowed, got %v", err)
+	}
+}
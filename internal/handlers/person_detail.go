@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// LinkedUserInfo is the account linked to a person, shown to approvers and the user themselves
+type LinkedUserInfo struct {
+	ID         string          `json:"id"`
+	Email      string          `json:"email"`
+	Role       models.UserRole `json:"role"`
+	IsVerified bool            `json:"is_verified"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// SocialProfile is the cached Instagram profile stored on a person
+type SocialProfile struct {
+	InstagramUsername string `json:"instagram_username"`
+	AvatarURL         string `json:"avatar_url"`
+	FullName          string `json:"full_name"`
+	Bio               string `json:"bio"`
+	IsVerified        bool   `json:"is_verified"`
+}
+
+// GetPersonDetail returns everything the person page shows in one response: the person,
+// parents, children and partners (spouses and other parents of their children) as summaries, the
+// father's line to the root ancestor, the cached Instagram profile and the like state.
+// The linked account is included for approvers and for the linked user themselves.
+func (h *FirestoreTreeHandler) GetPersonDetail(c *gin.Context) {
+	id := c.Param("id")
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+	var person models.Person
+	if err := doc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+	person.RelationshipType = person.ParentRelationship()
+
+	parents, err := findParents(ctx, h.client, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parents"})
+		return
+	}
+	children, err := fetchPeopleByIDs(ctx, h.client, person.Children)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch children"})
+		return
+	}
+	partners, err := findPartners(ctx, h.client, person)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch partners"})
+		return
+	}
+	lineage, cycleDetected, err := lineageChain(ctx, h.client, person, "male")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lineage"})
+		return
+	}
+
+	response := gin.H{
+		"person":         person,
+		"parents":        summarize(parents),
+		"children":       summarize(children),
+		"partners":       summarize(partners),
+		"lineage":        summarize(lineage),
+		"cycle_detected": cycleDetected,
+		"linked":         person.LinkedUserID != "",
+		"likes_count":    person.LikesCount,
+		"liked_by_me":    containsString(person.LikedBy, userID.(string)),
+	}
+
+	if person.InstagramUsername != "" {
+		response["social"] = SocialProfile{
+			InstagramUsername: person.InstagramUsername,
+			AvatarURL:         person.InstagramAvatarURL,
+			FullName:          person.InstagramFullName,
+			Bio:               person.InstagramBio,
+			IsVerified:        person.InstagramIsVerified,
+		}
+	}
+
+	canSeeLinkedUser := models.UserRole(role.(string)).CanApprove() || person.LinkedUserID == userID.(string)
+	if person.LinkedUserID != "" && canSeeLinkedUser {
+		if userDoc, err := h.coll(ctx, "users").Doc(person.LinkedUserID).Get(ctx); err == nil {
+			var user models.User
+			if err := userDoc.DataTo(&user); err == nil {
+				response["linked_user"] = LinkedUserInfo{
+					ID:         userDoc.Ref.ID,
+					Email:      user.Email,
+					Role:       user.Role,
+					IsVerified: user.IsVerified,
+					CreatedAt:  user.CreatedAt,
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
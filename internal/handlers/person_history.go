@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// historyFields are the person fields whose changes are kept in person_history,
+// named as in the API
+var historyFields = []struct {
+	name  string
+	value func(models.Person) interface{}
+}{
+	{"name", func(p models.Person) interface{} { return p.Name }},
+	{"alt_names", func(p models.Person) interface{} { return historyList(p.AltNames) }},
+	{"role", func(p models.Person) interface{} { return p.Role }},
+	{"occupation", func(p models.Person) interface{} { return p.Occupation }},
+	{"gender", func(p models.Person) interface{} { return p.Gender }},
+	{"birth", func(p models.Person) interface{} { return p.Birth }},
+	{"death", func(p models.Person) interface{} { return p.Death }},
+	{"deceased", func(p models.Person) interface{} { return p.Deceased }},
+	{"location", func(p models.Person) interface{} { return p.Location }},
+	{"avatar", func(p models.Person) interface{} { return p.Avatar }},
+	{"bio", func(p models.Person) interface{} { return p.Bio }},
+	{"children", func(p models.Person) interface{} { return historyList(p.Children) }},
+	{"relationship_type", func(p models.Person) interface{} { return p.RelationshipType }},
+	{"linked_user_id", func(p models.Person) interface{} { return p.LinkedUserID }},
+}
+
+// historyList treats a missing list like an empty one
+func historyList(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// diffPeople lists the tracked fields that differ between two versions of a person
+func diffPeople(before, after models.Person) []models.FieldChange {
+	var changes []models.FieldChange
+	for _, f := range historyFields {
+		oldValue, newValue := f.value(before), f.value(after)
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, models.FieldChange{Field: f.name, Before: oldValue, After: newValue})
+		}
+	}
+	return changes
+}
+
+// recordPersonHistory stores the field-level diff of a change to a person. Nothing is
+// written when no tracked field changed. Like recordAudit, failures are only logged.
+func recordPersonHistory(ctx context.Context, client *firestore.Client, before, after models.Person, editorID, editorEmail, source, sourceID string) {
+	changes := diffPeople(before, after)
+	if len(changes) == 0 {
+		return
+	}
+
+	entry := models.PersonHistoryEntry{
+		ID:          uuid.New().String(),
+		PersonID:    before.ID,
+		EditorID:    editorID,
+		EditorEmail: editorEmail,
+		Source:      source,
+		SourceID:    sourceID,
+		Changes:     changes,
+		CreatedAt:   time.Now(),
+	}
+	if _, err := database.TreeCollection(ctx, client, "person_history").Doc(entry.ID).Set(ctx, entry); err != nil {
+		log.Printf("[History] Failed to record %s of %s by %s: %v", source, before.ID, editorEmail, err)
+	}
+}
+
+// recordPersonHistoryFor records a change made by the current request's user
+func recordPersonHistoryFor(ctx context.Context, client *firestore.Client, c *gin.Context, before, after models.Person, source, sourceID string) {
+	editorID, _ := c.Get("user_id")
+	editorEmail, _ := c.Get("email")
+	id, _ := editorID.(string)
+	email, _ := editorEmail.(string)
+	recordPersonHistory(ctx, client, before, after, id, email, source, sourceID)
+}
+
+// GetPersonHistory returns the recorded changes to a person, newest first, with the
+// before/after value of each changed field. The history outlives the person, so it can
+// still be read for deleted people. Paginated with page and page_size.
+func (h *FirestoreTreeHandler) GetPersonHistory(c *gin.Context) {
+	id := c.Param("id")
+	page, pageSize := parsePagination(c)
+	ctx := treeContext(c)
+
+	docs, err := h.coll(ctx, "person_history").Where("person_id", "==", id).Documents(ctx).GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+		return
+	}
+
+	entries := make([]models.PersonHistoryEntry, 0, len(docs))
+	for _, doc := range docs {
+		var entry models.PersonHistoryEntry
+		if err := doc.DataTo(&entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+
+	total := len(entries)
+	start, end, totalPages := pageBounds(total, page, pageSize)
+
+	c.JSON(http.StatusOK, gin.H{
+		"person_id":   id,
+		"data":        entries[start:end],
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+	})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// bindClearQueue validates a clear request: ?action=approve|reject, confirm=true and a reason.
+// It writes the error response and returns ok=false when the request is invalid.
+func bindClearQueue(c *gin.Context) (approved bool, reason string, ok bool) {
+	action := c.Query("action")
+	if action != "approve" && action != "reject" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be 'approve' or 'reject'"})
+		return false, "", false
+	}
+
+	var req models.ClearQueueRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return false, "", false
+	}
+	if !sanitizeFields(c, &req.Reason) {
+		return false, "", false
+	}
+	if !req.Confirm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This processes every pending item. Re-send with confirm=true to proceed."})
+		return false, "", false
+	}
+
+	return action == "approve", strings.TrimSpace(req.Reason), true
+}
+
+// ClearSuggestions approves or rejects every pending suggestion (admin only)
+func (h *FirestoreSuggestionHandler) ClearSuggestions(c *gin.Context) {
+	approved, reason, ok := bindClearQueue(c)
+	if !ok {
+		return
+	}
+
+	reviewerID, _ := c.Get("user_id")
+	reviewerEmail, _ := c.Get("email")
+	ctx := treeContext(c)
+
+	iter := h.coll(ctx, "suggestions").Where("status", "==", "pending").Documents(ctx)
+	var ids []string
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			iter.Stop()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+			return
+		}
+		ids = append(ids, doc.Ref.ID)
+	}
+	iter.Stop()
+
+	successCount, failures := h.reviewSuggestions(ctx, ids, approved, reason, reviewerID.(string), reviewerEmail.(string))
+	failCount := len(failures)
+	log.Printf("[ClearQueue] Suggestions cleared by %s: %d success, %d failed", reviewerEmail, successCount, failCount)
+
+	recordAudit(ctx, h.client, c, "queue_clear", "suggestions", map[string]interface{}{
+		"approved":      approved,
+		"reason":        reason,
+		"success_count": successCount,
+		"fail_count":    failCount,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"processed":     len(ids),
+		"success_count": successCount,
+		"fail_count":    failCount,
+	})
+}
+
+// ClearIdentityClaims approves or rejects every pending identity claim (admin only).
+// Approved claims store their Instagram username but are not enriched here.
+func (h *FirestoreIdentityClaimHandler) ClearIdentityClaims(c *gin.Context) {
+	approved, reason, ok := bindClearQueue(c)
+	if !ok {
+		return
+	}
+
+	adminID, _ := c.Get("user_id")
+	ctx := treeContext(c)
+
+	iter := h.coll(ctx, "identity_claims").Where("status", "==", "pending").Documents(ctx)
+	defer iter.Stop()
+
+	processed, successCount, failCount := 0, 0, 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch claims"})
+			return
+		}
+
+		var claim models.IdentityClaimRequest
+		if err := doc.DataTo(&claim); err != nil {
+			continue
+		}
+		processed++
+
+		if err := h.applyClaimReview(ctx, doc.Ref.ID, claim, approved, reason, adminID.(string)); err != nil {
+			log.Printf("[ClearQueue] Failed to review claim %s: %v", doc.Ref.ID, err)
+			failCount++
+			continue
+		}
+		successCount++
+	}
+
+	recordAudit(ctx, h.client, c, "queue_clear", "identity_claims", map[string]interface{}{
+		"approved":      approved,
+		"reason":        reason,
+		"success_count": successCount,
+		"fail_count":    failCount,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"processed":     processed,
+		"success_count": successCount,
+		"fail_count":    failCount,
+	})
+}
+
+// ClearPermissionRequests approves or rejects every pending permission request (admin only).
+// Requests for the admin role are never bulk-approved; they are left pending and reported.
+func (h *FirestoreAuthHandler) ClearPermissionRequests(c *gin.Context) {
+	approved, reason, ok := bindClearQueue(c)
+	if !ok {
+		return
+	}
+
+	ctx := treeContext(c)
+
+	iter := h.coll(ctx, "permission_requests").Where("status", "==", "pending").Documents(ctx)
+	defer iter.Stop()
+
+	processed, successCount, failCount := 0, 0, 0
+	skipped := []string{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching requests"})
+			return
+		}
+
+		var req models.PermissionRequest
+		if err := doc.DataTo(&req); err != nil {
+			continue
+		}
+		processed++
+
+		if approved && req.RequestedRole == models.RoleAdmin {
+			skipped = append(skipped, doc.Ref.ID)
+			continue
+		}
+
+		if err := h.resolvePermissionRequest(ctx, doc.Ref.ID, req, approved, reason); err != nil {
+			log.Printf("[ClearQueue] Failed to resolve permission request %s: %v", doc.Ref.ID, err)
+			failCount++
+			continue
+		}
+		successCount++
+	}
+
+	recordAudit(ctx, h.client, c, "queue_clear", "permission_requests", map[string]interface{}{
+		"approved":      approved,
+		"reason":        reason,
+		"success_count": successCount,
+		"fail_count":    failCount,
+		"skipped":       skipped,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"processed":     processed,
+		"success_count": successCount,
+		"fail_count":    failCount,
+		"skipped_admin": skipped,
+	})
+}
@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/firestore"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
 	"google.golang.org/api/iterator"
 )
 
@@ -34,6 +39,16 @@ func (s *ReferentialIntegrityService) OnPersonDeleted(ctx context.Context, perso
 		log.Printf("[RefIntegrity] Warning: Failed to remove from parent children: %v", err)
 	}
 
+	// 2a. Remove this person from their children's parent_ids; other parents stay linked
+	if err := s.removeFromChildParentIDs(ctx, personID); err != nil {
+		log.Printf("[RefIntegrity] Warning: Failed to remove from children's parent_ids: %v", err)
+	}
+
+	// 2b. Remove this person from their spouses' spouses arrays
+	if err := s.removeFromSpouses(ctx, personID); err != nil {
+		log.Printf("[RefIntegrity] Warning: Failed to remove from spouses: %v", err)
+	}
+
 	// 3. Handle orphaned children - they become root nodes (no parent)
 	// Note: We don't delete children, just leave them as roots
 
@@ -50,44 +65,58 @@ func (s *ReferentialIntegrityService) OnPersonDeleted(ctx context.Context, perso
 	return nil
 }
 
-// OnUserDeleted cleans up all references when a user is deleted
-func (s *ReferentialIntegrityService) OnUserDeleted(ctx context.Context, userID string) error {
+// UserCleanupSummary counts the references OnUserDeleted cleaned up
+type UserCleanupSummary struct {
+	PersonLinks        int `json:"person_links" firestore:"person_links"`               // People whose linked_user_id was cleared
+	Likes              int `json:"likes" firestore:"likes"`                             // People the user was removed from liked_by of
+	PermissionRequests int `json:"permission_requests" firestore:"permission_requests"` // Pending requests rejected
+	IdentityClaims     int `json:"identity_claims" firestore:"identity_claims"`         // Pending claims rejected
+	Suggestions        int `json:"suggestions" firestore:"suggestions"`                 // Pending suggestions rejected
+}
+
+// OnUserDeleted cleans up all references when a user is deleted and reports what it changed.
+// Every step runs even when an earlier one fails; the failures are returned together so the
+// caller can keep the account until the cleanup succeeds.
+func (s *ReferentialIntegrityService) OnUserDeleted(ctx context.Context, userID string) (UserCleanupSummary, error) {
 	log.Printf("[RefIntegrity] Cleaning up references for deleted user: %s", userID)
+	var summary UserCleanupSummary
+	var errs []error
+	var err error
 
 	// 1. Clear linked_user_id from any person linked to this user
-	if err := s.clearPersonUserLinks(ctx, userID); err != nil {
-		log.Printf("[RefIntegrity] Warning: Failed to clear person links: %v", err)
+	if summary.PersonLinks, err = s.clearPersonUserLinks(ctx, userID); err != nil {
+		errs = append(errs, fmt.Errorf("clear person links: %w", err))
 	}
 
 	// 2. Remove user from liked_by arrays
-	if err := s.removeFromLikedBy(ctx, userID); err != nil {
-		log.Printf("[RefIntegrity] Warning: Failed to remove from liked_by: %v", err)
+	if summary.Likes, err = s.removeFromLikedBy(ctx, userID); err != nil {
+		errs = append(errs, fmt.Errorf("remove from liked_by: %w", err))
 	}
 
 	// 3. Cancel pending permission requests from this user
-	if err := s.cancelPermissionRequests(ctx, userID); err != nil {
-		log.Printf("[RefIntegrity] Warning: Failed to cancel permission requests: %v", err)
+	if summary.PermissionRequests, err = s.cancelPermissionRequests(ctx, userID); err != nil {
+		errs = append(errs, fmt.Errorf("cancel permission requests: %w", err))
 	}
 
 	// 4. Cancel pending identity claims from this user
-	if err := s.cancelIdentityClaimsForUser(ctx, userID); err != nil {
-		log.Printf("[RefIntegrity] Warning: Failed to cancel identity claims: %v", err)
+	if summary.IdentityClaims, err = s.cancelIdentityClaimsForUser(ctx, userID); err != nil {
+		errs = append(errs, fmt.Errorf("cancel identity claims: %w", err))
 	}
 
 	// 5. Cancel pending suggestions from this user
-	if err := s.cancelSuggestionsForUser(ctx, userID); err != nil {
-		log.Printf("[RefIntegrity] Warning: Failed to cancel suggestions: %v", err)
+	if summary.Suggestions, err = s.cancelSuggestionsForUser(ctx, userID); err != nil {
+		errs = append(errs, fmt.Errorf("cancel suggestions: %w", err))
 	}
 
 	// Note: We keep created_by and reviewed_by as historical records
 	// They just point to a deleted user, which is fine for audit purposes
 
-	return nil
+	return summary, errors.Join(errs...)
 }
 
 // clearUserPersonLinks clears person_id from users linked to the deleted person
 func (s *ReferentialIntegrityService) clearUserPersonLinks(ctx context.Context, personID string) error {
-	iter := s.client.Collection("users").Where("person_id", "==", personID).Documents(ctx)
+	iter := s.coll(ctx, "users").Where("person_id", "==", personID).Documents(ctx)
 	defer iter.Stop()
 
 	for {
@@ -99,7 +128,7 @@ func (s *ReferentialIntegrityService) clearUserPersonLinks(ctx context.Context,
 			return err
 		}
 
-		_, err = s.client.Collection("users").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
+		_, err = s.coll(ctx, "users").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
 			{Path: "person_id", Value: ""},
 			{Path: "tree_name", Value: ""},
 			{Path: "updated_at", Value: time.Now()},
@@ -115,7 +144,7 @@ func (s *ReferentialIntegrityService) clearUserPersonLinks(ctx context.Context,
 
 // removeFromParentChildren removes the person from any parent's children array
 func (s *ReferentialIntegrityService) removeFromParentChildren(ctx context.Context, personID string) error {
-	iter := s.client.Collection("people").Where("children", "array-contains", personID).Documents(ctx)
+	iter := s.coll(ctx, "people").Where("children", "array-contains", personID).Documents(ctx)
 	defer iter.Stop()
 
 	for {
@@ -127,7 +156,7 @@ func (s *ReferentialIntegrityService) removeFromParentChildren(ctx context.Conte
 			return err
 		}
 
-		_, err = s.client.Collection("people").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
+		_, err = s.coll(ctx, "people").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
 			{Path: "children", Value: firestore.ArrayRemove(personID)},
 			{Path: "updated_at", Value: time.Now()},
 		})
@@ -140,9 +169,63 @@ func (s *ReferentialIntegrityService) removeFromParentChildren(ctx context.Conte
 	return nil
 }
 
+// removeFromChildParentIDs removes the person from the parent_ids of each of their children
+func (s *ReferentialIntegrityService) removeFromChildParentIDs(ctx context.Context, personID string) error {
+	iter := s.coll(ctx, "people").Where("parent_ids", "array-contains", personID).Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = s.coll(ctx, "people").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
+			{Path: "parent_ids", Value: firestore.ArrayRemove(personID)},
+			{Path: "updated_at", Value: time.Now()},
+		})
+		if err != nil {
+			log.Printf("[RefIntegrity] Failed to remove from child %s parent_ids: %v", doc.Ref.ID, err)
+		} else {
+			log.Printf("[RefIntegrity] Removed person from child %s parent_ids", doc.Ref.ID)
+		}
+	}
+	return nil
+}
+
+// removeFromSpouses removes a person from the spouses array of everyone married to them
+func (s *ReferentialIntegrityService) removeFromSpouses(ctx context.Context, personID string) error {
+	iter := s.coll(ctx, "people").Where("spouses", "array-contains", personID).Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = s.coll(ctx, "people").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
+			{Path: "spouses", Value: firestore.ArrayRemove(personID)},
+			{Path: "updated_at", Value: time.Now()},
+		})
+		if err != nil {
+			log.Printf("[RefIntegrity] Failed to remove from spouse %s: %v", doc.Ref.ID, err)
+		} else {
+			log.Printf("[RefIntegrity] Removed person from spouse %s", doc.Ref.ID)
+		}
+	}
+	return nil
+}
+
 // invalidateSuggestionsForPerson rejects pending suggestions targeting this person
 func (s *ReferentialIntegrityService) invalidateSuggestionsForPerson(ctx context.Context, personID string) error {
-	iter := s.client.Collection("suggestions").
+	iter := s.coll(ctx, "suggestions").
 		Where("target_person_id", "==", personID).
 		Where("status", "==", "pending").
 		Documents(ctx)
@@ -157,7 +240,7 @@ func (s *ReferentialIntegrityService) invalidateSuggestionsForPerson(ctx context
 			return err
 		}
 
-		_, err = s.client.Collection("suggestions").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
+		_, err = s.coll(ctx, "suggestions").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
 			{Path: "status", Value: "rejected"},
 			{Path: "review_notes", Value: "Auto-rejected: Target person was deleted"},
 			{Path: "updated_at", Value: time.Now()},
@@ -173,7 +256,7 @@ func (s *ReferentialIntegrityService) invalidateSuggestionsForPerson(ctx context
 
 // rejectIdentityClaimsForPerson rejects pending claims for this person
 func (s *ReferentialIntegrityService) rejectIdentityClaimsForPerson(ctx context.Context, personID string) error {
-	iter := s.client.Collection("identity_claims").
+	iter := s.coll(ctx, "identity_claims").
 		Where("person_id", "==", personID).
 		Where("status", "==", "pending").
 		Documents(ctx)
@@ -188,7 +271,7 @@ func (s *ReferentialIntegrityService) rejectIdentityClaimsForPerson(ctx context.
 			return err
 		}
 
-		_, err = s.client.Collection("identity_claims").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
+		_, err = s.coll(ctx, "identity_claims").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
 			{Path: "status", Value: "rejected"},
 			{Path: "review_notes", Value: "Auto-rejected: Person was deleted from tree"},
 			{Path: "updated_at", Value: time.Now()},
@@ -202,246 +285,689 @@ func (s *ReferentialIntegrityService) rejectIdentityClaimsForPerson(ctx context.
 	return nil
 }
 
-// clearPersonUserLinks clears linked_user_id from people when user is deleted
-func (s *ReferentialIntegrityService) clearPersonUserLinks(ctx context.Context, userID string) error {
-	iter := s.client.Collection("people").Where("linked_user_id", "==", userID).Documents(ctx)
+// clearPersonUserLinks clears linked_user_id from people when user is deleted. A failed
+// update doesn't stop the others; the first failure is returned.
+func (s *ReferentialIntegrityService) clearPersonUserLinks(ctx context.Context, userID string) (int, error) {
+	iter := s.coll(ctx, "people").Where("linked_user_id", "==", userID).Documents(ctx)
 	defer iter.Stop()
 
+	cleaned := 0
+	var failed error
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return err
+			return cleaned, err
 		}
 
-		_, err = s.client.Collection("people").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
+		_, err = s.coll(ctx, "people").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
 			{Path: "linked_user_id", Value: ""},
 			{Path: "updated_at", Value: time.Now()},
 		})
 		if err != nil {
 			log.Printf("[RefIntegrity] Failed to clear person %s user link: %v", doc.Ref.ID, err)
+			if failed == nil {
+				failed = fmt.Errorf("person %s: %w", doc.Ref.ID, err)
+			}
 		} else {
+			cleaned++
 			log.Printf("[RefIntegrity] Cleared user link for person %s", doc.Ref.ID)
 		}
 	}
-	return nil
+	return cleaned, failed
 }
 
-// removeFromLikedBy removes user from all liked_by arrays
-func (s *ReferentialIntegrityService) removeFromLikedBy(ctx context.Context, userID string) error {
-	iter := s.client.Collection("people").Where("liked_by", "array-contains", userID).Documents(ctx)
+// removeFromLikedBy removes user from all liked_by arrays. A failed update doesn't stop
+// the others; the first failure is returned.
+func (s *ReferentialIntegrityService) removeFromLikedBy(ctx context.Context, userID string) (int, error) {
+	iter := s.coll(ctx, "people").Where("liked_by", "array-contains", userID).Documents(ctx)
 	defer iter.Stop()
 
+	cleaned := 0
+	var failed error
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return err
+			return cleaned, err
 		}
 
-		_, err = s.client.Collection("people").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
-			{Path: "liked_by", Value: firestore.ArrayRemove(userID)},
-			{Path: "likes_count", Value: firestore.Increment(-1)},
-			{Path: "updated_at", Value: time.Now()},
+		// Recount from the array rather than decrementing, which could go negative
+		err = s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			snap, err := tx.Get(doc.Ref)
+			if err != nil {
+				return err
+			}
+			var person models.Person
+			if err := snap.DataTo(&person); err != nil {
+				return err
+			}
+			return tx.Update(doc.Ref, likeUpdates(withoutIDs(person.LikedBy, []string{userID}), time.Now()))
 		})
 		if err != nil {
 			log.Printf("[RefIntegrity] Failed to remove user from liked_by for person %s: %v", doc.Ref.ID, err)
+			if failed == nil {
+				failed = fmt.Errorf("person %s: %w", doc.Ref.ID, err)
+			}
 		} else {
+			cleaned++
 			log.Printf("[RefIntegrity] Removed user from liked_by for person %s", doc.Ref.ID)
 		}
 	}
-	return nil
+	return cleaned, failed
+}
+
+// likeUpdates returns the writes that store likedBy with a likes_count matching it
+func likeUpdates(likedBy []string, now time.Time) []firestore.Update {
+	return []firestore.Update{
+		{Path: "liked_by", Value: likedBy},
+		{Path: "likes_count", Value: likesCount(likedBy)},
+		{Path: "updated_at", Value: now},
+	}
 }
 
 // cancelPermissionRequests cancels pending permission requests from deleted user
-func (s *ReferentialIntegrityService) cancelPermissionRequests(ctx context.Context, userID string) error {
-	iter := s.client.Collection("permission_requests").
+func (s *ReferentialIntegrityService) cancelPermissionRequests(ctx context.Context, userID string) (int, error) {
+	iter := s.coll(ctx, "permission_requests").
 		Where("user_id", "==", userID).
 		Where("status", "==", "pending").
 		Documents(ctx)
 	defer iter.Stop()
 
+	cleaned := 0
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return err
+			return cleaned, err
 		}
 
-		_, err = s.client.Collection("permission_requests").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
+		_, err = s.coll(ctx, "permission_requests").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
 			{Path: "status", Value: "rejected"},
 			{Path: "updated_at", Value: time.Now()},
 		})
 		if err != nil {
 			log.Printf("[RefIntegrity] Failed to cancel permission request %s: %v", doc.Ref.ID, err)
+			continue
 		}
+		cleaned++
 	}
-	return nil
+	return cleaned, nil
 }
 
 // cancelIdentityClaimsForUser cancels pending identity claims from deleted user
-func (s *ReferentialIntegrityService) cancelIdentityClaimsForUser(ctx context.Context, userID string) error {
-	iter := s.client.Collection("identity_claims").
+func (s *ReferentialIntegrityService) cancelIdentityClaimsForUser(ctx context.Context, userID string) (int, error) {
+	iter := s.coll(ctx, "identity_claims").
 		Where("user_id", "==", userID).
 		Where("status", "==", "pending").
 		Documents(ctx)
 	defer iter.Stop()
 
+	cleaned := 0
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return err
+			return cleaned, err
 		}
 
-		_, err = s.client.Collection("identity_claims").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
+		_, err = s.coll(ctx, "identity_claims").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
 			{Path: "status", Value: "rejected"},
 			{Path: "review_notes", Value: "Auto-rejected: User account deleted"},
 			{Path: "updated_at", Value: time.Now()},
 		})
 		if err != nil {
 			log.Printf("[RefIntegrity] Failed to cancel identity claim %s: %v", doc.Ref.ID, err)
+			continue
 		}
+		cleaned++
 	}
-	return nil
+	return cleaned, nil
 }
 
 // cancelSuggestionsForUser cancels pending suggestions from deleted user
-func (s *ReferentialIntegrityService) cancelSuggestionsForUser(ctx context.Context, userID string) error {
-	iter := s.client.Collection("suggestions").
+func (s *ReferentialIntegrityService) cancelSuggestionsForUser(ctx context.Context, userID string) (int, error) {
+	iter := s.coll(ctx, "suggestions").
 		Where("user_id", "==", userID).
 		Where("status", "==", "pending").
 		Documents(ctx)
 	defer iter.Stop()
 
+	cleaned := 0
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return err
+			return cleaned, err
 		}
 
-		_, err = s.client.Collection("suggestions").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
+		_, err = s.coll(ctx, "suggestions").Doc(doc.Ref.ID).Update(ctx, []firestore.Update{
 			{Path: "status", Value: "rejected"},
 			{Path: "review_notes", Value: "Auto-rejected: User account deleted"},
 			{Path: "updated_at", Value: time.Now()},
 		})
 		if err != nil {
 			log.Printf("[RefIntegrity] Failed to cancel suggestion %s: %v", doc.Ref.ID, err)
+			continue
 		}
+		cleaned++
 	}
-	return nil
+	return cleaned, nil
 }
 
-// ValidatePersonReferences checks if a person's references are valid and cleans up invalid ones
-// Returns true if any cleanup was performed
-func (s *ReferentialIntegrityService) ValidatePersonReferences(ctx context.Context, personID string) (bool, error) {
-	doc, err := s.client.Collection("people").Doc(personID).Get(ctx)
-	if err != nil {
-		return false, err
+// integritySweepInterval is how often the background sweep looks for dangling references
+// (INTEGRITY_SWEEP_MINUTES, default 60)
+var integritySweepInterval = time.Duration(envPositiveInt("INTEGRITY_SWEEP_MINUTES", 60)) * time.Minute
+
+// integritySweepMu keeps the scheduled and on-demand sweeps from running at the same time
+var integritySweepMu sync.Mutex
+
+// errSweepRunning is returned when a sweep is requested while another one is in progress
+var errSweepRunning = errors.New("an integrity sweep is already running")
+
+// SweepResult counts what one integrity sweep checked and cleaned
+type SweepResult struct {
+	PeopleChecked int `json:"people_checked"`
+	PeopleCleaned int `json:"people_cleaned"` // People with a dangling reference removed
+	UsersChecked  int `json:"users_checked"`
+	UsersCleaned  int `json:"users_cleaned"` // Users whose person_id pointed at a missing person
+}
+
+// StartIntegritySweep runs SweepDanglingReferences in the background every
+// integritySweepInterval, starting one interval after the server comes up
+func StartIntegritySweep(client *firestore.Client) {
+	service := NewReferentialIntegrityService(client)
+	go func() {
+		ticker := time.NewTicker(integritySweepInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			treeIDs, err := allTreeIDs(context.Background(), client)
+			if err != nil {
+				log.Printf("[RefIntegrity] Failed to list trees: %v", err)
+				continue
+			}
+			for _, treeID := range treeIDs {
+				result, err := service.SweepDanglingReferences(database.WithTree(context.Background(), treeID))
+				if err != nil {
+					log.Printf("[RefIntegrity] Sweep of tree %q failed: %v", treeID, err)
+					continue
+				}
+				log.Printf("[RefIntegrity] Sweep of tree %q cleaned %d of %d people and %d of %d users",
+					treeID, result.PeopleCleaned, result.PeopleChecked, result.UsersCleaned, result.UsersChecked)
+			}
+		}
+	}()
+}
+
+// SweepDanglingReferences checks every person and user for references to documents that
+// no longer exist. Candidates are found from one read of each collection; each is then
+// cleaned in its own transaction by ValidatePersonReferences or ValidateUserReferences,
+// so concurrent writes are never overwritten. Returns errSweepRunning if a sweep is in progress.
+func (s *ReferentialIntegrityService) SweepDanglingReferences(ctx context.Context) (SweepResult, error) {
+	var result SweepResult
+	if !integritySweepMu.TryLock() {
+		return result, errSweepRunning
 	}
+	defer integritySweepMu.Unlock()
 
-	var updates []firestore.Update
-	changed := false
+	people, err := fetchAllPeople(ctx, s.client)
+	if err != nil {
+		return result, err
+	}
+	personIDs := make(map[string]bool, len(people))
+	for _, p := range people {
+		personIDs[p.ID] = true
+	}
+	userIDs, userDocs, err := s.fetchTreeUsers(ctx)
+	if err != nil {
+		return result, err
+	}
 
-	// Check linked_user_id
-	if linkedUserID, ok := doc.Data()["linked_user_id"].(string); ok && linkedUserID != "" {
-		userDoc, err := s.client.Collection("users").Doc(linkedUserID).Get(ctx)
-		if err != nil || !userDoc.Exists() {
-			updates = append(updates, firestore.Update{Path: "linked_user_id", Value: ""})
-			changed = true
-			log.Printf("[RefIntegrity] Cleaning dangling linked_user_id %s from person %s", linkedUserID, personID)
+	result.PeopleChecked = len(people)
+	for _, p := range people {
+		if !detectPersonIssues(p, personIDs, userIDs).any() {
+			continue
+		}
+		changed, err := s.ValidatePersonReferences(ctx, p.ID)
+		if err != nil {
+			log.Printf("[RefIntegrity] Failed to clean person %s: %v", p.ID, err)
+			continue
+		}
+		if changed {
+			result.PeopleCleaned++
 		}
 	}
 
-	// Check children array
-	if children, ok := doc.Data()["children"].([]interface{}); ok {
-		var validChildren []string
-		for _, childID := range children {
-			if cid, ok := childID.(string); ok {
-				childDoc, err := s.client.Collection("people").Doc(cid).Get(ctx)
-				if err == nil && childDoc.Exists() {
-					validChildren = append(validChildren, cid)
-				} else {
-					changed = true
-					log.Printf("[RefIntegrity] Removing dangling child %s from person %s", cid, personID)
-				}
-			}
+	result.UsersChecked = len(userDocs)
+	for _, doc := range userDocs {
+		personID, _ := doc.Data()["person_id"].(string)
+		if personID == "" || personIDs[personID] {
+			continue
+		}
+		changed, err := s.ValidateUserReferences(ctx, doc.Ref.ID)
+		if err != nil {
+			log.Printf("[RefIntegrity] Failed to clean user %s: %v", doc.Ref.ID, err)
+			continue
 		}
 		if changed {
-			updates = append(updates, firestore.Update{Path: "children", Value: validChildren})
-		}
-	}
-
-	// Check liked_by array
-	if likedBy, ok := doc.Data()["liked_by"].([]interface{}); ok {
-		var validLikedBy []string
-		removedCount := 0
-		for _, userID := range likedBy {
-			if uid, ok := userID.(string); ok {
-				userDoc, err := s.client.Collection("users").Doc(uid).Get(ctx)
-				if err == nil && userDoc.Exists() {
-					validLikedBy = append(validLikedBy, uid)
-				} else {
-					removedCount++
-					log.Printf("[RefIntegrity] Removing dangling liked_by user %s from person %s", uid, personID)
-				}
-			}
+			result.UsersCleaned++
 		}
-		if removedCount > 0 {
-			changed = true
-			updates = append(updates,
-				firestore.Update{Path: "liked_by", Value: validLikedBy},
-				firestore.Update{Path: "likes_count", Value: len(validLikedBy)},
-			)
+	}
+	return result, nil
+}
+
+// fetchTreeUsers reads every user once. It returns the IDs of all users, so links to a
+// user of another tree are not reported as dangling, and the documents of the users in the
+// tree of ctx, the only ones a sweep or report of that tree may examine.
+func (s *ReferentialIntegrityService) fetchTreeUsers(ctx context.Context) (map[string]bool, []*firestore.DocumentSnapshot, error) {
+	docs, err := s.coll(ctx, "users").Select("person_id", "tree_id").Documents(ctx).GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	userIDs := make(map[string]bool, len(docs))
+	var treeUsers []*firestore.DocumentSnapshot
+	for _, doc := range docs {
+		userIDs[doc.Ref.ID] = true
+		if userInTree(ctx, doc) {
+			treeUsers = append(treeUsers, doc)
 		}
 	}
+	return userIDs, treeUsers, nil
+}
 
-	if changed {
-		updates = append(updates, firestore.Update{Path: "updated_at", Value: time.Now()})
-		_, err = s.client.Collection("people").Doc(personID).Update(ctx, updates)
-		if err != nil {
-			return false, err
+// existingIDs reads the given documents of a collection inside tx and reports which exist
+func existingIDs(tx *firestore.Transaction, coll *firestore.CollectionRef, ids []string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(ids))
+	if len(ids) == 0 {
+		return exists, nil
+	}
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = coll.Doc(id)
+	}
+	docs, err := tx.GetAll(refs)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		if doc.Exists() {
+			exists[doc.Ref.ID] = true
 		}
 	}
+	return exists, nil
+}
 
+// missingIDs returns the ids not found in exists
+func missingIDs(ids []string, exists map[string]bool) []string {
+	var missing []string
+	for _, id := range ids {
+		if !exists[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// withoutIDs returns ids minus the ones in drop
+func withoutIDs(ids, drop []string) []string {
+	kept := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !containsString(drop, id) {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// PersonReferenceIssues lists a person's references to documents that don't exist
+type PersonReferenceIssues struct {
+	Children           []string `json:"children,omitempty"`
+	Spouses            []string `json:"spouses,omitempty"`
+	ParentIDs          []string `json:"parent_ids,omitempty"`
+	LikedBy            []string `json:"liked_by,omitempty"`
+	LinkedUserID       string   `json:"linked_user_id,omitempty"`
+	LikesCountMismatch bool     `json:"likes_count_mismatch,omitempty"` // likes_count differs from the valid liked_by entries
+}
+
+// any reports whether there is anything to fix
+func (i PersonReferenceIssues) any() bool {
+	return len(i.Children)+len(i.Spouses)+len(i.ParentIDs)+len(i.LikedBy) > 0 ||
+		i.LinkedUserID != "" || i.LikesCountMismatch
+}
+
+// detectPersonIssues finds a person's dangling references given which people and users
+// exist. It only inspects; ValidatePersonReferences applies the fixes.
+func detectPersonIssues(p models.Person, people, users map[string]bool) PersonReferenceIssues {
+	issues := PersonReferenceIssues{
+		Children:  missingIDs(p.Children, people),
+		Spouses:   missingIDs(p.Spouses, people),
+		ParentIDs: missingIDs(p.ParentIDs, people),
+		LikedBy:   missingIDs(p.LikedBy, users),
+	}
+	if p.LinkedUserID != "" && !users[p.LinkedUserID] {
+		issues.LinkedUserID = p.LinkedUserID
+	}
+	issues.LikesCountMismatch = p.LikesCount != likesCount(withoutIDs(p.LikedBy, issues.LikedBy))
+	return issues
+}
+
+// ValidatePersonReferences removes a person's references to people (children, spouses,
+// parent_ids) and users (linked_user_id, liked_by) that no longer exist and corrects likes_count. The person and
+// everything they reference are read in one transaction, so a concurrent edit makes it retry
+// instead of being overwritten. Returns true if any cleanup was performed.
+func (s *ReferentialIntegrityService) ValidatePersonReferences(ctx context.Context, personID string) (bool, error) {
+	changed := false
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		changed = false
+		ref := s.coll(ctx, "people").Doc(personID)
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			return err
+		}
+
+		personRefs := append(append(append([]string{}, person.Children...), person.Spouses...), person.ParentIDs...)
+		people, err := existingIDs(tx, s.coll(ctx, "people"), uniqueIDs(personRefs))
+		if err != nil {
+			return err
+		}
+		userRefs := uniqueIDs(person.LikedBy)
+		if person.LinkedUserID != "" {
+			userRefs = uniqueIDs(append(userRefs, person.LinkedUserID))
+		}
+		users, err := existingIDs(tx, s.coll(ctx, "users"), userRefs)
+		if err != nil {
+			return err
+		}
+
+		issues := detectPersonIssues(person, people, users)
+		var updates []firestore.Update
+		if issues.LinkedUserID != "" {
+			updates = append(updates, firestore.Update{Path: "linked_user_id", Value: ""})
+			log.Printf("[RefIntegrity] Cleaning dangling linked_user_id %s from person %s", issues.LinkedUserID, personID)
+		}
+		for _, field := range []struct {
+			path    string
+			ids     []string
+			missing []string
+		}{
+			{"children", person.Children, issues.Children},
+			{"spouses", person.Spouses, issues.Spouses},
+			{"parent_ids", person.ParentIDs, issues.ParentIDs},
+			{"liked_by", person.LikedBy, issues.LikedBy},
+		} {
+			if len(field.missing) > 0 {
+				updates = append(updates, firestore.Update{Path: field.path, Value: withoutIDs(field.ids, field.missing)})
+				log.Printf("[RefIntegrity] Removing dangling %s %v from person %s", field.path, field.missing, personID)
+			}
+		}
+		if len(issues.LikedBy) > 0 || issues.LikesCountMismatch {
+			updates = append(updates, firestore.Update{Path: "likes_count", Value: likesCount(withoutIDs(person.LikedBy, issues.LikedBy))})
+		}
+
+		if len(updates) == 0 {
+			return nil
+		}
+		changed = true
+		updates = append(updates, firestore.Update{Path: "updated_at", Value: time.Now()})
+		return tx.Update(ref, updates)
+	})
+	if err != nil {
+		return false, err
+	}
 	return changed, nil
 }
 
-// ValidateUserReferences checks if a user's references are valid
+// ValidateUserReferences clears a user's person_id when that person no longer exists,
+// reading both in one transaction. Returns true if the user was changed.
 func (s *ReferentialIntegrityService) ValidateUserReferences(ctx context.Context, userID string) (bool, error) {
-	doc, err := s.client.Collection("users").Doc(userID).Get(ctx)
+	changed := false
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		changed = false
+		ref := s.coll(ctx, "users").Doc(userID)
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		personID, _ := doc.Data()["person_id"].(string)
+		if personID == "" {
+			return nil
+		}
+		exists, err := existingIDs(tx, s.coll(ctx, "people"), []string{personID})
+		if err != nil || exists[personID] {
+			return err
+		}
+
+		changed = true
+		log.Printf("[RefIntegrity] Cleaning dangling person_id %s from user %s", personID, userID)
+		return tx.Update(ref, []firestore.Update{
+			{Path: "person_id", Value: ""},
+			{Path: "tree_name", Value: ""},
+			{Path: "updated_at", Value: time.Now()},
+		})
+	})
 	if err != nil {
 		return false, err
 	}
+	return changed, nil
+}
 
-	changed := false
+// LikeRecountResult counts what RecomputeLikeCounts checked and corrected
+type LikeRecountResult struct {
+	PeopleChecked int `json:"people_checked"`
+	PeopleFixed   int `json:"people_fixed"` // likes_count reset or duplicate liked_by entries dropped
+}
+
+// RecomputeLikeCounts sets every person's likes_count to the number of distinct users in
+// liked_by, dropping duplicate entries from the array. Candidates come from one read of the
+// collection; each fix re-reads the person in a transaction so a concurrent like isn't lost.
+func (s *ReferentialIntegrityService) RecomputeLikeCounts(ctx context.Context) (LikeRecountResult, error) {
+	var result LikeRecountResult
+	docs, err := s.coll(ctx, "people").Select("liked_by", "likes_count").Documents(ctx).GetAll()
+	if err != nil {
+		return result, err
+	}
+
+	result.PeopleChecked = len(docs)
+	for _, doc := range docs {
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			continue
+		}
+		if !likesNeedRecount(person) {
+			continue
+		}
 
-	// Check person_id
-	if personID, ok := doc.Data()["person_id"].(string); ok && personID != "" {
-		personDoc, err := s.client.Collection("people").Doc(personID).Get(ctx)
-		if err != nil || !personDoc.Exists() {
-			_, err = s.client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
-				{Path: "person_id", Value: ""},
-				{Path: "tree_name", Value: ""},
-				{Path: "updated_at", Value: time.Now()},
-			})
-			if err == nil {
-				changed = true
-				log.Printf("[RefIntegrity] Cleaned dangling person_id %s from user %s", personID, userID)
+		changed := false
+		err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			changed = false
+			snap, err := tx.Get(doc.Ref)
+			if err != nil {
+				return err
 			}
+			var current models.Person
+			if err := snap.DataTo(&current); err != nil {
+				return err
+			}
+			if !likesNeedRecount(current) {
+				return nil
+			}
+			changed = true
+			return tx.Update(doc.Ref, likeUpdates(uniqueIDs(current.LikedBy), time.Now()))
+		})
+		if err != nil {
+			log.Printf("[RefIntegrity] Failed to recount likes for person %s: %v", doc.Ref.ID, err)
+			continue
+		}
+		if changed {
+			result.PeopleFixed++
+			log.Printf("[RefIntegrity] Corrected likes_count for person %s", doc.Ref.ID)
 		}
 	}
+	return result, nil
+}
 
-	return changed, nil
+// likesNeedRecount reports whether a person's likes_count disagrees with liked_by or
+// liked_by holds duplicates
+func likesNeedRecount(p models.Person) bool {
+	distinct := likesCount(p.LikedBy)
+	return p.LikesCount != distinct || len(p.LikedBy) != distinct
+}
+
+// RelationshipIssue describes one inconsistent children entry
+type RelationshipIssue struct {
+	PersonID string `json:"person_id"` // Owner of the children array
+	ChildID  string `json:"child_id"`
+	Issue    string `json:"issue"` // "dangling", "self_reference" or "duplicate"
+}
+
+// MultiParentChild is a person listed in more than one parent's children array
+type MultiParentChild struct {
+	ChildID   string   `json:"child_id"`
+	ParentIDs []string `json:"parent_ids"`
+}
+
+// RelationshipReport is the result of RepairBidirectional
+type RelationshipReport struct {
+	CheckedCount int                 `json:"checked_count"`
+	Issues       []RelationshipIssue `json:"issues"`
+	MultiParent  []MultiParentChild  `json:"multi_parent"`  // Reported only; may be intentional (two parents)
+	StaleParents []string            `json:"stale_parents"` // People whose parent_ids disagree with the children arrays
+	FixedCount   int                 `json:"fixed_count"`   // People whose children array or parent_ids was rewritten
+}
+
+// RepairBidirectional scans every children array for ids that don't exist, point back at
+// the owner or repeat, flags children listed under several parents and compares each
+// person's parent_ids with the children arrays that list them. With apply, the broken
+// entries are removed and parent_ids rebuilt; multi-parent cases are never changed automatically.
+func (s *ReferentialIntegrityService) RepairBidirectional(ctx context.Context, apply bool) (*RelationshipReport, error) {
+	people, err := fetchAllPeople(ctx, s.client)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[string]bool, len(people))
+	for _, p := range people {
+		exists[p.ID] = true
+	}
+
+	report := &RelationshipReport{
+		CheckedCount: len(people),
+		Issues:       []RelationshipIssue{},
+		MultiParent:  []MultiParentChild{},
+		StaleParents: []string{},
+	}
+	parentsOf := make(map[string][]string)
+	repaired := make(map[string][]string) // personID -> cleaned children
+	var order []string
+
+	for _, p := range people {
+		seen := make(map[string]bool, len(p.Children))
+		clean := make([]string, 0, len(p.Children))
+		for _, childID := range p.Children {
+			issue := ""
+			switch {
+			case childID == p.ID:
+				issue = "self_reference"
+			case !exists[childID]:
+				issue = "dangling"
+			case seen[childID]:
+				issue = "duplicate"
+			}
+			if issue != "" {
+				report.Issues = append(report.Issues, RelationshipIssue{PersonID: p.ID, ChildID: childID, Issue: issue})
+				continue
+			}
+			seen[childID] = true
+			clean = append(clean, childID)
+			parentsOf[childID] = append(parentsOf[childID], p.ID)
+		}
+		if len(clean) != len(p.Children) {
+			repaired[p.ID] = clean
+			order = append(order, p.ID)
+		}
+	}
+
+	staleParents := make(map[string]bool)
+	for _, p := range people {
+		parents := parentsOf[p.ID]
+		if len(parents) > 1 {
+			report.MultiParent = append(report.MultiParent, MultiParentChild{ChildID: p.ID, ParentIDs: parents})
+		}
+		if !sameIDSet(p.ParentIDs, parents) {
+			report.StaleParents = append(report.StaleParents, p.ID)
+			staleParents[p.ID] = true
+			if _, ok := repaired[p.ID]; !ok {
+				order = append(order, p.ID)
+			}
+		}
+	}
+
+	if !apply || len(order) == 0 {
+		return report, nil
+	}
+
+	now := time.Now()
+	batch := s.client.Batch()
+	pending := 0
+	for _, id := range order {
+		updates := []firestore.Update{{Path: "updated_at", Value: now}}
+		if children, ok := repaired[id]; ok {
+			updates = append(updates, firestore.Update{Path: "children", Value: children})
+		}
+		if staleParents[id] {
+			parents := parentsOf[id]
+			if parents == nil {
+				parents = []string{}
+			}
+			updates = append(updates, firestore.Update{Path: "parent_ids", Value: parents})
+		}
+		batch.Update(s.coll(ctx, "people").Doc(id), updates)
+		pending++
+
+		// Firestore batch limit is 500
+		if pending == 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return report, err
+			}
+			report.FixedCount += pending
+			pending = 0
+			batch = s.client.Batch()
+		}
+	}
+	if pending > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			return report, err
+		}
+		report.FixedCount += pending
+	}
+	log.Printf("[RefIntegrity] Repaired children arrays or parent_ids of %d people", report.FixedCount)
+	return report, nil
+}
+
+// sameIDSet reports whether a and b hold the same ids, ignoring order and repeats
+func sameIDSet(a, b []string) bool {
+	a, b = uniqueIDs(a), uniqueIDs(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for _, id := range a {
+		if !containsString(b, id) {
+			return false
+		}
+	}
+	return true
 }
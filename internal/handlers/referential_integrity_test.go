@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+func TestDeletingOneParentKeepsTheOther(t *testing.T) {
+	mother := models.Person{ID: "mother", Children: []string{"child"}}
+	child := models.Person{ID: "child", ParentIDs: []string{"mother", "father"}}
+
+	// The father is gone; the mother and child remain
+	people := map[string]bool{"mother": true, "child": true}
+
+	issues := detectPersonIssues(child, people, nil)
+	if !reflect.DeepEqual(issues.ParentIDs, []string{"father"}) {
+		t.Fatalf("dangling parent_ids = %v, want [father]", issues.ParentIDs)
+	}
+	child.ParentIDs = withoutIDs(child.ParentIDs, issues.ParentIDs)
+	if !reflect.DeepEqual(child.ParentIDs, []string{"mother"}) {
+		t.Fatalf("parent_ids after cleanup = %v, want [mother]", child.ParentIDs)
+	}
+
+	if issues := detectPersonIssues(mother, people, nil); issues.any() {
+		t.Errorf("mother flagged after father's deletion: %+v", issues)
+	}
+
+	parentsOf := func(id string) ([]string, error) {
+		if id == child.ID {
+			return child.ParentIDs, nil
+		}
+		return nil, nil
+	}
+	ancestors, err := collectAncestorIDs(child.ID, parentsOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"mother": true}; !reflect.DeepEqual(ancestors, want) {
+		t.Errorf("ancestors of child = %v, want %v", ancestors, want)
+	}
+}
+
+// updateValue returns the value updates writes to path
+func updateValue(t *testing.T, updates []firestore.Update, path string) interface{} {
+	t.Helper()
+	for _, u := range updates {
+		if u.Path == path {
+			return u.Value
+		}
+	}
+	t.Fatalf("no update for %s", path)
+	return nil
+}
+
+func TestDeletedUserStrippedFromLikedBy(t *testing.T) {
+	person := models.Person{ID: "p1", LikedBy: []string{"u1", "gone", "u2", "gone"}, LikesCount: 4}
+
+	updates := likeUpdates(withoutIDs(person.LikedBy, []string{"gone"}), time.Now())
+	likedBy := updateValue(t, updates, "liked_by").([]string)
+	if !reflect.DeepEqual(likedBy, []string{"u1", "u2"}) {
+		t.Errorf("liked_by = %v, want [u1 u2]", likedBy)
+	}
+	if n := updateValue(t, updates, "likes_count"); n != 2 {
+		t.Errorf("likes_count = %v, want 2", n)
+	}
+
+	// A deletion the hook missed is caught by the sweep
+	issues := detectPersonIssues(person, nil, map[string]bool{"u1": true, "u2": true})
+	if !reflect.DeepEqual(issues.LikedBy, []string{"gone", "gone"}) || !issues.LikesCountMismatch {
+		t.Errorf("sweep issues = %+v, want the deleted user in liked_by and a count mismatch", issues)
+	}
+}
+
+func TestCorruptLikesCountIsCorrected(t *testing.T) {
+	tests := []struct {
+		name    string
+		person  models.Person
+		recount bool
+		want    []string
+	}{
+		{"consistent", models.Person{LikedBy: []string{"u1", "u2"}, LikesCount: 2}, false, nil},
+		{"no likes", models.Person{}, false, nil},
+		{"count too high", models.Person{LikedBy: []string{"u1"}, LikesCount: 7}, true, []string{"u1"}},
+		{"negative count", models.Person{LikesCount: -3}, true, []string{}},
+		{"duplicate likers", models.Person{LikedBy: []string{"u1", "u1", "u2"}, LikesCount: 2}, true, []string{"u1", "u2"}},
+	}
+	for _, tt := range tests {
+		if got := likesNeedRecount(tt.person); got != tt.recount {
+			t.Errorf("%s: likesNeedRecount = %v, want %v", tt.name, got, tt.recount)
+			continue
+		}
+		if !tt.recount {
+			continue
+		}
+
+		updates := likeUpdates(uniqueIDs(tt.person.LikedBy), time.Now())
+		fixed := tt.person
+		fixed.LikedBy = updateValue(t, updates, "liked_by").([]string)
+		fixed.LikesCount = updateValue(t, updates, "likes_count").(int)
+		if !reflect.DeepEqual(fixed.LikedBy, tt.want) || fixed.LikesCount != len(tt.want) {
+			t.Errorf("%s: fixed to liked_by=%v likes_count=%d, want %v", tt.name, fixed.LikedBy, fixed.LikesCount, tt.want)
+		}
+		if likesNeedRecount(fixed) {
+			t.Errorf("%s: still needs a recount after the fix", tt.name)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+	"google.golang.org/api/iterator"
+)
+
+// refreshTokenTTL is how long a refresh token is valid (REFRESH_TOKEN_DAYS, default 30)
+var refreshTokenTTL = time.Duration(envPositiveInt("REFRESH_TOKEN_DAYS", 30)) * 24 * time.Hour
+
+// Refresh failures; errRefreshTokenReused means an already rotated token came back,
+// so whoever holds the newer one may have stolen it
+var (
+	errInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	errRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// newRefreshTokenRecord is the stored record of a refresh token issued at now. An empty
+// familyID starts a new family.
+func newRefreshTokenRecord(userID, familyID string, now time.Time) models.RefreshToken {
+	if familyID == "" {
+		familyID = uuid.New().String()
+	}
+	return models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		ExpiresAt: now.Add(refreshTokenTTL),
+		CreatedAt: now,
+	}
+}
+
+// validateRefreshToken checks that a refresh token can be exchanged at now.
+// A rotated token reports errRefreshTokenReused; revoked or expired ones errInvalidRefreshToken.
+func validateRefreshToken(record models.RefreshToken, now time.Time) error {
+	if !record.RevokedAt.IsZero() {
+		if record.ReplacedBy != "" {
+			return errRefreshTokenReused
+		}
+		return errInvalidRefreshToken
+	}
+	if now.After(record.ExpiresAt) {
+		return errInvalidRefreshToken
+	}
+	return nil
+}
+
+// rotateRefreshToken returns record revoked at now and pointing at its replacement
+func rotateRefreshToken(record models.RefreshToken, newToken string, now time.Time) models.RefreshToken {
+	record.RevokedAt = now
+	record.ReplacedBy = hashToken(newToken)
+	return record
+}
+
+// issueRefreshToken creates a refresh token for the user and returns it. An empty familyID
+// starts a new family (a fresh login). With tx the document is created in that transaction.
+func (h *FirestoreAuthHandler) issueRefreshToken(ctx context.Context, tx *firestore.Transaction, userID, familyID string) (string, error) {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+	record := newRefreshTokenRecord(userID, familyID, time.Now())
+	ref := h.coll(ctx, "refresh_tokens").Doc(hashToken(token))
+	if tx != nil {
+		err = tx.Create(ref, record)
+	} else {
+		_, err = ref.Create(ctx, record)
+	}
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// revokeRefreshToken returns record revoked at now; false if it already was revoked
+func revokeRefreshToken(record models.RefreshToken, now time.Time) (models.RefreshToken, bool) {
+	if !record.RevokedAt.IsZero() {
+		return record, false
+	}
+	record.RevokedAt = now
+	return record, true
+}
+
+// revokeRefreshTokens revokes every unrevoked refresh token matching field == value
+// (a family_id after reuse, a user_id after a password change)
+func revokeRefreshTokens(ctx context.Context, client *firestore.Client, field, value string) error {
+	iter := database.Collection(client, "refresh_tokens").Where(field, "==", value).Documents(ctx)
+	defer iter.Stop()
+
+	now := time.Now()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var record models.RefreshToken
+		if err := doc.DataTo(&record); err != nil {
+			continue
+		}
+		revoked, changed := revokeRefreshToken(record, now)
+		if !changed {
+			continue
+		}
+		if _, err := doc.Ref.Update(ctx, []firestore.Update{{Path: "revoked_at", Value: revoked.RevokedAt}}); err != nil {
+			return err
+		}
+	}
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a new refresh token;
+// the presented one stops working. Presenting a token that was already rotated revokes
+// every token descending from the same login, forcing a fresh login.
+func (h *FirestoreAuthHandler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	ctx := treeContext(c)
+	ref := h.coll(ctx, "refresh_tokens").Doc(hashToken(req.RefreshToken))
+	var user models.User
+	var record models.RefreshToken
+	var newToken string
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return errInvalidRefreshToken
+		}
+		if err := doc.DataTo(&record); err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := validateRefreshToken(record, now); err != nil {
+			return err
+		}
+
+		userDoc, err := tx.Get(h.coll(ctx, "users").Doc(record.UserID))
+		if err != nil {
+			return errInvalidRefreshToken
+		}
+		if err := userDoc.DataTo(&user); err != nil {
+			return err
+		}
+		user.ID = userDoc.Ref.ID
+
+		newToken, err = h.issueRefreshToken(ctx, tx, user.ID, record.FamilyID)
+		if err != nil {
+			return err
+		}
+		rotated := rotateRefreshToken(record, newToken, now)
+		return tx.Update(ref, []firestore.Update{
+			{Path: "revoked_at", Value: rotated.RevokedAt},
+			{Path: "replaced_by", Value: rotated.ReplacedBy},
+		})
+	})
+	if errors.Is(err, errRefreshTokenReused) {
+		log.Printf("[Auth] Refresh token reuse for user %s, revoking family %s", record.UserID, record.FamilyID)
+		if err := revokeRefreshTokens(ctx, h.client, "family_id", record.FamilyID); err != nil {
+			log.Printf("[Auth] Failed to revoke refresh token family %s: %v", record.FamilyID, err)
+		}
+		recordAudit(ctx, h.client, c, "refresh_token_reuse", record.UserID, map[string]interface{}{
+			"family_id": record.FamilyID,
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if errors.Is(err, errInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		log.Printf("[Auth] Failed to refresh token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	token, err := h.generateToken(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":         token,
+		"refresh_token": newToken,
+		"expires_in":    int(h.auth.TokenTTL.Seconds()),
+	})
+}
+
+// Logout revokes the presented refresh token. Unknown tokens are ignored so logging out
+// twice is harmless; the access token stays valid until it expires.
+func (h *FirestoreAuthHandler) Logout(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	ctx := treeContext(c)
+	ref := h.coll(ctx, "refresh_tokens").Doc(hashToken(req.RefreshToken))
+	if doc, err := ref.Get(ctx); err == nil {
+		var record models.RefreshToken
+		if err := doc.DataTo(&record); err == nil && record.RevokedAt.IsZero() {
+			if _, err := ref.Update(ctx, []firestore.Update{{Path: "revoked_at", Value: time.Now()}}); err != nil {
+				log.Printf("[Auth] Failed to revoke refresh token for %s: %v", record.UserID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// refreshTokenStore mirrors the refresh_tokens collection: token hash -> record
+type refreshTokenStore map[string]models.RefreshToken
+
+// rotate exchanges token for next the way RefreshToken does inside its transaction
+func (s refreshTokenStore) rotate(t *testing.T, token, next string, now time.Time) error {
+	t.Helper()
+	record, ok := s[hashToken(token)]
+	if !ok {
+		return errInvalidRefreshToken
+	}
+	if err := validateRefreshToken(record, now); err != nil {
+		return err
+	}
+	s[hashToken(next)] = newRefreshTokenRecord(record.UserID, record.FamilyID, now)
+	s[hashToken(token)] = rotateRefreshToken(record, next, now)
+	return nil
+}
+
+// revokeFamily revokes every token of a family the way revokeRefreshTokens does
+func (s refreshTokenStore) revokeFamily(familyID string, now time.Time) int {
+	revoked := 0
+	for hash, record := range s {
+		if record.FamilyID != familyID {
+			continue
+		}
+		if updated, changed := revokeRefreshToken(record, now); changed {
+			s[hash] = updated
+			revoked++
+		}
+	}
+	return revoked
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := refreshTokenStore{hashToken("a"): newRefreshTokenRecord("u1", "", now)}
+	family := store[hashToken("a")].FamilyID
+	if family == "" {
+		t.Fatal("a fresh login should start a new family")
+	}
+
+	if err := store.rotate(t, "a", "b", now.Add(time.Minute)); err != nil {
+		t.Fatalf("first rotation failed: %v", err)
+	}
+	if got := store[hashToken("b")]; got.FamilyID != family || got.UserID != "u1" {
+		t.Errorf("rotated token = %+v, want user u1 in family %s", got, family)
+	}
+	if got := store[hashToken("a")].ReplacedBy; got != hashToken("b") {
+		t.Errorf("old token replaced_by = %q, want the new token's hash", got)
+	}
+
+	if err := store.rotate(t, "b", "c", now.Add(2*time.Minute)); err != nil {
+		t.Fatalf("second rotation failed: %v", err)
+	}
+	if err := store.rotate(t, "unknown", "x", now); !errors.Is(err, errInvalidRefreshToken) {
+		t.Errorf("unknown token: got %v, want errInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := refreshTokenStore{
+		hashToken("a"):     newRefreshTokenRecord("u1", "", now),
+		hashToken("other"): newRefreshTokenRecord("u1", "", now), // Another login of the same user
+	}
+	family := store[hashToken("a")].FamilyID
+	if err := store.rotate(t, "a", "b", now); err != nil {
+		t.Fatalf("rotation failed: %v", err)
+	}
+
+	// The rotated token comes back: reuse
+	err := store.rotate(t, "a", "stolen", now.Add(time.Minute))
+	if !errors.Is(err, errRefreshTokenReused) {
+		t.Fatalf("reused token: got %v, want errRefreshTokenReused", err)
+	}
+	if revoked := store.revokeFamily(family, now.Add(time.Minute)); revoked != 1 {
+		t.Errorf("revoked %d tokens, want only the live one in the family", revoked)
+	}
+
+	if err := store.rotate(t, "b", "c", now.Add(2*time.Minute)); !errors.Is(err, errInvalidRefreshToken) {
+		t.Errorf("newest token after family revocation: got %v, want errInvalidRefreshToken", err)
+	}
+	if err := store.rotate(t, "other", "d", now.Add(2*time.Minute)); err != nil {
+		t.Errorf("other family should be unaffected, got %v", err)
+	}
+}
+
+func TestValidateRefreshTokenExpired(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	record := newRefreshTokenRecord("u1", "f1", now.Add(-refreshTokenTTL-time.Second))
+	if err := validateRefreshToken(record, now); !errors.Is(err, errInvalidRefreshToken) {
+		t.Errorf("expired token: got %v, want errInvalidRefreshToken", err)
+	}
+}
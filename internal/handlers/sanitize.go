@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// sanitizeFields runs utils.SanitizeText over each non-nil field in place.
+// In reject mode it writes a 400 and returns false when any field contains markup.
+func sanitizeFields(c *gin.Context, fields ...*string) bool {
+	for _, field := range fields {
+		if field == nil {
+			continue
+		}
+		clean, err := utils.SanitizeText(*field)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "HTML is not allowed in text fields"})
+			return false
+		}
+		*field = clean
+	}
+	return true
+}
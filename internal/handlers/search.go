@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,18 +8,25 @@ import (
 	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
 	"google.golang.org/api/iterator"
 )
 
 // SearchRequest represents search parameters
+// Year filters are mutually exclusive, applied with this precedence:
+// birth_year (exact) > decade (e.g. 1980 = 1980-1989) > year_from/year_to (range)
 type SearchRequest struct {
-	Query    string `form:"q"`
-	Location string `form:"location"`
-	Role     string `form:"role"`
-	YearFrom string `form:"year_from"`
-	YearTo   string `form:"year_to"`
-	Page     int    `form:"page"`
-	PageSize int    `form:"page_size"`
+	Query             string `form:"q"`
+	Location          string `form:"location"`
+	Role              string `form:"role"`
+	IncludeOccupation bool   `form:"include_occupation"` // Role filter also matches occupation
+	BirthYear         string `form:"birth_year"`
+	Decade            string `form:"decade"`
+	YearFrom          string `form:"year_from"`
+	YearTo            string `form:"year_to"`
+	SpouseOf          string `form:"spouse_of"` // Only people recorded as this person's spouse
+	Page              int    `form:"page"`
+	PageSize          int    `form:"page_size"`
 }
 
 // SearchResponse represents paginated search results
@@ -50,19 +56,27 @@ func (h *FirestoreSearchHandler) SearchPeople(c *gin.Context) {
 		return
 	}
 
-	// Default pagination
-	if req.Page < 1 {
-		req.Page = 1
+	if req.BirthYear != "" {
+		if _, err := strconv.Atoi(req.BirthYear); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "birth_year must be a number"})
+			return
+		}
 	}
-	if req.PageSize < 1 || req.PageSize > 100 {
-		req.PageSize = 50
+	if req.Decade != "" {
+		decade, err := strconv.Atoi(req.Decade)
+		if err != nil || decade%10 != 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "decade must be a year ending in 0 (e.g. 1980)"})
+			return
+		}
 	}
 
-	ctx := context.Background()
+	req.Page, req.PageSize = parsePagination(c)
+
+	ctx := treeContext(c)
 
 	// Fetch all people (Firestore doesn't support complex text search natively)
 	// For production, consider using Algolia or Elasticsearch
-	iter := h.client.Collection("people").Documents(ctx)
+	iter := h.coll(ctx, "people").Documents(ctx)
 	defer iter.Stop()
 
 	var allPeople []models.Person
@@ -94,22 +108,11 @@ func (h *FirestoreSearchHandler) SearchPeople(c *gin.Context) {
 
 	// Calculate pagination
 	total := len(filtered)
-	totalPages := (total + req.PageSize - 1) / req.PageSize
-	start := (req.Page - 1) * req.PageSize
-	end := start + req.PageSize
+	start, end, totalPages := pageBounds(total, req.Page, req.PageSize)
 
-	if start > total {
-		start = total
-	}
-	if end > total {
-		end = total
-	}
-
-	var paged []models.Person
+	paged := []models.Person{}
 	if start < total {
 		paged = filtered[start:end]
-	} else {
-		paged = []models.Person{}
 	}
 
 	c.JSON(http.StatusOK, SearchResponse{
@@ -123,13 +126,20 @@ func (h *FirestoreSearchHandler) SearchPeople(c *gin.Context) {
 
 // matchesFilters checks if a person matches all search filters
 func (h *FirestoreSearchHandler) matchesFilters(person models.Person, req SearchRequest) bool {
-	// Text search (name, role, location, bio)
+	// Text search (name, alternate names, role, occupation, location, bio)
 	if req.Query != "" {
 		query := strings.ToLower(req.Query)
 		nameMatch := strings.Contains(strings.ToLower(person.Name), query)
-		roleMatch := strings.Contains(strings.ToLower(person.Role), query)
+		roleMatch := strings.Contains(strings.ToLower(person.Role), query) ||
+			strings.Contains(strings.ToLower(person.Occupation), query)
 		locationMatch := strings.Contains(strings.ToLower(person.Location), query)
 		bioMatch := strings.Contains(strings.ToLower(person.Bio), query)
+		for _, alt := range person.AltNames {
+			if nameMatch {
+				break
+			}
+			nameMatch = strings.Contains(strings.ToLower(alt), query)
+		}
 
 		if !nameMatch && !roleMatch && !locationMatch && !bioMatch {
 			return false
@@ -145,18 +155,38 @@ func (h *FirestoreSearchHandler) matchesFilters(person models.Person, req Search
 
 	// Role filter
 	if req.Role != "" {
-		if !strings.Contains(strings.ToLower(person.Role), strings.ToLower(req.Role)) {
+		role := strings.ToLower(req.Role)
+		match := strings.Contains(strings.ToLower(person.Role), role)
+		if !match && req.IncludeOccupation {
+			match = strings.Contains(strings.ToLower(person.Occupation), role)
+		}
+		if !match {
 			return false
 		}
 	}
 
-	// Year range filter
-	if req.YearFrom != "" || req.YearTo != "" {
-		birthYear, err := strconv.Atoi(person.Birth)
-		if err != nil {
+	// Spouse filter
+	if req.SpouseOf != "" && !containsString(person.Spouses, req.SpouseOf) {
+		return false
+	}
+
+	// Year filters (see SearchRequest for precedence)
+	if req.BirthYear != "" || req.Decade != "" || req.YearFrom != "" || req.YearTo != "" {
+		birthYear, ok := utils.ParseBirthYear(person.Birth)
+		if !ok {
 			return false // Can't parse birth year, exclude from filtered results
 		}
 
+		if req.BirthYear != "" {
+			exact, _ := strconv.Atoi(req.BirthYear)
+			return birthYear == exact
+		}
+
+		if req.Decade != "" {
+			decade, _ := strconv.Atoi(req.Decade)
+			return birthYear >= decade && birthYear <= decade+9
+		}
+
 		if req.YearFrom != "" {
 			yearFrom, err := strconv.Atoi(req.YearFrom)
 			if err == nil && birthYear < yearFrom {
@@ -175,11 +205,49 @@ func (h *FirestoreSearchHandler) matchesFilters(person models.Person, req Search
 	return true
 }
 
+// SearchByInstagram returns the people whose Instagram handle matches ?username=,
+// case-insensitively and ignoring a leading @
+func (h *FirestoreSearchHandler) SearchByInstagram(c *gin.Context) {
+	username := strings.TrimPrefix(strings.TrimSpace(c.Query("username")), "@")
+	if username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username is required"})
+		return
+	}
+
+	ctx := treeContext(c)
+
+	// Handles are stored as entered, so compare case-insensitively in code
+	iter := h.coll(ctx, "people").Where("instagram_username", "!=", "").Documents(ctx)
+	defer iter.Stop()
+
+	people := []models.Person{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search people"})
+			return
+		}
+
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			continue
+		}
+		if strings.EqualFold(strings.TrimPrefix(person.InstagramUsername, "@"), username) {
+			people = append(people, person)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": people, "total": len(people)})
+}
+
 // GetLocations returns all unique locations for filter dropdown
 func (h *FirestoreSearchHandler) GetLocations(c *gin.Context) {
-	ctx := context.Background()
+	ctx := treeContext(c)
 
-	iter := h.client.Collection("people").Documents(ctx)
+	iter := h.coll(ctx, "people").Documents(ctx)
 	defer iter.Stop()
 
 	locationSet := make(map[string]bool)
@@ -212,9 +280,9 @@ func (h *FirestoreSearchHandler) GetLocations(c *gin.Context) {
 
 // GetRoles returns all unique roles for filter dropdown
 func (h *FirestoreSearchHandler) GetRoles(c *gin.Context) {
-	ctx := context.Background()
+	ctx := treeContext(c)
 
-	iter := h.client.Collection("people").Documents(ctx)
+	iter := h.coll(ctx, "people").Documents(ctx)
 	defer iter.Stop()
 
 	roleSet := make(map[string]bool)
@@ -244,3 +312,26 @@ func (h *FirestoreSearchHandler) GetRoles(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"roles": roles})
 }
+
+// PreviewNameNormalization shows how the duplicate matcher sees ?name=, for debugging matches
+func (h *FirestoreSearchHandler) PreviewNameNormalization(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	parts := utils.ExtractNameParts(name)
+	if parts == nil {
+		parts = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":                   name,
+		"normalized":             utils.NormalizePersianName(name),
+		"normalized_with_spaces": utils.NormalizePersianNameKeepSpaces(name),
+		"phonetic_hash":          utils.PersianPhoneticHash(name),
+		"parts":                  parts,
+		"contains_persian":       utils.ContainsPersianCharacters(name),
+	})
+}
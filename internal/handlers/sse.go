@@ -11,16 +11,24 @@ import (
 
 	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
 	"github.com/mamiri/findyourroot/internal/utils"
 )
 
 // SSEHandler handles Server-Sent Events for real-time updates
 type SSEHandler struct {
 	client       *firestore.Client
-	adminClients map[string]chan SSEMessage
+	adminClients map[string]sseClient
+	watching     map[string]bool // Trees whose collections are being watched
 	mu           sync.RWMutex
 }
 
+// sseClient is one connected admin and the tree whose changes they receive
+type sseClient struct {
+	treeID string
+	ch     chan SSEMessage
+}
+
 // SSEMessage represents a message to be sent via SSE
 type SSEMessage struct {
 	Event string      `json:"event"`
@@ -31,11 +39,12 @@ type SSEMessage struct {
 func NewSSEHandler(client *firestore.Client) *SSEHandler {
 	handler := &SSEHandler{
 		client:       client,
-		adminClients: make(map[string]chan SSEMessage),
+		adminClients: make(map[string]sseClient),
+		watching:     make(map[string]bool),
 	}
 
-	// Start watching collections
-	go handler.watchCollections()
+	// The original tree is watched from the start; other trees once an admin connects
+	handler.watchCollections("")
 
 	return handler
 }
@@ -87,8 +96,9 @@ func (h *SSEHandler) AdminStream(c *gin.Context) {
 
 	// Register client
 	h.mu.Lock()
-	h.adminClients[clientID] = messageChan
+	h.adminClients[clientID] = sseClient{treeID: claims.TreeID, ch: messageChan}
 	h.mu.Unlock()
+	h.watchCollections(claims.TreeID)
 
 	log.Printf("[SSE] Admin client connected: %s (role: %s)", clientID, role)
 
@@ -100,7 +110,7 @@ func (h *SSEHandler) AdminStream(c *gin.Context) {
 	c.Writer.Flush()
 
 	// Send initial data
-	h.sendInitialAdminData(c, messageChan)
+	h.sendInitialAdminData(database.WithTree(context.Background(), claims.TreeID), c)
 
 	// Create context that cancels when client disconnects
 	ctx := c.Request.Context()
@@ -135,9 +145,7 @@ func (h *SSEHandler) AdminStream(c *gin.Context) {
 }
 
 // sendInitialAdminData sends the current state of all admin collections
-func (h *SSEHandler) sendInitialAdminData(c *gin.Context, messageChan chan SSEMessage) {
-	ctx := context.Background()
-
+func (h *SSEHandler) sendInitialAdminData(ctx context.Context, c *gin.Context) {
 	// Fetch and send suggestions
 	suggestions, err := h.fetchCollection(ctx, "suggestions", "pending")
 	if err == nil {
@@ -169,9 +177,9 @@ func (h *SSEHandler) fetchCollection(ctx context.Context, collectionName, status
 	var err error
 
 	if status != "" {
-		docs, err = h.client.Collection(collectionName).Where("status", "==", status).Documents(ctx).GetAll()
+		docs, err = h.coll(ctx, collectionName).Where("status", "==", status).Documents(ctx).GetAll()
 	} else {
-		docs, err = h.client.Collection(collectionName).Documents(ctx).GetAll()
+		docs, err = h.coll(ctx, collectionName).Documents(ctx).GetAll()
 	}
 
 	if err != nil {
@@ -188,9 +196,16 @@ func (h *SSEHandler) fetchCollection(ctx context.Context, collectionName, status
 	return results, nil
 }
 
-// watchCollections starts Firestore snapshot listeners for admin collections
-func (h *SSEHandler) watchCollections() {
-	ctx := context.Background()
+// watchCollections starts Firestore snapshot listeners for a tree's admin collections,
+// unless they are already running
+func (h *SSEHandler) watchCollections(treeID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.watching[treeID] {
+		return
+	}
+	h.watching[treeID] = true
+	ctx := database.WithTree(context.Background(), treeID)
 
 	// Watch suggestions collection
 	go h.watchCollection(ctx, "suggestions")
@@ -201,13 +216,13 @@ func (h *SSEHandler) watchCollections() {
 	// Watch identity_claims collection
 	go h.watchCollection(ctx, "identity_claims")
 
-	log.Println("[SSE] Started watching Firestore collections")
+	log.Printf("[SSE] Started watching Firestore collections of tree %q", treeID)
 }
 
 // watchCollection watches a single collection for changes
 func (h *SSEHandler) watchCollection(ctx context.Context, collectionName string) {
 	// Watch for pending items
-	snapIter := h.client.Collection(collectionName).
+	snapIter := h.coll(ctx, collectionName).
 		Where("status", "==", "pending").
 		Snapshots(ctx)
 
@@ -253,19 +268,22 @@ func (h *SSEHandler) watchCollection(ctx context.Context, collectionName string)
 			log.Printf("[SSE] %s change in %s: %s", eventType, collectionName, change.Doc.Ref.ID)
 
 			// Broadcast to all admin clients
-			h.broadcastToAdmins(message)
+			h.broadcastToAdmins(database.TreeID(ctx), message)
 		}
 	}
 }
 
-// broadcastToAdmins sends a message to all connected admin clients
-func (h *SSEHandler) broadcastToAdmins(msg SSEMessage) {
+// broadcastToAdmins sends a message to the connected admin clients of a tree
+func (h *SSEHandler) broadcastToAdmins(treeID string, msg SSEMessage) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	for clientID, ch := range h.adminClients {
+	for clientID, client := range h.adminClients {
+		if client.treeID != treeID {
+			continue
+		}
 		select {
-		case ch <- msg:
+		case client.ch <- msg:
 			// Message sent
 		default:
 			// Channel full, skip this message
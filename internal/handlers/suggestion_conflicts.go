@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportSuggestionConflicts exports the conflicting suggestion groups as CSV so reviewers
+// can triage them offline. Uses the same grouping and conflict detection as the grouped view.
+// ?status= filters the suggestions (default pending).
+func (h *FirestoreSuggestionHandler) ExportSuggestionConflicts(c *gin.Context) {
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Must be 'csv'"})
+		return
+	}
+	status := c.DefaultQuery("status", "pending")
+
+	groups, _, err := h.fetchGroupedSuggestions(treeContext(c), status)
+	if err != nil {
+		log.Printf("[ExportSuggestionConflicts] Error fetching suggestions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"Group ID", "Type", "Person ID", "Person Name", "Conflict Type", "Conflicts With", "Suggestions", "Submitters"}
+	if err := writer.Write(header); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write CSV header"})
+		return
+	}
+
+	for _, g := range groups {
+		if !g.HasConflicts {
+			continue
+		}
+		personName := ""
+		if g.TargetPerson != nil {
+			personName = g.TargetPerson.Name
+		} else if g.PersonData != nil {
+			personName = g.PersonData.Name
+		}
+		row := []string{
+			g.GroupID,
+			string(g.Type),
+			g.TargetPersonID,
+			personName,
+			g.ConflictType,
+			strings.Join(g.ConflictsWith, "; "),
+			strconv.Itoa(g.Count),
+			strconv.Itoa(len(uniqueIDs(g.UserEmails))),
+		}
+		if err := writer.Write(row); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write CSV row"})
+			return
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate CSV"})
+		return
+	}
+
+	filename := fmt.Sprintf("suggestion-conflicts-%s.csv", time.Now().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// maxChildrenSoftCap is the number of children above which writes succeed with a warning
+// (MAX_CHILDREN_PER_PERSON, default 30; 0 disables the warning)
+var maxChildrenSoftCap = func() int {
+	if v := os.Getenv("MAX_CHILDREN_PER_PERSON"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return 30
+}()
+
+// ChildrenError describes why a children list was rejected
+type ChildrenError struct {
+	Message    string
+	InvalidIDs []string
+}
+
+func (e *ChildrenError) Error() string {
+	if len(e.InvalidIDs) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Message, strings.Join(e.InvalidIDs, ", "))
+}
+
+// validateChildren rejects a children list containing the person itself or duplicate ids,
+// and, when checkExist is set, ids that don't reference an existing person or that are
+// already the person's ancestors (which would create a cycle).
+// A non-empty warning is returned when the list exceeds the soft cap.
+func validateChildren(ctx context.Context, client *firestore.Client, personID string, children []string, checkExist bool) (string, error) {
+	seen := make(map[string]bool, len(children))
+	var duplicates []string
+	for _, childID := range children {
+		if !validDocID(childID) {
+			return "", &ChildrenError{Message: "Invalid child id", InvalidIDs: []string{childID}}
+		}
+		if personID != "" && childID == personID {
+			return "", &ChildrenError{Message: "A person cannot be their own child", InvalidIDs: []string{childID}}
+		}
+		if seen[childID] {
+			duplicates = append(duplicates, childID)
+		}
+		seen[childID] = true
+	}
+	if len(duplicates) > 0 {
+		return "", &ChildrenError{Message: "Duplicate child ids", InvalidIDs: duplicates}
+	}
+
+	if checkExist && len(children) > 0 {
+		refs := make([]*firestore.DocumentRef, len(children))
+		for i, childID := range children {
+			refs[i] = database.TreeCollection(ctx, client, "people").Doc(childID)
+		}
+		docs, err := client.GetAll(ctx, refs)
+		if err != nil {
+			return "", err
+		}
+		var missing []string
+		for i, doc := range docs {
+			if !doc.Exists() {
+				missing = append(missing, children[i])
+			}
+		}
+		if len(missing) > 0 {
+			return "", &ChildrenError{Message: "Children not found", InvalidIDs: missing}
+		}
+
+		if personID != "" {
+			ancestors, err := findAncestorIDs(ctx, client, personID)
+			if err != nil {
+				return "", err
+			}
+			if cyclic := cyclicChildren(children, ancestors); len(cyclic) > 0 {
+				return "", &ChildrenError{Message: "Adding these children would create a cycle", InvalidIDs: cyclic}
+			}
+		}
+	}
+
+	if maxChildrenSoftCap > 0 && len(children) > maxChildrenSoftCap {
+		warning := fmt.Sprintf("Person has %d children, above the soft limit of %d", len(children), maxChildrenSoftCap)
+		log.Printf("[Children] %s (person %s)", warning, personID)
+		return warning, nil
+	}
+	return "", nil
+}
+
+// syncParentIDs adds to batch the writes that keep children's parent_ids in step with
+// parentID's new children list: listed children gain the parent and people that list it
+// but are no longer children lose it. Children must already be known to exist.
+func syncParentIDs(ctx context.Context, client *firestore.Client, batch *firestore.WriteBatch, parentID string, children []string) error {
+	current, err := database.TreeCollection(ctx, client, "people").Where("parent_ids", "array-contains", parentID).Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, doc := range current {
+		if !containsString(children, doc.Ref.ID) {
+			batch.Update(doc.Ref, []firestore.Update{
+				{Path: "parent_ids", Value: firestore.ArrayRemove(parentID)},
+				{Path: "updated_at", Value: now},
+			})
+		}
+	}
+	for _, childID := range children {
+		batch.Update(database.TreeCollection(ctx, client, "people").Doc(childID), []firestore.Update{
+			{Path: "parent_ids", Value: firestore.ArrayUnion(parentID)},
+			{Path: "updated_at", Value: now},
+		})
+	}
+	return nil
+}
+
+// findAncestorIDs returns every ancestor of personID reachable through children arrays.
+// Visited people are not expanded again, so existing cycles cannot loop forever.
+func findAncestorIDs(ctx context.Context, client *firestore.Client, personID string) (map[string]bool, error) {
+	return collectAncestorIDs(personID, func(id string) ([]string, error) {
+		parents, err := findParents(ctx, client, id)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, len(parents))
+		for i, parent := range parents {
+			ids[i] = parent.ID
+		}
+		return ids, nil
+	})
+}
+
+// findAncestorIDsTx is findAncestorIDs read through tx, so a concurrent move or children
+// update before the transaction commits makes it retry rather than act on a stale chain
+func findAncestorIDsTx(ctx context.Context, tx *firestore.Transaction, client *firestore.Client, personID string) (map[string]bool, error) {
+	return collectAncestorIDs(personID, func(id string) ([]string, error) {
+		docs, err := tx.Documents(database.TreeCollection(ctx, client, "people").Where("children", "array-contains", id)).GetAll()
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, len(docs))
+		for i, doc := range docs {
+			ids[i] = doc.Ref.ID
+		}
+		return ids, nil
+	})
+}
+
+// collectAncestorIDs walks parentsOf breadth-first from personID, as findAncestorIDs does
+func collectAncestorIDs(personID string, parentsOf func(string) ([]string, error)) (map[string]bool, error) {
+	ancestors := make(map[string]bool)
+	queue := []string{personID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		parents, err := parentsOf(current)
+		if err != nil {
+			return nil, err
+		}
+		for _, parentID := range parents {
+			if !ancestors[parentID] && parentID != personID {
+				ancestors[parentID] = true
+				queue = append(queue, parentID)
+			}
+		}
+	}
+	return ancestors, nil
+}
+
+// cyclicChildren returns the children that are already ancestors
+func cyclicChildren(children []string, ancestors map[string]bool) []string {
+	var cyclic []string
+	for _, childID := range children {
+		if ancestors[childID] {
+			cyclic = append(cyclic, childID)
+		}
+	}
+	return cyclic
+}
+
+// validDocID reports whether id can safely be used as a people document ID
+func validDocID(id string) bool {
+	if id == "" || len(id) > 1500 || id == "." || id == ".." || strings.Contains(id, "/") {
+		return false
+	}
+	return !(strings.HasPrefix(id, "__") && strings.HasSuffix(id, "__"))
+}
+
+// respondChildrenError writes the response for a failed validateChildren call
+func respondChildrenError(c *gin.Context, err error) {
+	if ce, ok := err.(*ChildrenError); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ce.Message, "invalid_ids": ce.InvalidIDs})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate children"})
+}
+
+// setChildrenWarning surfaces a soft-cap warning on the response
+func setChildrenWarning(c *gin.Context, warning string) {
+	if warning != "" {
+		c.Header("Warning", fmt.Sprintf("199 - %q", warning))
+	}
+}
+
+// ReorderChildren stores a new order for a person's children. The list must be a
+// permutation of the current children: no additions, removals or duplicates.
+func (h *FirestoreTreeHandler) ReorderChildren(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.ReorderChildrenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "children is required"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	ctx := treeContext(c)
+	settings := loadTreeSettings(ctx, h.client)
+
+	// The order is checked against the children read in the same transaction, so a
+	// concurrent add or remove can't be overwritten with a stale list
+	var person models.Person
+	var unknown, missing []string
+	forbidden := false
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		forbidden = false
+		ref := h.coll(ctx, "people").Doc(id)
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return errPersonNotFound
+		}
+		if err := doc.DataTo(&person); err != nil {
+			return err
+		}
+		if !canModifyPerson(settings, person, userID.(string), role.(string)) {
+			forbidden = true
+			return nil
+		}
+
+		unknown, missing = childrenOrderDiff(person.Children, req.Children)
+		if len(unknown) > 0 || len(missing) > 0 || len(req.Children) != len(person.Children) {
+			return errNotChildrenPermutation
+		}
+
+		person.Children = req.Children
+		person.UpdatedAt = time.Now()
+		return tx.Update(ref, []firestore.Update{
+			{Path: "children", Value: person.Children},
+			{Path: "updated_at", Value: person.UpdatedAt},
+		})
+	})
+	switch {
+	case err == errPersonNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	case err == errNotChildrenPermutation:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "children must contain exactly the person's current children",
+			"unknown": unknown,
+			"missing": missing,
+		})
+		return
+	case err != nil:
+		log.Printf("[ReorderChildren] Failed to reorder children of %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder children"})
+		return
+	case forbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": modifyDeniedMessage(settings, "edit")})
+		return
+	}
+
+	c.JSON(http.StatusOK, person)
+}
+
+var errNotChildrenPermutation = errors.New("children must contain exactly the person's current children")
+
+// childrenOrderDiff compares a requested children order with the current children. unknown
+// holds requested ids that aren't current children or are repeated; missing holds current
+// children left out. Both empty means requested is a permutation of current.
+func childrenOrderDiff(current, requested []string) (unknown, missing []string) {
+	isCurrent := make(map[string]bool, len(current))
+	for _, childID := range current {
+		isCurrent[childID] = true
+	}
+	seen := make(map[string]bool, len(requested))
+	for _, childID := range requested {
+		if !isCurrent[childID] || seen[childID] {
+			unknown = append(unknown, childID)
+		}
+		seen[childID] = true
+	}
+	for _, childID := range current {
+		if !seen[childID] {
+			missing = append(missing, childID)
+		}
+	}
+	return unknown, missing
+}
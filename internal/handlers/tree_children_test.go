@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCycleCheckRejectsAncestorAsChild(t *testing.T) {
+	// grandpa -> dad -> me -> kid; uncle is grandpa's other child
+	childrenOf := map[string][]string{
+		"grandpa": {"dad", "uncle"},
+		"dad":     {"me"},
+		"me":      {"kid"},
+	}
+	parentsOf := func(id string) ([]string, error) {
+		var parents []string
+		for parent, children := range childrenOf {
+			if containsString(children, id) {
+				parents = append(parents, parent)
+			}
+		}
+		return parents, nil
+	}
+
+	ancestors, err := collectAncestorIDs("me", parentsOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"dad": true, "grandpa": true}; !reflect.DeepEqual(ancestors, want) {
+		t.Fatalf("ancestors of me = %v, want %v", ancestors, want)
+	}
+
+	if got := cyclicChildren([]string{"kid", "grandpa"}, ancestors); !reflect.DeepEqual(got, []string{"grandpa"}) {
+		t.Errorf("cyclicChildren = %v, want [grandpa]", got)
+	}
+	if got := cyclicChildren([]string{"kid", "uncle"}, ancestors); len(got) != 0 {
+		t.Errorf("non-ancestors flagged as cyclic: %v", got)
+	}
+}
+
+func TestCollectAncestorIDsTerminatesOnExistingCycle(t *testing.T) {
+	childrenOf := map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}}
+	parentsOf := func(id string) ([]string, error) {
+		var parents []string
+		for parent, children := range childrenOf {
+			if containsString(children, id) {
+				parents = append(parents, parent)
+			}
+		}
+		return parents, nil
+	}
+
+	ancestors, err := collectAncestorIDs("a", parentsOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"b": true, "c": true}; !reflect.DeepEqual(ancestors, want) {
+		t.Errorf("ancestors of a = %v, want %v", ancestors, want)
+	}
+}
+
+func TestChildrenOrderDiff(t *testing.T) {
+	current := []string{"a", "b", "c"}
+	tests := []struct {
+		name             string
+		requested        []string
+		unknown, missing []string
+	}{
+		{"permutation", []string{"c", "a", "b"}, nil, nil},
+		{"removed child", []string{"a", "b"}, nil, []string{"c"}},
+		{"added child", []string{"a", "b", "c", "d"}, []string{"d"}, nil},
+		{"duplicate", []string{"a", "a", "b"}, []string{"a"}, []string{"c"}},
+	}
+	for _, tt := range tests {
+		unknown, missing := childrenOrderDiff(current, tt.requested)
+		if !reflect.DeepEqual(unknown, tt.unknown) || !reflect.DeepEqual(missing, tt.missing) {
+			t.Errorf("%s: got unknown=%v missing=%v, want %v %v", tt.name, unknown, missing, tt.unknown, tt.missing)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// descendantsInMemoryMax is the tree size up to which GetDescendants loads every person
+// once instead of reading the subtree level by level (DESCENDANTS_IN_MEMORY_MAX, default 2000)
+var descendantsInMemoryMax = envPositiveInt("DESCENDANTS_IN_MEMORY_MAX", 2000)
+
+// DescendantNode is one person in a subtree with their distance from its root
+type DescendantNode struct {
+	Person models.Person `json:"person"`
+	Depth  int           `json:"depth"` // 1 for children, 2 for grandchildren, ...
+}
+
+// collectDescendants walks the subtree below root breadth-first, at most maxDepth levels
+// (0 means unlimited). Each person appears once, at their shallowest depth, even when
+// reachable through several parents; the root itself is never included. lookup returns
+// the people for a level's ids. It also reports whether the depth limit cut off children.
+func collectDescendants(root models.Person, maxDepth int, lookup func([]string) ([]models.Person, error)) ([]DescendantNode, bool, error) {
+	seen := map[string]bool{root.ID: true}
+	nodes := []DescendantNode{}
+	level := []models.Person{root}
+	for depth := 1; len(level) > 0; depth++ {
+		var ids []string
+		for _, p := range level {
+			for _, childID := range p.Children {
+				if !seen[childID] {
+					seen[childID] = true
+					ids = append(ids, childID)
+				}
+			}
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if maxDepth > 0 && depth > maxDepth {
+			return nodes, true, nil
+		}
+		next, err := lookup(ids)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, p := range next {
+			p.RelationshipType = p.ParentRelationship()
+			nodes = append(nodes, DescendantNode{Person: p, Depth: depth})
+		}
+		level = next
+	}
+	return nodes, false, nil
+}
+
+// GetDescendants returns every descendant of a person with their depth, nearest first,
+// and the total count. ?depth= limits how many generations are expanded. Small trees are
+// read into memory once; larger ones are read one generation at a time.
+func (h *FirestoreTreeHandler) GetDescendants(c *gin.Context) {
+	id := c.Param("id")
+
+	maxDepth := 0
+	if v := c.Query("depth"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "depth must be a positive integer"})
+			return
+		}
+		maxDepth = n
+	}
+
+	ctx := treeContext(c)
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+	var person models.Person
+	if err := doc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+
+	lookup, err := h.descendantLookup(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+	descendants, truncated, err := collectDescendants(person, maxDepth, lookup)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch descendants"})
+		return
+	}
+
+	person.RelationshipType = person.ParentRelationship()
+	c.JSON(http.StatusOK, gin.H{
+		"person":      person,
+		"descendants": descendants,
+		"total_count": len(descendants),
+		"truncated":   truncated, // More generations exist below the depth limit
+	})
+}
+
+// descendantLookup returns how collectDescendants should resolve ids: from one in-memory
+// map of all people when the tree is small, otherwise by batch reads per generation
+func (h *FirestoreTreeHandler) descendantLookup(ctx context.Context) (func([]string) ([]models.Person, error), error) {
+	byBatch := func(ids []string) ([]models.Person, error) {
+		return fetchPeopleByIDs(ctx, h.client, ids)
+	}
+
+	// If the count fails, per-generation reads are the safe choice
+	total, err := countQuery(ctx, h.coll(ctx, "people").Query)
+	if err != nil || total > int64(descendantsInMemoryMax) {
+		return byBatch, nil
+	}
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Person, len(people))
+	for _, p := range people {
+		byID[p.ID] = p
+	}
+	return func(ids []string) ([]models.Person, error) {
+		found := make([]models.Person, 0, len(ids))
+		for _, id := range ids {
+			if p, ok := byID[id]; ok {
+				found = append(found, p)
+			}
+		}
+		return found, nil
+	}, nil
+}
@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// importDuplicateThreshold is the similarity at which an imported name conflicts with an existing one
+const importDuplicateThreshold = 0.85
+
+// csvImportColumns maps accepted CSV headers (lowercased) to field keys.
+// Matches the columns written by ExportCSV, plus optional Gender and Parent ID.
+var csvImportColumns = map[string]string{
+	"id":              "id",
+	"name":            "name",
+	"alternate names": "alt_names",
+	"role":            "role",
+	"occupation":      "occupation",
+	"gender":          "gender",
+	"birth year":      "birth",
+	"birth":           "birth",
+	"died":            "death",
+	"death":           "death",
+	"location":        "location",
+	"bio":             "bio",
+	"avatar url":      "avatar",
+	"parent id":       "parent_id",
+}
+
+// csvDerivedColumns are written by ExportCSV but computed from other data, so the
+// importer accepts and ignores them
+var csvDerivedColumns = map[string]bool{
+	"children names": true,
+}
+
+// CSVImportRow is the result for one row of an imported CSV
+type CSVImportRow struct {
+	Row       int                     `json:"row"` // 1-based data row number (header excluded)
+	Name      string                  `json:"name"`
+	PersonID  string                  `json:"person_id,omitempty"`
+	Reason    string                  `json:"reason,omitempty"`
+	Conflicts []utils.NameMatchResult `json:"conflicts,omitempty"`
+}
+
+// CSVImportReport summarizes a CSV import
+type CSVImportReport struct {
+	Created     []CSVImportRow `json:"created"`
+	Skipped     []CSVImportRow `json:"skipped"`
+	Conflicting []CSVImportRow `json:"conflicting"`
+	Errors      []CSVImportRow `json:"errors"`
+}
+
+// csvImportRecord is a parsed CSV row before it is written
+type csvImportRecord struct {
+	row      int
+	sourceID string
+	parentID string
+	person   models.Person
+}
+
+// ImportCSV bulk-imports people from an uploaded CSV file (form field "file") using the
+// ExportCSV column layout. Rows matching an existing ID or an existing person with the same
+// name and birth are skipped; rows with similar names are reported as conflicts and not
+// created unless ?allow_conflicts=true.
+func (h *FirestoreTreeHandler) ImportCSV(c *gin.Context) {
+	allowConflicts := c.Query("allow_conflicts") == "true"
+	userID, _ := c.Get("user_id")
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is required (form field 'file')"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is empty or unreadable"})
+		return
+	}
+	columns, err := parseCSVImportHeader(header)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := treeContext(c)
+	existing, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+	existingByID := make(map[string]models.Person, len(existing))
+	allNames := make(map[string][]string, len(existing))
+	for _, p := range existing {
+		existingByID[p.ID] = p
+		allNames[p.ID] = append([]string{p.Name}, p.AltNames...)
+	}
+
+	report := CSVImportReport{
+		Created:     []CSVImportRow{},
+		Skipped:     []CSVImportRow{},
+		Conflicting: []CSVImportRow{},
+		Errors:      []CSVImportRow{},
+	}
+
+	// resolved maps CSV IDs to the Firestore ID they ended up as (new or existing);
+	// notImported holds CSV IDs of rows that were rejected
+	resolved := make(map[string]string)
+	notImported := make(map[string]bool)
+	var records []*csvImportRecord
+	now := time.Now()
+
+	for rowNum := 1; ; rowNum++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, CSVImportRow{Row: rowNum, Reason: fmt.Sprintf("Malformed CSV: %v", err)})
+			continue
+		}
+
+		values := make(map[string]string)
+		for i, key := range columns {
+			if key != "" && i < len(fields) {
+				values[key] = strings.TrimSpace(fields[i])
+			}
+		}
+
+		name := values["name"]
+		if name == "" {
+			report.Errors = append(report.Errors, CSVImportRow{Row: rowNum, Reason: "Name is required"})
+			continue
+		}
+		sourceID := values["id"]
+		if strings.Contains(sourceID, "/") {
+			report.Errors = append(report.Errors, CSVImportRow{Row: rowNum, Name: name, Reason: "ID must not contain '/'"})
+			continue
+		}
+		if sourceID != "" {
+			if _, dup := resolved[sourceID]; dup {
+				report.Errors = append(report.Errors, CSVImportRow{Row: rowNum, Name: name, Reason: fmt.Sprintf("Duplicate ID %q in file", sourceID)})
+				continue
+			}
+		}
+
+		// Same ID already in the tree: nothing to do
+		if p, ok := existingByID[sourceID]; ok && sourceID != "" {
+			resolved[sourceID] = p.ID
+			report.Skipped = append(report.Skipped, CSVImportRow{Row: rowNum, Name: name, PersonID: p.ID, Reason: "ID already exists"})
+			continue
+		}
+
+		var altNames []string
+		if raw := values["alt_names"]; raw != "" {
+			altNames, err = utils.CleanAltNames(strings.Split(raw, ";"))
+			if err != nil {
+				notImported[sourceID] = true
+				report.Errors = append(report.Errors, CSVImportRow{Row: rowNum, Name: name, Reason: err.Error()})
+				continue
+			}
+		}
+
+		bio, err := utils.SanitizeText(values["bio"])
+		if err != nil {
+			notImported[sourceID] = true
+			report.Errors = append(report.Errors, CSVImportRow{Row: rowNum, Name: name, Reason: "HTML is not allowed in bio"})
+			continue
+		}
+
+		// Duplicate detection against the existing tree
+		matches := utils.FindSimilarNamesWithAliases(name, allNames, importDuplicateThreshold)
+		if dupID := findExactDuplicate(matches, existingByID, values["birth"]); dupID != "" {
+			if sourceID != "" {
+				resolved[sourceID] = dupID
+			}
+			report.Skipped = append(report.Skipped, CSVImportRow{Row: rowNum, Name: name, PersonID: dupID, Reason: "Same name and birth as existing person"})
+			continue
+		}
+		if len(matches) > 0 && !allowConflicts {
+			notImported[sourceID] = true
+			report.Conflicting = append(report.Conflicting, CSVImportRow{Row: rowNum, Name: name, Reason: "Similar to existing people", Conflicts: matches})
+			continue
+		}
+
+		id := sourceID
+		if id == "" {
+			id = uuid.New().String()
+		}
+		if sourceID != "" {
+			resolved[sourceID] = id
+		}
+
+		gender := strings.ToLower(values["gender"])
+		if gender != "male" && gender != "female" {
+			gender = ""
+		}
+		role := values["role"]
+		if role == "" {
+			role = defaultPersonRole
+		}
+		avatar := values["avatar"]
+		if avatar == "" {
+			avatar = generateGenderAvatar(name, gender)
+		}
+		if altNames == nil {
+			altNames = []string{}
+		}
+		// ExportCSV writes "deceased" in the Died column when the date is unknown
+		death, deceased := values["death"], values["death"] != ""
+		if strings.EqualFold(death, "deceased") {
+			death = ""
+		}
+
+		records = append(records, &csvImportRecord{
+			row:      rowNum,
+			sourceID: sourceID,
+			parentID: values["parent_id"],
+			person: models.Person{
+				ID:         id,
+				Name:       name,
+				AltNames:   altNames,
+				Role:       role,
+				Occupation: values["occupation"],
+				Gender:     gender,
+				Birth:      values["birth"],
+				Death:      death,
+				Deceased:   deceased,
+				Location:   values["location"],
+				Avatar:     avatar,
+				Bio:        bio,
+				Children:   []string{},
+				ParentIDs:  []string{},
+				CreatedBy:  userID.(string),
+				TreeID:     requestTreeID(c),
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			},
+		})
+	}
+
+	// Reconstruct relationships now that every row's final ID is known
+	byID := make(map[string]*csvImportRecord, len(records))
+	for _, r := range records {
+		byID[r.person.ID] = r
+	}
+	parentOf := make(map[string]string)
+	failed := make(map[string]bool)
+	existingParentUpdates := make(map[string][]string)
+
+	for _, r := range records {
+		if r.parentID == "" {
+			continue
+		}
+		parentID, ok := resolved[r.parentID]
+		if !ok {
+			if _, exists := existingByID[r.parentID]; exists {
+				parentID = r.parentID
+			} else {
+				reason := fmt.Sprintf("Parent ID %q not found in file or tree", r.parentID)
+				if notImported[r.parentID] {
+					reason = fmt.Sprintf("Parent row %q was not imported", r.parentID)
+				}
+				failed[r.person.ID] = true
+				report.Errors = append(report.Errors, CSVImportRow{Row: r.row, Name: r.person.Name, Reason: reason})
+				continue
+			}
+		}
+		if parentID == r.person.ID {
+			failed[r.person.ID] = true
+			report.Errors = append(report.Errors, CSVImportRow{Row: r.row, Name: r.person.Name, Reason: "Person cannot be their own parent"})
+			continue
+		}
+		parentOf[r.person.ID] = parentID
+	}
+
+	// Drop rows whose parent chain loops back on itself or reaches a row that failed
+	var valid []*csvImportRecord
+	for _, r := range records {
+		if failed[r.person.ID] {
+			continue
+		}
+		if reason := checkImportParentChain(r.person.ID, parentOf, failed); reason != "" {
+			report.Errors = append(report.Errors, CSVImportRow{Row: r.row, Name: r.person.Name, Reason: reason})
+			continue
+		}
+		valid = append(valid, r)
+	}
+	validIDs := make(map[string]bool, len(valid))
+	for _, r := range valid {
+		validIDs[r.person.ID] = true
+	}
+	for _, r := range valid {
+		parentID, ok := parentOf[r.person.ID]
+		if !ok {
+			continue
+		}
+		r.person.ParentIDs = []string{parentID}
+		if parent, inFile := byID[parentID]; inFile && validIDs[parentID] {
+			parent.person.Children = append(parent.person.Children, r.person.ID)
+		} else {
+			existingParentUpdates[parentID] = append(existingParentUpdates[parentID], r.person.ID)
+		}
+	}
+
+	// Write in batches (Firestore batch limit is 500)
+	batch := h.client.Batch()
+	count := 0
+	commit := func() error {
+		if count%500 == 0 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return err
+			}
+			batch = h.client.Batch()
+		}
+		return nil
+	}
+
+	for _, r := range valid {
+		batch.Set(h.coll(ctx, "people").Doc(r.person.ID), r.person)
+		count++
+		if err := commit(); err != nil {
+			log.Printf("[ImportCSV] Batch commit failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create people", "report": report})
+			return
+		}
+		report.Created = append(report.Created, CSVImportRow{Row: r.row, Name: r.person.Name, PersonID: r.person.ID})
+	}
+	for parentID, children := range existingParentUpdates {
+		childValues := make([]interface{}, len(children))
+		for i, id := range children {
+			childValues[i] = id
+		}
+		batch.Update(h.coll(ctx, "people").Doc(parentID), []firestore.Update{
+			{Path: "children", Value: firestore.ArrayUnion(childValues...)},
+			{Path: "updated_at", Value: now},
+		})
+		count++
+		if err := commit(); err != nil {
+			log.Printf("[ImportCSV] Batch commit failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link imported people", "report": report})
+			return
+		}
+	}
+	if count%500 != 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			log.Printf("[ImportCSV] Batch commit failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create people"})
+			return
+		}
+	}
+
+	log.Printf("[ImportCSV] Imported CSV by %s: created=%d skipped=%d conflicting=%d errors=%d",
+		userID, len(report.Created), len(report.Skipped), len(report.Conflicting), len(report.Errors))
+	c.JSON(http.StatusOK, report)
+}
+
+// parseCSVImportHeader validates the header row and returns the field key for each column
+func parseCSVImportHeader(header []string) ([]string, error) {
+	columns := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if csvDerivedColumns[name] {
+			continue
+		}
+		key, ok := csvImportColumns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", h)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate column %q", h)
+		}
+		seen[key] = true
+		columns[i] = key
+	}
+	if !seen["name"] {
+		return nil, errors.New("missing required column \"Name\"")
+	}
+	return columns, nil
+}
+
+// findExactDuplicate returns the ID of an existing person with an exact/normalized name
+// match and the same birth value, or "" if none
+func findExactDuplicate(matches []utils.NameMatchResult, existing map[string]models.Person, birth string) string {
+	for _, m := range matches {
+		if m.MatchType != "exact" && m.MatchType != "normalized" {
+			continue
+		}
+		if p, ok := existing[m.PersonID]; ok && strings.TrimSpace(p.Birth) == birth {
+			return p.ID
+		}
+	}
+	return ""
+}
+
+// checkImportParentChain walks up from id and reports a cycle or an ancestor row that failed
+func checkImportParentChain(id string, parentOf map[string]string, failed map[string]bool) string {
+	visited := map[string]bool{id: true}
+	current := id
+	for {
+		parentID, ok := parentOf[current]
+		if !ok {
+			return ""
+		}
+		if failed[parentID] {
+			return fmt.Sprintf("Ancestor %q was not imported", parentID)
+		}
+		if visited[parentID] {
+			return "Parent relationships form a cycle"
+		}
+		visited[parentID] = true
+		current = parentID
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import "testing"
+
+func TestParseCSVImportHeaderAcceptsExportHeader(t *testing.T) {
+	columns, err := parseCSVImportHeader(csvExportHeader)
+	if err != nil {
+		t.Fatalf("exported header rejected: %v", err)
+	}
+	if len(columns) != len(csvExportHeader) {
+		t.Fatalf("got %d columns, want %d", len(columns), len(csvExportHeader))
+	}
+	if got := columns[len(columns)-1]; got != "" {
+		t.Errorf("Children Names should be ignored, mapped to %q", got)
+	}
+	if columns[1] != "name" {
+		t.Errorf("Name mapped to %q", columns[1])
+	}
+}
+
+func TestParseCSVImportHeaderRejectsUnknownColumn(t *testing.T) {
+	if _, err := parseCSVImportHeader([]string{"Name", "Nickname"}); err == nil {
+		t.Error("expected an unknown column to be rejected")
+	}
+	if _, err := parseCSVImportHeader([]string{"Birth Year", "Birth", "Name"}); err == nil {
+		t.Error("expected a duplicate column to be rejected")
+	}
+	if _, err := parseCSVImportHeader([]string{"ID", "Children Names"}); err == nil {
+		t.Error("expected a missing Name column to be rejected")
+	}
+}
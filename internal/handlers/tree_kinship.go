@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// ancestorDistances returns every ancestor of id (and id itself at 0) with the fewest
+// generations between them. Visited people are never expanded twice, so cycles end the walk.
+func ancestorDistances(id string, parentsOf map[string][]string) map[string]int {
+	distances := map[string]int{id: 0}
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, parentID := range parentsOf[current] {
+			if _, seen := distances[parentID]; !seen {
+				distances[parentID] = distances[current] + 1
+				queue = append(queue, parentID)
+			}
+		}
+	}
+	return distances
+}
+
+// ordinal formats n as "1st", "2nd", "3rd", "4th", ...
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// greatPrefix is "" for 0, "great-" for 1 and "2nd great-", "3rd great-", ... beyond
+func greatPrefix(n int) string {
+	switch {
+	case n <= 0:
+		return ""
+	case n == 1:
+		return "great-"
+	default:
+		return ordinal(n) + " great-"
+	}
+}
+
+// gendered picks the male or female form of a kinship term, or the neutral one
+func gendered(gender, male, female, neutral string) string {
+	switch gender {
+	case "male":
+		return male
+	case "female":
+		return female
+	}
+	return neutral
+}
+
+// cousinNames are the spelled-out cousin degrees; higher degrees use ordinal
+var cousinNames = []string{"", "first", "second", "third"}
+
+// kinshipLabel names what the second person is to the first, given how many generations
+// each is below their lowest common ancestor (up for the first, down for the second).
+// gender is the second person's; half marks siblings sharing only one parent.
+func kinshipLabel(up, down int, gender string, half bool) string {
+	switch {
+	case up == 0 && down == 0:
+		return "self"
+	case up == 0:
+		if down == 1 {
+			return gendered(gender, "son", "daughter", "child")
+		}
+		return greatPrefix(down-2) + gendered(gender, "grandson", "granddaughter", "grandchild")
+	case down == 0:
+		if up == 1 {
+			return gendered(gender, "father", "mother", "parent")
+		}
+		return greatPrefix(up-2) + gendered(gender, "grandfather", "grandmother", "grandparent")
+	case up == 1 && down == 1:
+		sibling := gendered(gender, "brother", "sister", "sibling")
+		if half {
+			return "half-" + sibling
+		}
+		return sibling
+	case up == 1:
+		return greatPrefix(down-2) + gendered(gender, "nephew", "niece", "niece/nephew")
+	case down == 1:
+		return greatPrefix(up-2) + gendered(gender, "uncle", "aunt", "aunt/uncle")
+	}
+
+	degree, removed := up-1, up-down
+	if down < up {
+		degree = down - 1
+	} else {
+		removed = down - up
+	}
+	name := ordinal(degree)
+	if degree < len(cousinNames) {
+		name = cousinNames[degree]
+	}
+	label := name + " cousin"
+	switch removed {
+	case 0:
+	case 1:
+		label += " once removed"
+	case 2:
+		label += " twice removed"
+	default:
+		label += fmt.Sprintf(" %d times removed", removed)
+	}
+	return label
+}
+
+// GetRelationship computes how ?to= is related to ?from= through their lowest common
+// ancestor in the children arrays. The response carries the ancestor, both distances and
+// an English label such as "first cousin once removed" describing the to person.
+// People without a common ancestor are reported as unrelated (or as spouses if linked).
+func (h *FirestoreTreeHandler) GetRelationship(c *gin.Context) {
+	fromID, toID := c.Query("from"), c.Query("to")
+	if fromID == "" || toID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+		return
+	}
+
+	ctx := treeContext(c)
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+	byID := make(map[string]models.Person, len(people))
+	parentsOf := make(map[string][]string)
+	for _, p := range people {
+		byID[p.ID] = p
+		for _, childID := range p.Children {
+			if childID != p.ID && !containsString(parentsOf[childID], p.ID) {
+				parentsOf[childID] = append(parentsOf[childID], p.ID)
+			}
+		}
+	}
+	from, ok := byID[fromID]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found", "person_id": fromID})
+		return
+	}
+	to, ok := byID[toID]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found", "person_id": toID})
+		return
+	}
+
+	fromDistances := ancestorDistances(from.ID, parentsOf)
+	toDistances := ancestorDistances(to.ID, parentsOf)
+
+	// The lowest common ancestors are those with the smallest combined distance
+	best := -1
+	var common []string
+	for id, up := range fromDistances {
+		down, ok := toDistances[id]
+		if !ok {
+			continue
+		}
+		switch total := up + down; {
+		case best == -1 || total < best:
+			best, common = total, []string{id}
+		case total == best:
+			common = append(common, id)
+		}
+	}
+
+	response := gin.H{
+		"from": from.Summary(),
+		"to":   to.Summary(),
+	}
+	if len(common) == 0 {
+		label := "not related"
+		if containsString(from.Spouses, to.ID) {
+			label = gendered(to.Gender, "husband", "wife", "spouse")
+		}
+		response["related"] = false
+		response["label"] = label
+		response["lca_id"] = ""
+		response["common_ancestor_ids"] = []string{}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	sort.Strings(common)
+	lcaID := common[0]
+	up, down := fromDistances[lcaID], toDistances[lcaID]
+	// Siblings sharing one parent while either has another are half-siblings
+	half := up == 1 && down == 1 && len(common) == 1 &&
+		(len(parentsOf[from.ID]) > 1 || len(parentsOf[to.ID]) > 1)
+
+	response["related"] = true
+	response["label"] = kinshipLabel(up, down, to.Gender, half)
+	response["lca_id"] = lcaID
+	response["common_ancestor_ids"] = common
+	response["from_distance"] = up
+	response["to_distance"] = down
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// maxLikeBatch caps how many people a single likes request may touch
+const maxLikeBatch = 100
+
+// uniqueIDs drops empty and repeated ids, keeping the first occurrence order
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
+
+// likesCount is the likes_count matching a liked_by array: the number of distinct users,
+// never negative
+func likesCount(likedBy []string) int {
+	return len(uniqueIDs(likedBy))
+}
+
+var (
+	errAlreadyLiked = errors.New("already liked")
+	errNotLiked     = errors.New("not liked")
+)
+
+// applyLike returns likedBy after uid likes (like) or unlikes the person, or errAlreadyLiked
+// / errNotLiked when the person is already in that state
+func applyLike(likedBy []string, uid string, like bool) ([]string, error) {
+	hasLiked := containsString(likedBy, uid)
+	switch {
+	case like && hasLiked:
+		return nil, errAlreadyLiked
+	case like:
+		return append(append([]string{}, likedBy...), uid), nil
+	case !hasLiked:
+		return nil, errNotLiked
+	default:
+		return withoutIDs(likedBy, []string{uid}), nil
+	}
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLikesStatus returns, for each requested person, whether the caller has liked them.
+// Unknown ids are reported as not liked.
+func (h *FirestoreTreeHandler) GetLikesStatus(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.LikesStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	ids := uniqueIDs(req.IDs)
+	if len(ids) > maxLikeBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many ids", "max": maxLikeBatch})
+		return
+	}
+
+	liked := make(map[string]bool, len(ids))
+	if len(ids) == 0 {
+		c.JSON(http.StatusOK, gin.H{"liked": liked})
+		return
+	}
+
+	ctx := treeContext(c)
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = h.coll(ctx, "people").Doc(id)
+	}
+	docs, err := h.client.GetAll(ctx, refs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	for i, doc := range docs {
+		liked[ids[i]] = false
+		if !doc.Exists() {
+			continue
+		}
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			continue
+		}
+		liked[ids[i]] = containsString(person.LikedBy, userID.(string))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"liked": liked})
+}
+
+// BatchLikePeople likes or unlikes several people in one transaction. People already
+// in the requested state are left alone; any unknown id fails the whole batch.
+func (h *FirestoreTreeHandler) BatchLikePeople(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	uid := userID.(string)
+
+	var req models.BatchLikeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if req.Action != "like" && req.Action != "unlike" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be 'like' or 'unlike'"})
+		return
+	}
+	ids := uniqueIDs(req.IDs)
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No person IDs provided"})
+		return
+	}
+	if len(ids) > maxLikeBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many ids", "max": maxLikeBatch})
+		return
+	}
+
+	ctx := treeContext(c)
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = h.coll(ctx, "people").Doc(id)
+	}
+
+	var changed, unchanged []string
+	var missingID string
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		changed, unchanged = []string{}, []string{}
+
+		docs, err := tx.GetAll(refs)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i, doc := range docs {
+			if !doc.Exists() {
+				missingID = ids[i]
+				return errPersonNotFound
+			}
+			var person models.Person
+			if err := doc.DataTo(&person); err != nil {
+				return err
+			}
+
+			likedBy, err := applyLike(person.LikedBy, uid, req.Action == "like")
+			if err != nil {
+				unchanged = append(unchanged, ids[i])
+				continue
+			}
+			var arrayUpdate interface{} = firestore.ArrayUnion(uid)
+			if req.Action == "unlike" {
+				arrayUpdate = firestore.ArrayRemove(uid)
+			}
+
+			if err := tx.Update(refs[i], []firestore.Update{
+				{Path: "liked_by", Value: arrayUpdate},
+				{Path: "likes_count", Value: likesCount(likedBy)},
+				{Path: "updated_at", Value: now},
+			}); err != nil {
+				return err
+			}
+			changed = append(changed, ids[i])
+		}
+		return nil
+	})
+
+	if err != nil {
+		if err == errPersonNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Person not found", "person_id": missingID})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update likes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"action":    req.Action,
+		"changed":   changed,
+		"unchanged": unchanged,
+	})
+}
+
+// linkedPersonQueryLimit is how many values one Firestore "in" filter may hold
+const linkedPersonQueryLimit = 30
+
+// PersonLiker is a user who liked a person, named by the person they are linked to.
+// Email is only filled in for approvers.
+type PersonLiker struct {
+	UserID     string `json:"user_id"`
+	Email      string `json:"email,omitempty"`
+	PersonID   string `json:"person_id,omitempty"`
+	PersonName string `json:"person_name,omitempty"`
+}
+
+// GetPersonLikes lists who liked a person, most recent likes last, paginated
+// with ?page= and ?page_size=. Users that no longer exist are skipped.
+func (h *FirestoreTreeHandler) GetPersonLikes(c *gin.Context) {
+	id := c.Param("id")
+	role, _ := c.Get("role")
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+	var person models.Person
+	if err := doc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+
+	likedBy := uniqueIDs(person.LikedBy)
+	page, pageSize := parsePagination(c)
+	start, end, totalPages := pageBounds(len(likedBy), page, pageSize)
+	pageIDs := likedBy[start:end]
+
+	likers := []PersonLiker{}
+	if len(pageIDs) > 0 {
+		refs := make([]*firestore.DocumentRef, len(pageIDs))
+		for i, uid := range pageIDs {
+			refs[i] = h.coll(ctx, "users").Doc(uid)
+		}
+		userDocs, err := h.client.GetAll(ctx, refs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+			return
+		}
+
+		// Name each liker by the person linked to their account
+		linked := make(map[string]models.Person)
+		for chunk := 0; chunk < len(pageIDs); chunk += linkedPersonQueryLimit {
+			chunkEnd := chunk + linkedPersonQueryLimit
+			if chunkEnd > len(pageIDs) {
+				chunkEnd = len(pageIDs)
+			}
+			docs, err := h.coll(ctx, "people").Where("linked_user_id", "in", pageIDs[chunk:chunkEnd]).Documents(ctx).GetAll()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked people"})
+				return
+			}
+			for _, d := range docs {
+				var p models.Person
+				if err := d.DataTo(&p); err == nil {
+					linked[p.LinkedUserID] = p
+				}
+			}
+		}
+
+		showEmail := models.UserRole(role.(string)).CanApprove()
+		for i, userDoc := range userDocs {
+			if !userDoc.Exists() {
+				continue
+			}
+			liker := PersonLiker{UserID: pageIDs[i]}
+			if showEmail {
+				var user models.User
+				if err := userDoc.DataTo(&user); err == nil {
+					liker.Email = user.Email
+				}
+			}
+			if p, ok := linked[pageIDs[i]]; ok {
+				liker.PersonID = p.ID
+				liker.PersonName = p.Name
+			}
+			likers = append(likers, liker)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"person_id":   person.ID,
+		"likes_count": person.LikesCount,
+		"likers":      likers,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       len(likedBy),
+		"total_pages": totalPages,
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyLike(t *testing.T) {
+	tests := []struct {
+		name    string
+		likedBy []string
+		like    bool
+		want    []string
+		wantErr error
+	}{
+		{"first like", nil, true, []string{"u1"}, nil},
+		{"like alongside others", []string{"u2"}, true, []string{"u2", "u1"}, nil},
+		{"double like", []string{"u2", "u1"}, true, nil, errAlreadyLiked},
+		{"unlike", []string{"u2", "u1"}, false, []string{"u2"}, nil},
+		{"unlike when not liked", []string{"u2"}, false, nil, errNotLiked},
+		{"unlike when nobody liked", nil, false, nil, errNotLiked},
+	}
+	for _, tt := range tests {
+		got, err := applyLike(tt.likedBy, "u1", tt.like)
+		if err != tt.wantErr {
+			t.Errorf("%s: err %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyLikeDoesNotModifyInput(t *testing.T) {
+	likedBy := make([]string, 1, 4)
+	likedBy[0] = "u2"
+	if _, err := applyLike(likedBy, "u1", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := likedBy[:cap(likedBy)][1]; got != "" {
+		t.Errorf("input backing array overwritten with %q", got)
+	}
+}
+
+// Two users liking at once: the transaction retries whichever commits second against the
+// first one's write, so both orders must end with both likes counted once
+func TestApplyLikeConcurrentUsers(t *testing.T) {
+	for _, order := range [][]string{{"a", "b"}, {"b", "a"}} {
+		var likedBy []string
+		for _, uid := range order {
+			next, err := applyLike(likedBy, uid, true)
+			if err != nil {
+				t.Fatalf("%v: %s: %v", order, uid, err)
+			}
+			likedBy = next
+		}
+		if n := likesCount(likedBy); n != 2 {
+			t.Errorf("%v: likes_count %d, want 2", order, n)
+		}
+		for _, uid := range order {
+			if !containsString(likedBy, uid) {
+				t.Errorf("%v: %s missing from liked_by", order, uid)
+			}
+		}
+	}
+}
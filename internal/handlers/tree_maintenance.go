@@ -0,0 +1,610 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// NameCluster groups people whose names are likely spellings of the same name
+type NameCluster struct {
+	CanonicalName string              `json:"canonical_name"`
+	Variants      map[string]int      `json:"variants"` // spelling -> number of people using it
+	Members       []NameClusterMember `json:"members"`
+	ToUpdate      int                 `json:"to_update"` // members not using the canonical spelling
+}
+
+// NameClusterMember is a person inside a name cluster
+type NameClusterMember struct {
+	PersonID  string `json:"person_id"`
+	Name      string `json:"name"`
+	Canonical bool   `json:"canonical"`
+}
+
+// NormalizeTreeNames finds clusters of likely-same names across the tree and suggests a
+// canonical spelling for each. Dry-run by default; ?apply=true renames non-canonical nodes.
+func (h *FirestoreTreeHandler) NormalizeTreeNames(c *gin.Context) {
+	apply := c.Query("apply") == "true"
+	useAI := c.Query("use_ai") == "true"
+
+	threshold := 0.95 // phonetic match or better
+	if t := c.Query("threshold"); t != "" {
+		parsed, err := strconv.ParseFloat(t, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "threshold must be a number between 0 and 1"})
+			return
+		}
+		threshold = parsed
+	}
+
+	ctx := treeContext(c)
+
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	names := make(map[string]string, len(people)) // personID -> name
+	for _, p := range people {
+		if p.Name != "" {
+			names[p.ID] = p.Name
+		}
+	}
+
+	// Union-find over person IDs so transitive matches end up in one cluster
+	parent := make(map[string]string, len(names))
+	var find func(string) string
+	find = func(id string) string {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	union := func(a, b string) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[rb] = ra
+		}
+	}
+	for id := range names {
+		parent[id] = id
+	}
+
+	for id, name := range names {
+		for _, m := range utils.FindSimilarNames(name, names, threshold) {
+			if m.PersonID != id {
+				union(id, m.PersonID)
+			}
+		}
+	}
+
+	aiUsed, aiTruncated := false, false
+	if useAI {
+		aiUsed, aiTruncated = mergeNameClustersWithGemini(names, find, union, threshold)
+	}
+
+	grouped := make(map[string][]string)
+	for id := range names {
+		root := find(id)
+		grouped[root] = append(grouped[root], id)
+	}
+
+	clusters := make([]NameCluster, 0)
+	renames := make(map[string]string) // personID -> canonical name
+	for _, ids := range grouped {
+		variants := make(map[string]int)
+		for _, id := range ids {
+			variants[names[id]]++
+		}
+		if len(variants) < 2 {
+			continue // Everyone already uses the same spelling
+		}
+
+		canonical := pickCanonicalName(variants)
+		cluster := NameCluster{
+			CanonicalName: canonical,
+			Variants:      variants,
+			Members:       make([]NameClusterMember, 0, len(ids)),
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			isCanonical := names[id] == canonical
+			cluster.Members = append(cluster.Members, NameClusterMember{
+				PersonID:  id,
+				Name:      names[id],
+				Canonical: isCanonical,
+			})
+			if !isCanonical {
+				cluster.ToUpdate++
+				renames[id] = canonical
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].ToUpdate != clusters[j].ToUpdate {
+			return clusters[i].ToUpdate > clusters[j].ToUpdate
+		}
+		return clusters[i].CanonicalName < clusters[j].CanonicalName
+	})
+
+	updated := 0
+	if apply && len(renames) > 0 {
+		userID, _ := c.Get("user_id")
+		now := time.Now()
+		batch := h.client.Batch()
+		pending := 0
+		for id, canonical := range renames {
+			batch.Update(h.coll(ctx, "people").Doc(id), []firestore.Update{
+				{Path: "name", Value: canonical},
+				{Path: "updated_at", Value: now},
+			})
+			pending++
+
+			// Firestore batch limit is 500
+			if pending == 500 {
+				if _, err := batch.Commit(ctx); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update names", "updated_count": updated})
+					return
+				}
+				updated += pending
+				pending = 0
+				batch = h.client.Batch()
+			}
+		}
+		if pending > 0 {
+			if _, err := batch.Commit(ctx); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update names", "updated_count": updated})
+				return
+			}
+			updated += pending
+		}
+		log.Printf("[NormalizeNames] Renamed %d people to canonical spellings (by %v)", updated, userID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":       !apply,
+		"threshold":     threshold,
+		"ai_enhanced":   aiUsed,
+		"ai_truncated":  aiTruncated, // Only the first clusters were sent to Gemini
+		"cluster_count": len(clusters),
+		"clusters":      clusters,
+		"to_update":     len(renames),
+		"updated_count": updated,
+		"message":       normalizeNamesMessage(apply, len(renames), updated),
+	})
+}
+
+// normalizeNamesAIBatchSize is how many clusters are sent to Gemini per prompt, and
+// maxNormalizeNamesAIBatches caps the prompts per request
+const (
+	normalizeNamesAIBatchSize  = 40
+	maxNormalizeNamesAIBatches = 5
+)
+
+// mergeNameClustersWithGemini asks Gemini for spellings the traditional matcher missed and
+// unions them into the provisional clusters. Only clusters that already have several
+// spellings are sent, in a few batched prompts, each listing the tree's distinct spellings.
+// Reports whether Gemini was used and whether some clusters were left out by the cap.
+func mergeNameClustersWithGemini(names map[string]string, find func(string) string, union func(string, string), threshold float64) (bool, bool) {
+	bySpelling := make(map[string][]string) // name -> person IDs
+	variantsByRoot := make(map[string]map[string]int)
+	for id, name := range names {
+		bySpelling[name] = append(bySpelling[name], id)
+		root := find(id)
+		if variantsByRoot[root] == nil {
+			variantsByRoot[root] = make(map[string]int)
+		}
+		variantsByRoot[root][name]++
+	}
+
+	rootByTarget := make(map[string]string)
+	targets := make([]string, 0)
+	for root, variants := range variantsByRoot {
+		if len(variants) < 2 {
+			continue
+		}
+		target := pickCanonicalName(variants)
+		if _, dup := rootByTarget[target]; dup {
+			continue
+		}
+		rootByTarget[target] = root
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	spellings := make([]string, 0, len(bySpelling))
+	for name := range bySpelling {
+		spellings = append(spellings, name)
+	}
+	sort.Strings(spellings)
+
+	truncated := false
+	if limit := normalizeNamesAIBatchSize * maxNormalizeNamesAIBatches; len(targets) > limit {
+		targets = targets[:limit]
+		truncated = true
+	}
+
+	used := false
+	for start := 0; start < len(targets); start += normalizeNamesAIBatchSize {
+		end := start + normalizeNamesAIBatchSize
+		if end > len(targets) {
+			end = len(targets)
+		}
+		results, err := utils.MatchNameVariantsWithGemini(targets[start:end], spellings)
+		if err != nil {
+			log.Printf("[NormalizeNames] Gemini matching failed (using traditional only): %v", err)
+			break
+		}
+		used = true
+		for _, result := range results {
+			root, ok := rootByTarget[result.Name]
+			if !ok {
+				continue
+			}
+			for _, m := range result.Matches {
+				if m.Similarity < threshold {
+					continue
+				}
+				for _, id := range bySpelling[m.Name] {
+					union(root, id)
+				}
+			}
+		}
+	}
+	return used, truncated
+}
+
+// CharacterFix is a before/after sample from character normalization
+type CharacterFix struct {
+	PersonID string `json:"person_id"`
+	Before   string `json:"before"`
+	After    string `json:"after"`
+}
+
+// NormalizeTreeCharacters rewrites every person's name with utils.NormalizePersianCharacters
+// (Arabic kaf/ya to Persian, no diacritics), updating only names that change. Safe to re-run.
+func (h *FirestoreTreeHandler) NormalizeTreeCharacters(c *gin.Context) {
+	const sampleSize = 20
+
+	ctx := treeContext(c)
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	var fixes []CharacterFix
+	for _, p := range people {
+		normalized := utils.NormalizePersianCharacters(p.Name)
+		if normalized != p.Name && normalized != "" {
+			fixes = append(fixes, CharacterFix{PersonID: p.ID, Before: p.Name, After: normalized})
+		}
+	}
+
+	now := time.Now()
+	updated := 0
+	batch := h.client.Batch()
+	pending := 0
+	for _, fix := range fixes {
+		batch.Update(h.coll(ctx, "people").Doc(fix.PersonID), []firestore.Update{
+			{Path: "name", Value: fix.After},
+			{Path: "updated_at", Value: now},
+		})
+		pending++
+
+		// Firestore batch limit is 500
+		if pending == 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update names", "updated_count": updated})
+				return
+			}
+			updated += pending
+			pending = 0
+			batch = h.client.Batch()
+		}
+	}
+	if pending > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update names", "updated_count": updated})
+			return
+		}
+		updated += pending
+	}
+
+	sample := fixes
+	if len(sample) > sampleSize {
+		sample = sample[:sampleSize]
+	}
+	if sample == nil {
+		sample = []CharacterFix{}
+	}
+
+	userID, _ := c.Get("user_id")
+	log.Printf("[NormalizeCharacters] Normalized %d of %d names (by %v)", updated, len(people), userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"checked_count": len(people),
+		"updated_count": updated,
+		"sample":        sample,
+	})
+}
+
+// InferGendersRequest optionally extends the built-in first-name dictionary
+type InferGendersRequest struct {
+	Dictionary map[string]string `json:"dictionary"` // first name -> "male"/"female"
+}
+
+// GenderProposal is a proposed gender change for one person
+type GenderProposal struct {
+	PersonID       string `json:"person_id"`
+	Name           string `json:"name"`
+	CurrentGender  string `json:"current_gender"`
+	ProposedGender string `json:"proposed_gender"`
+	Source         string `json:"source"` // "dictionary" or "ai"
+}
+
+// isLegacyDefaultGender reports whether p may carry the "male" default stored before
+// gender_defaulted existed; such records can't be told apart from an explicit "male"
+func isLegacyDefaultGender(p models.Person) bool {
+	return p.Gender == "male" && !p.GenderDefaulted
+}
+
+// isGenderInferenceCandidate reports whether InferGenders may propose a gender for p:
+// no gender, a defaulted one, or with includeLegacy a possible legacy default
+func isGenderInferenceCandidate(p models.Person, includeLegacy bool) bool {
+	return p.Gender == "" || p.GenderDefaulted || (includeLegacy && isLegacyDefaultGender(p))
+}
+
+// InferGenders proposes genders for people whose gender is empty or was defaulted on import,
+// based on their first name. Explicitly set genders are never touched. Dry-run by default;
+// ?apply=true saves the proposals, ?use_ai=true asks Gemini about names not in the dictionary.
+// ?include_legacy=true also considers "male" genders saved without gender_defaulted, which
+// older imports stored by default; for those only actual changes are proposed.
+func (h *FirestoreTreeHandler) InferGenders(c *gin.Context) {
+	apply := c.Query("apply") == "true"
+	useAI := c.Query("use_ai") == "true"
+	includeLegacy := c.Query("include_legacy") == "true"
+
+	var req InferGendersRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			return
+		}
+	}
+	for name, gender := range req.Dictionary {
+		if gender != "male" && gender != "female" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("dictionary[%q] must be 'male' or 'female'", name)})
+			return
+		}
+	}
+	dict := utils.BuildGenderDictionary(req.Dictionary)
+
+	ctx := treeContext(c)
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	proposals := []GenderProposal{}
+	var unresolved []models.Person
+	candidates := 0
+	for _, p := range people {
+		if !isGenderInferenceCandidate(p, includeLegacy) {
+			continue // Explicitly set
+		}
+		candidates++
+		if gender := utils.InferGenderFromName(p.Name, dict); gender != "" {
+			if isLegacyDefaultGender(p) && gender == p.Gender {
+				continue
+			}
+			proposals = append(proposals, GenderProposal{PersonID: p.ID, Name: p.Name, CurrentGender: p.Gender, ProposedGender: gender, Source: "dictionary"})
+		} else {
+			unresolved = append(unresolved, p)
+		}
+	}
+
+	aiUsed := false
+	if useAI && len(unresolved) > 0 {
+		names := make([]string, 0, len(unresolved))
+		for _, p := range unresolved {
+			names = append(names, p.Name)
+		}
+		aiGenders, err := utils.InferGendersWithGemini(names)
+		if err != nil {
+			log.Printf("[InferGenders] Gemini inference failed (dictionary only): %v", err)
+		} else {
+			aiUsed = true
+			remaining := unresolved[:0]
+			for _, p := range unresolved {
+				if gender, ok := aiGenders[p.Name]; ok {
+					if isLegacyDefaultGender(p) && gender == p.Gender {
+						continue
+					}
+					proposals = append(proposals, GenderProposal{PersonID: p.ID, Name: p.Name, CurrentGender: p.Gender, ProposedGender: gender, Source: "ai"})
+				} else {
+					remaining = append(remaining, p)
+				}
+			}
+			unresolved = remaining
+		}
+	}
+
+	byID := make(map[string]models.Person, len(people))
+	for _, p := range people {
+		byID[p.ID] = p
+	}
+
+	updated := 0
+	if apply && len(proposals) > 0 {
+		now := time.Now()
+		batch := h.client.Batch()
+		pending := 0
+		for _, proposal := range proposals {
+			p := byID[proposal.PersonID]
+			updates := []firestore.Update{
+				{Path: "gender", Value: proposal.ProposedGender},
+				{Path: "gender_defaulted", Value: false},
+				{Path: "updated_at", Value: now},
+			}
+			// Regenerate the avatar only if it is still the generated default for the old gender
+			if p.Avatar == generateGenderAvatar(p.Name, p.Gender) {
+				updates = append(updates, firestore.Update{Path: "avatar", Value: generateGenderAvatar(p.Name, proposal.ProposedGender)})
+			}
+			batch.Update(h.coll(ctx, "people").Doc(p.ID), updates)
+			pending++
+
+			// Firestore batch limit is 500
+			if pending == 500 {
+				if _, err := batch.Commit(ctx); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update genders", "updated_count": updated})
+					return
+				}
+				updated += pending
+				pending = 0
+				batch = h.client.Batch()
+			}
+		}
+		if pending > 0 {
+			if _, err := batch.Commit(ctx); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update genders", "updated_count": updated})
+				return
+			}
+			updated += pending
+		}
+		userID, _ := c.Get("user_id")
+		log.Printf("[InferGenders] Updated %d genders (by %v)", updated, userID)
+	}
+
+	unresolvedNames := make([]string, 0, len(unresolved))
+	for _, p := range unresolved {
+		unresolvedNames = append(unresolvedNames, p.Name)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":         !apply,
+		"include_legacy":  includeLegacy,
+		"ai_enhanced":     aiUsed,
+		"candidate_count": candidates,
+		"proposals":       proposals,
+		"unresolved":      unresolvedNames,
+		"updated_count":   updated,
+	})
+}
+
+// pickCanonicalName chooses the most used spelling; ties prefer the already-normalized form
+func pickCanonicalName(variants map[string]int) string {
+	spellings := make([]string, 0, len(variants))
+	for name := range variants {
+		spellings = append(spellings, name)
+	}
+	sort.Slice(spellings, func(i, j int) bool {
+		a, b := spellings[i], spellings[j]
+		if variants[a] != variants[b] {
+			return variants[a] > variants[b]
+		}
+		aNorm := utils.NormalizePersianNameKeepSpaces(a) == a
+		bNorm := utils.NormalizePersianNameKeepSpaces(b) == b
+		if aNorm != bNorm {
+			return aNorm
+		}
+		return a < b
+	})
+	return spellings[0]
+}
+
+func normalizeNamesMessage(apply bool, toUpdate, updated int) string {
+	if !apply {
+		return fmt.Sprintf("Dry run: %d people would be renamed. Re-send with ?apply=true to update them.", toUpdate)
+	}
+	return fmt.Sprintf("Renamed %d people to their canonical spelling", updated)
+}
+
+// RunIntegritySweep runs the referential-integrity sweep now instead of waiting for the
+// scheduled one and returns its counts (admin only)
+func (h *FirestoreTreeHandler) RunIntegritySweep(c *gin.Context) {
+	ctx := treeContext(c)
+	result, err := NewReferentialIntegrityService(h.client).SweepDanglingReferences(ctx)
+	if errors.Is(err, errSweepRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": "An integrity sweep is already running"})
+		return
+	}
+	if err != nil {
+		log.Printf("[RefIntegrity] On-demand sweep failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run integrity sweep"})
+		return
+	}
+
+	if result.PeopleCleaned > 0 || result.UsersCleaned > 0 {
+		recordAudit(ctx, h.client, c, "integrity_sweep", "", map[string]interface{}{
+			"people_cleaned": result.PeopleCleaned,
+			"users_cleaned":  result.UsersCleaned,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RecountLikes resets every person's likes_count to the number of distinct users in
+// liked_by and returns how many were corrected (admin only)
+func (h *FirestoreTreeHandler) RecountLikes(c *gin.Context) {
+	ctx := treeContext(c)
+	result, err := NewReferentialIntegrityService(h.client).RecomputeLikeCounts(ctx)
+	if err != nil {
+		log.Printf("[RefIntegrity] Like recount failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recount likes"})
+		return
+	}
+
+	if result.PeopleFixed > 0 {
+		recordAudit(ctx, h.client, c, "likes_recount", "", map[string]interface{}{
+			"people_fixed": result.PeopleFixed,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RepairRelationships checks parent/child consistency across the tree.
+// Dry-run by default; ?apply=true removes dangling, self and duplicate children entries.
+func (h *FirestoreTreeHandler) RepairRelationships(c *gin.Context) {
+	apply := c.Query("apply") == "true"
+	ctx := treeContext(c)
+
+	report, err := NewReferentialIntegrityService(h.client).RepairBidirectional(ctx, apply)
+	if err != nil {
+		if report != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to repair relationships", "fixed_count": report.FixedCount})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	if apply && report.FixedCount > 0 {
+		recordAudit(ctx, h.client, c, "relationships_repair", "", map[string]interface{}{
+			"issues":      len(report.Issues),
+			"fixed_count": report.FixedCount,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":       !apply,
+		"checked_count": report.CheckedCount,
+		"issue_count":   len(report.Issues),
+		"issues":        report.Issues,
+		"multi_parent":  report.MultiParent,
+		"fixed_count":   report.FixedCount,
+	})
+}
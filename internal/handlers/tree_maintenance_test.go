@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+func TestIsGenderInferenceCandidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		person        models.Person
+		includeLegacy bool
+		want          bool
+	}{
+		{"empty gender", models.Person{}, false, true},
+		{"defaulted", models.Person{Gender: "male", GenderDefaulted: true}, false, true},
+		{"explicit female", models.Person{Gender: "female"}, false, false},
+		{"explicit female with legacy", models.Person{Gender: "female"}, true, false},
+		{"legacy male without flag", models.Person{Gender: "male"}, false, false},
+		{"legacy male with flag", models.Person{Gender: "male"}, true, true},
+	}
+	for _, tt := range tests {
+		if got := isGenderInferenceCandidate(tt.person, tt.includeLegacy); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+var (
+	errNotAParent = errors.New("from_parent_id is not a parent of this person")
+	errMoveCycle  = errors.New("Moving this person under their own descendant would create a cycle")
+)
+
+// MovePerson re-homes a person, and with them their whole subtree, under a new parent.
+// The person is removed from the current parents' children (or only from from_parent_id)
+// and added to the new parent in one transaction. Moving a person under themselves or
+// one of their descendants is rejected.
+func (h *FirestoreTreeHandler) MovePerson(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.MovePersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new_parent_id is required"})
+		return
+	}
+	if !validDocID(req.NewParentID) || (req.FromParentID != "" && !validDocID(req.FromParentID)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent id"})
+		return
+	}
+	if req.NewParentID == id {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A person cannot be moved under themselves"})
+		return
+	}
+	if req.FromParentID == req.NewParentID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new_parent_id must differ from from_parent_id"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	ctx := treeContext(c)
+	settings := loadTreeSettings(ctx, h.client)
+
+	var person models.Person
+	var oldParentIDs []string
+	forbidden := false
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		forbidden = false
+		oldParentIDs = nil
+		personRef := h.coll(ctx, "people").Doc(id)
+		newParentRef := h.coll(ctx, "people").Doc(req.NewParentID)
+		docs, err := tx.GetAll([]*firestore.DocumentRef{personRef, newParentRef})
+		if err != nil {
+			return err
+		}
+		if !docs[0].Exists() || !docs[1].Exists() {
+			return errPersonNotFound
+		}
+		if err := docs[0].DataTo(&person); err != nil {
+			return err
+		}
+		if !canModifyPerson(settings, person, userID.(string), role.(string)) {
+			forbidden = true
+			return nil
+		}
+
+		// The new parent must not sit inside the subtree being moved
+		ancestors, err := findAncestorIDsTx(ctx, tx, h.client, req.NewParentID)
+		if err != nil {
+			return err
+		}
+		if ancestors[id] {
+			return errMoveCycle
+		}
+
+		parentDocs, err := tx.Documents(h.coll(ctx, "people").Where("children", "array-contains", id)).GetAll()
+		if err != nil {
+			return err
+		}
+		var keptParentIDs []string
+		var removeFrom []*firestore.DocumentRef
+		for _, doc := range parentDocs {
+			oldParentIDs = append(oldParentIDs, doc.Ref.ID)
+			switch {
+			case doc.Ref.ID == req.NewParentID:
+				// Already a child there; keep the link
+			case req.FromParentID == "" || doc.Ref.ID == req.FromParentID:
+				removeFrom = append(removeFrom, doc.Ref)
+				continue
+			}
+			keptParentIDs = append(keptParentIDs, doc.Ref.ID)
+		}
+		if req.FromParentID != "" && !containsString(oldParentIDs, req.FromParentID) {
+			return errNotAParent
+		}
+
+		now := time.Now()
+		for _, ref := range removeFrom {
+			if err := tx.Update(ref, []firestore.Update{
+				{Path: "children", Value: firestore.ArrayRemove(id)},
+				{Path: "updated_at", Value: now},
+			}); err != nil {
+				return err
+			}
+		}
+		if err := tx.Update(newParentRef, []firestore.Update{
+			{Path: "children", Value: firestore.ArrayUnion(id)},
+			{Path: "updated_at", Value: now},
+		}); err != nil {
+			return err
+		}
+
+		person.ParentIDs = uniqueIDs(append(keptParentIDs, req.NewParentID))
+		person.UpdatedAt = now
+		return tx.Update(personRef, []firestore.Update{
+			{Path: "parent_ids", Value: person.ParentIDs},
+			{Path: "updated_at", Value: now},
+		})
+	})
+	switch {
+	case err == errPersonNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person or new parent not found"})
+		return
+	case err == errNotAParent, err == errMoveCycle:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("[MovePerson] Failed to move %s under %s: %v", id, req.NewParentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move person"})
+		return
+	case forbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": modifyDeniedMessage(settings, "edit")})
+		return
+	}
+
+	log.Printf("[MovePerson] %s moved %s from %v to %s", userID, id, oldParentIDs, req.NewParentID)
+	recordAudit(ctx, h.client, c, "person_move", id, map[string]interface{}{
+		"old_parent_ids": oldParentIDs,
+		"new_parent_id":  req.NewParentID,
+	})
+
+	person.RelationshipType = person.ParentRelationship()
+	c.JSON(http.StatusOK, person)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// RequireIdentityLink enforces the tree's require_identity_link setting: when enabled, only
+// users linked to a person in the tree may continue. Admins are exempt so the tree owner
+// cannot lock themselves out. Must run after AuthMiddleware.
+func RequireIdentityLink(client *firestore.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := treeContext(c)
+		if !loadTreeSettings(ctx, client).RequireIdentityLink {
+			c.Next()
+			return
+		}
+
+		role, _ := c.Get("role")
+		if roleStr, _ := role.(string); models.UserRole(roleStr) == models.RoleAdmin {
+			c.Next()
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(string)
+		iter := database.TreeCollection(ctx, client, "people").Where("linked_user_id", "==", uid).Limit(1).Documents(ctx)
+		_, err := iter.Next()
+		iter.Stop()
+		if uid == "" || err != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "This tree requires you to claim your own person in the tree before contributing",
+				"code":  "identity_link_required",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireWritableTree rejects writes with 503 while the tree's read_only setting is on.
+// Admins pass when read_only_exempt_admins is set. Must run after AuthMiddleware.
+func RequireWritableTree(client *firestore.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings := loadTreeSettings(treeContext(c), client)
+		if !settings.ReadOnly {
+			c.Next()
+			return
+		}
+
+		role, _ := c.Get("role")
+		if roleStr, _ := role.(string); settings.ReadOnlyExemptAdmins && models.UserRole(roleStr) == models.RoleAdmin {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "The tree is read-only right now. Please try again later.",
+			"code":  "tree_read_only",
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+	"google.golang.org/api/iterator"
+)
+
+// maxBirthAgeYears is how far back a birth year may lie (MAX_BIRTH_AGE_YEARS, default 1000)
+var maxBirthAgeYears = func() int {
+	if v := os.Getenv("MAX_BIRTH_AGE_YEARS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 1000
+}()
+
+// validateBirth checks that a person's birth year is plausible
+func validateBirth(birth string) error {
+	return utils.ValidateBirthYear(birth, maxBirthAgeYears, time.Now())
+}
+
+// validateDeath checks that a person's death year is plausible given their birth
+func validateDeath(death, birth string) error {
+	return utils.ValidateDeathYear(death, birth, time.Now())
+}
+
+// SanityCheckPerson asks Gemini to flag implausible birth dates for a person relative to
+// their parents and children (admin only). Returns 503 when Gemini is not configured.
+func (h *FirestoreTreeHandler) SanityCheckPerson(c *gin.Context) {
+	id := c.Param("id")
+
+	if os.Getenv("GEMINI_API_KEY") == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "AI sanity check is not configured (GEMINI_API_KEY unset)"})
+		return
+	}
+
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+	var person models.Person
+	if err := doc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+
+	// Parents are the people listing this person as a child
+	parents := []utils.SanityCheckPerson{}
+	iter := h.coll(ctx, "people").Where("children", "array-contains", id).Documents(ctx)
+	defer iter.Stop()
+	for {
+		parentDoc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parents"})
+			return
+		}
+		var parent models.Person
+		if err := parentDoc.DataTo(&parent); err != nil {
+			continue
+		}
+		parents = append(parents, utils.SanityCheckPerson{ID: parent.ID, Name: parent.Name, Birth: parent.Birth})
+	}
+
+	children := []utils.SanityCheckPerson{}
+	for _, childID := range person.Children {
+		childDoc, err := h.coll(ctx, "people").Doc(childID).Get(ctx)
+		if err != nil {
+			continue // Dangling child reference
+		}
+		var child models.Person
+		if err := childDoc.DataTo(&child); err != nil {
+			continue
+		}
+		children = append(children, utils.SanityCheckPerson{ID: child.ID, Name: child.Name, Birth: child.Birth})
+	}
+
+	subject := utils.SanityCheckPerson{ID: person.ID, Name: person.Name, Birth: person.Birth}
+	warnings, err := utils.CheckDateSanityWithGemini(subject, parents, children)
+	if err != nil {
+		log.Printf("[SanityCheck] Gemini check failed for %s: %v", id, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "AI sanity check failed"})
+		return
+	}
+	if warnings == nil {
+		warnings = []utils.GeminiDateWarning{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"person":   subject,
+		"parents":  parents,
+		"children": children,
+		"warnings": warnings,
+	})
+}
+
+// GetDateIssues runs the deterministic date consistency check over the whole tree (admin only).
+// ?min_parent_age= sets the youngest plausible parenting age (default 12).
+func (h *FirestoreTreeHandler) GetDateIssues(c *gin.Context) {
+	minParentAge := 12
+	if v := c.Query("min_parent_age"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_parent_age must be a positive integer"})
+			return
+		}
+		minParentAge = parsed
+	}
+
+	ctx := treeContext(c)
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	dated := make([]utils.DatedPerson, len(people))
+	for i, p := range people {
+		dated[i] = utils.DatedPerson{ID: p.ID, Name: p.Name, Birth: p.Birth, Children: p.Children}
+	}
+	issues := utils.ValidateTreeDateConsistency(dated, minParentAge)
+
+	c.JSON(http.StatusOK, gin.H{
+		"min_parent_age": minParentAge,
+		"checked_count":  len(people),
+		"issue_count":    len(issues),
+		"issues":         issues,
+	})
+}
+
+// VerifyPersonData marks a person's record as vetted by the calling admin/co-admin
+func (h *FirestoreTreeHandler) VerifyPersonData(c *gin.Context) {
+	h.setDataVerified(c, true)
+}
+
+// ClearPersonDataVerification removes the vetted mark from a person's record
+func (h *FirestoreTreeHandler) ClearPersonDataVerification(c *gin.Context) {
+	h.setDataVerified(c, false)
+}
+
+// setDataVerified stores or clears the data-verified badge of the person in :id
+func (h *FirestoreTreeHandler) setDataVerified(c *gin.Context, verified bool) {
+	id := c.Param("id")
+	userID, _ := c.Get("user_id")
+	ctx := treeContext(c)
+
+	ref := h.coll(ctx, "people").Doc(id)
+	if _, err := ref.Get(ctx); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+
+	now := time.Now()
+	verifiedBy := ""
+	verifiedAt := time.Time{}
+	if verified {
+		verifiedBy = userID.(string)
+		verifiedAt = now
+	}
+
+	if _, err := ref.Update(ctx, []firestore.Update{
+		{Path: "data_verified", Value: verified},
+		{Path: "data_verified_by", Value: verifiedBy},
+		{Path: "data_verified_at", Value: verifiedAt},
+		{Path: "updated_at", Value: now},
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update verification"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"person_id":        id,
+		"data_verified":    verified,
+		"data_verified_by": verifiedBy,
+		"data_verified_at": verifiedAt,
+	})
+}
+
+// incompleteFields are the completeness checks GetIncompletePeople can report on
+var incompleteFields = []string{"birth", "gender", "avatar", "role"}
+
+// missingFields returns which completeness checks the person fails
+func missingFields(p models.Person) []string {
+	var missing []string
+	if strings.TrimSpace(p.Birth) == "" {
+		missing = append(missing, "birth")
+	}
+	if p.Gender == "" {
+		missing = append(missing, "gender")
+	}
+	if strings.TrimSpace(p.Avatar) == "" {
+		missing = append(missing, "avatar")
+	}
+	if role := strings.TrimSpace(p.Role); role == "" || role == defaultPersonRole {
+		missing = append(missing, "role")
+	}
+	return missing
+}
+
+// GetIncompletePeople lists people missing birth, gender, avatar or a real role
+// (empty or the import placeholder), grouped by field (admin only).
+// ?fields=birth,gender limits the report to those fields.
+func (h *FirestoreTreeHandler) GetIncompletePeople(c *gin.Context) {
+	selected := make(map[string]bool)
+	if raw := c.Query("fields"); raw != "" {
+		for _, f := range strings.Split(raw, ",") {
+			f = strings.TrimSpace(f)
+			if !containsString(incompleteFields, f) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown field: " + f, "allowed": incompleteFields})
+				return
+			}
+			selected[f] = true
+		}
+	} else {
+		for _, f := range incompleteFields {
+			selected[f] = true
+		}
+	}
+
+	ctx := treeContext(c)
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	groups := make(map[string][]models.PersonSummary)
+	for f := range selected {
+		groups[f] = []models.PersonSummary{}
+	}
+	incomplete := 0
+	for _, p := range people {
+		flagged := false
+		for _, f := range missingFields(p) {
+			if selected[f] {
+				groups[f] = append(groups[f], p.Summary())
+				flagged = true
+			}
+		}
+		if flagged {
+			incomplete++
+		}
+	}
+
+	counts := make(map[string]int, len(groups))
+	for f, list := range groups {
+		counts[f] = len(list)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"checked_count":    len(people),
+		"incomplete_count": incomplete,
+		"counts":           counts,
+		"missing":          groups,
+	})
+}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// maxLineageDepth bounds the walk up the tree in case of corrupt data
+const maxLineageDepth = 200
+
+// findParents returns the people listing id as a child
+func findParents(ctx context.Context, client *firestore.Client, id string) ([]models.Person, error) {
+	iter := database.TreeCollection(ctx, client, "people").Where("children", "array-contains", id).Documents(ctx)
+	defer iter.Stop()
+
+	var parents []models.Person
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var parent models.Person
+		if err := doc.DataTo(&parent); err != nil {
+			continue
+		}
+		parents = append(parents, parent)
+	}
+	return parents, nil
+}
+
+// coParentQueryLimit is how many values one array-contains-any filter may hold
+const coParentQueryLimit = 10
+
+// findCoParents returns the other parents of the given person's children
+func findCoParents(ctx context.Context, client *firestore.Client, person models.Person) ([]models.Person, error) {
+	childIDs := uniqueIDs(person.Children)
+	seen := map[string]bool{person.ID: true}
+	var coParents []models.Person
+	for start := 0; start < len(childIDs); start += coParentQueryLimit {
+		end := start + coParentQueryLimit
+		if end > len(childIDs) {
+			end = len(childIDs)
+		}
+		docs, err := database.TreeCollection(ctx, client, "people").
+			Where("children", "array-contains-any", childIDs[start:end]).
+			Documents(ctx).GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			var parent models.Person
+			if err := doc.DataTo(&parent); err != nil || seen[parent.ID] {
+				continue
+			}
+			seen[parent.ID] = true
+			coParents = append(coParents, parent)
+		}
+	}
+	return coParents, nil
+}
+
+// findPartners returns the person's recorded spouses followed by any other parents of
+// their children who are not also spouses
+func findPartners(ctx context.Context, client *firestore.Client, person models.Person) ([]models.Person, error) {
+	partners, err := fetchPeopleByIDs(ctx, client, uniqueIDs(person.Spouses))
+	if err != nil {
+		return nil, err
+	}
+	coParents, err := findCoParents(ctx, client, person)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range coParents {
+		if !containsString(person.Spouses, p.ID) {
+			partners = append(partners, p)
+		}
+	}
+	return partners, nil
+}
+
+// pickLineageParent chooses which parent to follow: the one matching the preferred gender,
+// otherwise the first parent found
+func pickLineageParent(parents []models.Person, preferGender string) models.Person {
+	for _, p := range parents {
+		if p.Gender == preferGender {
+			return p
+		}
+	}
+	return parents[0]
+}
+
+// ancestorChain walks up from person at most maxDepth generations and returns the
+// ancestors nearest first (excluding the person), and whether a cycle stopped the walk
+func ancestorChain(ctx context.Context, client *firestore.Client, person models.Person, preferGender string, maxDepth int) ([]models.Person, bool, error) {
+	ancestors := []models.Person{}
+	visited := map[string]bool{person.ID: true}
+	current := person
+	for len(ancestors) < maxDepth {
+		parents, err := findParents(ctx, client, current.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(parents) == 0 {
+			break
+		}
+		parent := pickLineageParent(parents, preferGender)
+		if visited[parent.ID] {
+			return ancestors, true, nil
+		}
+		visited[parent.ID] = true
+		ancestors = append(ancestors, parent)
+		current = parent
+	}
+	return ancestors, false, nil
+}
+
+// lineageChain walks up from person and returns the chain starting at the root ancestor,
+// and whether a cycle stopped the walk
+func lineageChain(ctx context.Context, client *firestore.Client, person models.Person, preferGender string) ([]models.Person, bool, error) {
+	ancestors, cycleDetected, err := ancestorChain(ctx, client, person, preferGender, maxLineageDepth-1)
+	if err != nil {
+		return nil, false, err
+	}
+	chain := append([]models.Person{person}, ancestors...)
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	for i := range chain {
+		chain[i].RelationshipType = chain[i].ParentRelationship()
+	}
+	return chain, cycleDetected, nil
+}
+
+// GetLineage returns the direct line from a root ancestor down to the person.
+// With several parents the father's line is followed; ?via=mother follows the mother's.
+func (h *FirestoreTreeHandler) GetLineage(c *gin.Context) {
+	id := c.Param("id")
+
+	preferGender := "male"
+	switch c.DefaultQuery("via", "father") {
+	case "father":
+	case "mother":
+		preferGender = "female"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "via must be 'father' or 'mother'"})
+		return
+	}
+
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+	var person models.Person
+	if err := doc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+
+	chain, cycleDetected, err := lineageChain(ctx, h.client, person, preferGender)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"person_id":      person.ID,
+		"lineage":        chain,
+		"depth":          len(chain) - 1,
+		"cycle_detected": cycleDetected,
+	})
+}
+
+// GetAncestors returns the person's direct ancestors from their parent up to the root,
+// following the father's line with several parents (?via=mother follows the mother's).
+// ?max_depth= limits how many generations are walked (default and maximum maxLineageDepth).
+func (h *FirestoreTreeHandler) GetAncestors(c *gin.Context) {
+	id := c.Param("id")
+
+	preferGender := "male"
+	switch c.DefaultQuery("via", "father") {
+	case "father":
+	case "mother":
+		preferGender = "female"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "via must be 'father' or 'mother'"})
+		return
+	}
+	maxDepth := maxLineageDepth
+	if v := c.Query("max_depth"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_depth must be a positive integer"})
+			return
+		}
+		if n < maxDepth {
+			maxDepth = n
+		}
+	}
+
+	ctx := treeContext(c)
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+	var person models.Person
+	if err := doc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+
+	ancestors, cycleDetected, err := ancestorChain(ctx, h.client, person, preferGender, maxDepth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parents"})
+		return
+	}
+	for i := range ancestors {
+		ancestors[i].RelationshipType = ancestors[i].ParentRelationship()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"person_id":      person.ID,
+		"ancestors":      ancestors,
+		"depth":          len(ancestors),
+		"cycle_detected": cycleDetected,
+	})
+}
+
+// fetchPeopleByIDs batch-reads people by id, preserving order and skipping missing ones
+func fetchPeopleByIDs(ctx context.Context, client *firestore.Client, ids []string) ([]models.Person, error) {
+	if len(ids) == 0 {
+		return []models.Person{}, nil
+	}
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = database.TreeCollection(ctx, client, "people").Doc(id)
+	}
+	docs, err := client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	people := make([]models.Person, 0, len(docs))
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			continue
+		}
+		people = append(people, person)
+	}
+	return people, nil
+}
+
+// summarize converts people to their lightweight summaries
+func summarize(people []models.Person) []models.PersonSummary {
+	summaries := make([]models.PersonSummary, len(people))
+	for i, p := range people {
+		summaries[i] = p.Summary()
+	}
+	return summaries
+}
+
+// GetPersonNetwork returns the person with their parents, siblings, spouses and children as summaries.
+// Entries that would make the person their own relative (cyclic data) are dropped and
+// reported via cycle_detected.
+func (h *FirestoreTreeHandler) GetPersonNetwork(c *gin.Context) {
+	id := c.Param("id")
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "people").Doc(id).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+	var person models.Person
+	if err := doc.DataTo(&person); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse person data"})
+		return
+	}
+
+	parents, err := findParents(ctx, h.client, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch parents"})
+		return
+	}
+
+	// Anything already classified (self or a parent) can't also be a sibling or child
+	cycleDetected := false
+	taken := map[string]bool{id: true}
+	for _, p := range parents {
+		taken[p.ID] = true
+	}
+
+	var childIDs []string
+	for _, childID := range person.Children {
+		if taken[childID] {
+			cycleDetected = true
+			continue
+		}
+		taken[childID] = true
+		childIDs = append(childIDs, childID)
+	}
+
+	var siblingIDs []string
+	for _, p := range parents {
+		for _, siblingID := range p.Children {
+			if siblingID == id {
+				continue
+			}
+			if taken[siblingID] {
+				if !containsString(siblingIDs, siblingID) {
+					cycleDetected = true
+				}
+				continue
+			}
+			taken[siblingID] = true
+			siblingIDs = append(siblingIDs, siblingID)
+		}
+	}
+
+	related, err := fetchPeopleByIDs(ctx, h.client, append(childIDs, siblingIDs...))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch relatives"})
+		return
+	}
+	isChild := make(map[string]bool, len(childIDs))
+	for _, childID := range childIDs {
+		isChild[childID] = true
+	}
+	children, siblings := []models.Person{}, []models.Person{}
+	for _, p := range related {
+		if isChild[p.ID] {
+			children = append(children, p)
+		} else {
+			siblings = append(siblings, p)
+		}
+	}
+
+	spouses, err := fetchPeopleByIDs(ctx, h.client, uniqueIDs(person.Spouses))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch spouses"})
+		return
+	}
+
+	person.RelationshipType = person.ParentRelationship()
+	c.JSON(http.StatusOK, gin.H{
+		"person":         person,
+		"parents":        summarize(parents),
+		"siblings":       summarize(siblings),
+		"spouses":        summarize(spouses),
+		"children":       summarize(children),
+		"cycle_detected": cycleDetected,
+	})
+}
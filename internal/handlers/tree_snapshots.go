@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mamiri/findyourroot/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// snapshotChunkSize is how many people are stored per snapshot chunk document,
+// keeping each document well under Firestore's 1 MiB limit
+const snapshotChunkSize = 200
+
+// TreeSnapshot is a named point-in-time copy of the people collection.
+// The people themselves are stored in the "chunks" subcollection.
+type TreeSnapshot struct {
+	ID          string    `json:"id" firestore:"id"`
+	Name        string    `json:"name" firestore:"name"`
+	Hash        string    `json:"hash" firestore:"hash"` // SHA-256 over the sorted snapshot people
+	PeopleCount int       `json:"people_count" firestore:"people_count"`
+	ChunkCount  int       `json:"chunk_count" firestore:"chunk_count"`
+	CreatedBy   string    `json:"created_by" firestore:"created_by"`
+	CreatedAt   time.Time `json:"created_at" firestore:"created_at"`
+}
+
+// SnapshotPerson is the compact form of a person kept in a snapshot
+type SnapshotPerson struct {
+	ID         string   `json:"id" firestore:"id"`
+	Name       string   `json:"name" firestore:"name"`
+	AltNames   []string `json:"alt_names" firestore:"alt_names"`
+	Role       string   `json:"role" firestore:"role"`
+	Occupation string   `json:"occupation" firestore:"occupation"`
+	Gender     string   `json:"gender" firestore:"gender"`
+	Birth      string   `json:"birth" firestore:"birth"`
+	Death      string   `json:"death,omitempty" firestore:"death"`       // omitempty keeps hashes of older snapshots stable
+	Deceased   bool     `json:"deceased,omitempty" firestore:"deceased"` // omitempty keeps hashes of older snapshots stable
+	Location   string   `json:"location" firestore:"location"`
+	Avatar     string   `json:"avatar" firestore:"avatar"`
+	Bio        string   `json:"bio" firestore:"bio"`
+	Children   []string `json:"children" firestore:"children"`
+}
+
+type snapshotChunk struct {
+	Index  int              `firestore:"index"`
+	People []SnapshotPerson `firestore:"people"`
+}
+
+// FieldChange is one changed field of a modified person
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ModifiedPerson is a person present in both the snapshot and the current tree with changes
+type ModifiedPerson struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// CreateSnapshotRequest names a new snapshot
+type CreateSnapshotRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// toSnapshotPerson converts a person to its snapshot form with sorted children for stable diffs
+func toSnapshotPerson(p models.Person) SnapshotPerson {
+	children := append([]string{}, p.Children...)
+	sort.Strings(children)
+	altNames := p.AltNames
+	if altNames == nil {
+		altNames = []string{}
+	}
+	return SnapshotPerson{
+		ID:         p.ID,
+		Name:       p.Name,
+		AltNames:   altNames,
+		Role:       p.Role,
+		Occupation: p.Occupation,
+		Gender:     p.Gender,
+		Birth:      p.Birth,
+		Death:      p.Death,
+		Deceased:   p.Deceased,
+		Location:   p.Location,
+		Avatar:     p.Avatar,
+		Bio:        p.Bio,
+		Children:   children,
+	}
+}
+
+// snapshotPeople converts and sorts people by ID, returning them with their content hash
+func snapshotPeople(people []models.Person) ([]SnapshotPerson, string) {
+	result := make([]SnapshotPerson, len(people))
+	for i, p := range people {
+		result[i] = toSnapshotPerson(p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	data, _ := json.Marshal(result)
+	sum := sha256.Sum256(data)
+	return result, hex.EncodeToString(sum[:])
+}
+
+// CreateSnapshot stores a named snapshot of the current people set (admin only)
+func (h *FirestoreTreeHandler) CreateSnapshot(c *gin.Context) {
+	var req CreateSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	ctx := treeContext(c)
+
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+	compact, hash := snapshotPeople(people)
+
+	snapshot := TreeSnapshot{
+		ID:          uuid.New().String(),
+		Name:        strings.TrimSpace(req.Name),
+		Hash:        hash,
+		PeopleCount: len(compact),
+		ChunkCount:  (len(compact) + snapshotChunkSize - 1) / snapshotChunkSize,
+		CreatedBy:   userID.(string),
+		CreatedAt:   time.Now(),
+	}
+
+	ref := h.coll(ctx, "tree_snapshots").Doc(snapshot.ID)
+	batch := h.client.Batch()
+	pending := 0
+	for i := 0; i < snapshot.ChunkCount; i++ {
+		end := (i + 1) * snapshotChunkSize
+		if end > len(compact) {
+			end = len(compact)
+		}
+		batch.Set(ref.Collection("chunks").Doc(chunkDocID(i)), snapshotChunk{Index: i, People: compact[i*snapshotChunkSize : end]})
+		pending++
+
+		// Keep batches small: each chunk can be large
+		if pending == 20 {
+			if _, err := batch.Commit(ctx); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save snapshot"})
+				return
+			}
+			pending = 0
+			batch = h.client.Batch()
+		}
+	}
+	// The header is written last so a snapshot is only listed once all chunks exist
+	batch.Set(ref, snapshot)
+	if _, err := batch.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save snapshot"})
+		return
+	}
+
+	log.Printf("[Snapshots] Created snapshot %q (%d people) by %s", snapshot.Name, snapshot.PeopleCount, userID)
+	c.JSON(http.StatusCreated, snapshot)
+}
+
+// GetSnapshots lists stored snapshots, newest first (admin only)
+func (h *FirestoreTreeHandler) GetSnapshots(c *gin.Context) {
+	ctx := treeContext(c)
+
+	iter := h.coll(ctx, "tree_snapshots").OrderBy("created_at", firestore.Desc).Documents(ctx)
+	defer iter.Stop()
+
+	snapshots := []TreeSnapshot{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch snapshots"})
+			return
+		}
+		var s TreeSnapshot
+		if err := doc.DataTo(&s); err != nil {
+			continue
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	c.JSON(http.StatusOK, snapshots)
+}
+
+// DiffSnapshot compares a snapshot with the current tree, returning added, removed and
+// field-level modified people (admin only)
+func (h *FirestoreTreeHandler) DiffSnapshot(c *gin.Context) {
+	id := c.Param("id")
+	ctx := treeContext(c)
+
+	ref := h.coll(ctx, "tree_snapshots").Doc(id)
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+		return
+	}
+	var snapshot TreeSnapshot
+	if err := doc.DataTo(&snapshot); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse snapshot"})
+		return
+	}
+
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+	current, hash := snapshotPeople(people)
+
+	added := []SnapshotPerson{}
+	removed := []SnapshotPerson{}
+	modified := []ModifiedPerson{}
+
+	if hash != snapshot.Hash {
+		before, err := loadSnapshotPeople(ctx, ref)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load snapshot data"})
+			return
+		}
+
+		beforeByID := make(map[string]SnapshotPerson, len(before))
+		for _, p := range before {
+			beforeByID[p.ID] = p
+		}
+		currentIDs := make(map[string]bool, len(current))
+		for _, p := range current {
+			currentIDs[p.ID] = true
+			old, existed := beforeByID[p.ID]
+			if !existed {
+				added = append(added, p)
+				continue
+			}
+			if changes := diffSnapshotPerson(old, p); len(changes) > 0 {
+				modified = append(modified, ModifiedPerson{ID: p.ID, Name: p.Name, Changes: changes})
+			}
+		}
+		for _, p := range before {
+			if !currentIDs[p.ID] {
+				removed = append(removed, p)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"snapshot":  snapshot,
+		"unchanged": hash == snapshot.Hash,
+		"added":     added,
+		"removed":   removed,
+		"modified":  modified,
+		"summary": gin.H{
+			"added":    len(added),
+			"removed":  len(removed),
+			"modified": len(modified),
+		},
+	})
+}
+
+// loadSnapshotPeople reads every chunk of a snapshot
+func loadSnapshotPeople(ctx context.Context, ref *firestore.DocumentRef) ([]SnapshotPerson, error) {
+	iter := ref.Collection("chunks").OrderBy("index", firestore.Asc).Documents(ctx)
+	defer iter.Stop()
+
+	var people []SnapshotPerson
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var chunk snapshotChunk
+		if err := doc.DataTo(&chunk); err != nil {
+			return nil, err
+		}
+		people = append(people, chunk.People...)
+	}
+	return people, nil
+}
+
+// diffSnapshotPerson lists the fields that differ between two versions of a person
+func diffSnapshotPerson(before, after SnapshotPerson) []FieldChange {
+	var changes []FieldChange
+	bv := reflect.ValueOf(before)
+	av := reflect.ValueOf(after)
+	t := bv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		b, a := bv.Field(i).Interface(), av.Field(i).Interface()
+		if !reflect.DeepEqual(b, a) {
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			changes = append(changes, FieldChange{Field: name, Before: b, After: a})
+		}
+	}
+	return changes
+}
+
+// chunkDocID returns a zero-padded chunk document ID so chunks sort naturally
+func chunkDocID(i int) string {
+	return fmt.Sprintf("chunk-%04d", i)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+var (
+	errAlreadySpouses = errors.New("already spouses")
+	errNotSpouses     = errors.New("not spouses")
+)
+
+// LinkSpouse records two people as spouses. The link is symmetric, so both people's
+// spouses arrays are updated in one transaction.
+func (h *FirestoreTreeHandler) LinkSpouse(c *gin.Context) {
+	h.updateSpouseLink(c, c.Param("id"), true)
+}
+
+// UnlinkSpouse removes the spouse link between two people on both sides
+func (h *FirestoreTreeHandler) UnlinkSpouse(c *gin.Context) {
+	h.updateSpouseLink(c, c.Param("spouse_id"), false)
+}
+
+// updateSpouseLink adds or removes the spouse link between :id and the other person.
+// For linking, the other person comes from the request body.
+func (h *FirestoreTreeHandler) updateSpouseLink(c *gin.Context, spouseID string, link bool) {
+	id := c.Param("id")
+	if link {
+		var req models.SpouseRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "spouse_id is required"})
+			return
+		}
+		spouseID = req.SpouseID
+	}
+	if spouseID == id {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A person cannot be their own spouse"})
+		return
+	}
+	if !validDocID(spouseID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spouse_id"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	ctx := treeContext(c)
+	settings := loadTreeSettings(ctx, h.client)
+
+	var person models.Person
+	forbidden := false
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		forbidden = false
+		personRef := h.coll(ctx, "people").Doc(id)
+		spouseRef := h.coll(ctx, "people").Doc(spouseID)
+		docs, err := tx.GetAll([]*firestore.DocumentRef{personRef, spouseRef})
+		if err != nil {
+			return err
+		}
+		if !docs[0].Exists() || !docs[1].Exists() {
+			return errPersonNotFound
+		}
+		if err := docs[0].DataTo(&person); err != nil {
+			return err
+		}
+		if !canModifyPerson(settings, person, userID.(string), role.(string)) {
+			forbidden = true
+			return nil
+		}
+
+		linked := containsString(person.Spouses, spouseID)
+		if link && linked {
+			return errAlreadySpouses
+		}
+		if !link && !linked {
+			return errNotSpouses
+		}
+
+		now := time.Now()
+		var personChange, spouseChange interface{} = firestore.ArrayUnion(spouseID), firestore.ArrayUnion(id)
+		if !link {
+			personChange, spouseChange = firestore.ArrayRemove(spouseID), firestore.ArrayRemove(id)
+		}
+		if err := tx.Update(personRef, []firestore.Update{
+			{Path: "spouses", Value: personChange},
+			{Path: "updated_at", Value: now},
+		}); err != nil {
+			return err
+		}
+		if err := tx.Update(spouseRef, []firestore.Update{
+			{Path: "spouses", Value: spouseChange},
+			{Path: "updated_at", Value: now},
+		}); err != nil {
+			return err
+		}
+
+		if link {
+			person.Spouses = append(person.Spouses, spouseID)
+		} else {
+			kept := []string{}
+			for _, s := range person.Spouses {
+				if s != spouseID {
+					kept = append(kept, s)
+				}
+			}
+			person.Spouses = kept
+		}
+		person.UpdatedAt = now
+		return nil
+	})
+	switch {
+	case err == errPersonNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	case err == errAlreadySpouses:
+		c.JSON(http.StatusConflict, gin.H{"error": "These people are already spouses"})
+		return
+	case err == errNotSpouses:
+		c.JSON(http.StatusConflict, gin.H{"error": "These people are not spouses"})
+		return
+	case err != nil:
+		log.Printf("[Spouse] Failed to update link %s <-> %s: %v", id, spouseID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update spouse link"})
+		return
+	case forbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": modifyDeniedMessage(settings, "edit")})
+		return
+	}
+
+	c.JSON(http.StatusOK, person)
+}
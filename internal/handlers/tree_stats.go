@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	firestorepb "cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+)
+
+// treeStatsCache holds computed tree statistics for a short time
+var treeStatsCache = utils.NewTTLCache(2 * time.Minute)
+
+// treeCacheKey scopes a cache key to the tree ctx is scoped to, so cached results of one
+// tree are never served to another
+func treeCacheKey(ctx context.Context, key string) string {
+	return database.TreeID(ctx) + "/" + key
+}
+
+// BranchSize is a root person with the size of their subtree
+type BranchSize struct {
+	PersonID    string `json:"person_id"`
+	Name        string `json:"name"`
+	Descendants int    `json:"descendants"`
+}
+
+// GetBranchSizes returns every root person with their total descendant count, largest first.
+// Results are cached briefly; ?refresh=true forces a recompute.
+func (h *FirestoreTreeHandler) GetBranchSizes(c *gin.Context) {
+	ctx := treeContext(c)
+	if c.Query("refresh") != "true" {
+		if cached, ok := treeStatsCache.Get(treeCacheKey(ctx, "branch-sizes")); ok {
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+	}
+
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	byID := make(map[string]models.Person, len(people))
+	hasParent := make(map[string]bool)
+	for _, p := range people {
+		byID[p.ID] = p
+		for _, childID := range p.Children {
+			hasParent[childID] = true
+		}
+	}
+
+	memo := make(map[string]int)
+	visiting := make(map[string]bool)
+	branches := []BranchSize{}
+	for _, p := range people {
+		if hasParent[p.ID] {
+			continue
+		}
+		branches = append(branches, BranchSize{
+			PersonID:    p.ID,
+			Name:        p.Name,
+			Descendants: countDescendants(p.ID, byID, memo, visiting),
+		})
+	}
+
+	sort.Slice(branches, func(i, j int) bool {
+		if branches[i].Descendants != branches[j].Descendants {
+			return branches[i].Descendants > branches[j].Descendants
+		}
+		return branches[i].Name < branches[j].Name
+	})
+
+	response := gin.H{
+		"branches":    branches,
+		"total":       len(people),
+		"computed_at": time.Now().Format(time.RFC3339),
+	}
+	treeStatsCache.Set(treeCacheKey(ctx, "branch-sizes"), response)
+
+	log.Printf("[BranchSizes] Computed %d branches over %d people", len(branches), len(people))
+	c.JSON(http.StatusOK, response)
+}
+
+// countDescendants returns the number of descendants below id, memoizing per node.
+// Children already on the current path are skipped so cyclic data cannot recurse forever;
+// dangling child IDs are ignored.
+func countDescendants(id string, byID map[string]models.Person, memo map[string]int, visiting map[string]bool) int {
+	if count, ok := memo[id]; ok {
+		return count
+	}
+	person, ok := byID[id]
+	if !ok {
+		return 0
+	}
+
+	visiting[id] = true
+	count := 0
+	for _, childID := range person.Children {
+		if visiting[childID] {
+			log.Printf("[BranchSizes] Cycle detected at %s -> %s", id, childID)
+			continue
+		}
+		if _, exists := byID[childID]; !exists {
+			continue
+		}
+		count += 1 + countDescendants(childID, byID, memo, visiting)
+	}
+	visiting[id] = false
+
+	memo[id] = count
+	return count
+}
+
+// GenderCounts is the number of people per gender value
+type GenderCounts struct {
+	Male        int `json:"male"`
+	Female      int `json:"female"`
+	Unspecified int `json:"unspecified"`
+}
+
+// countGenders tallies people by gender; anything other than male/female is unspecified
+func countGenders(people []models.Person) GenderCounts {
+	var counts GenderCounts
+	for _, p := range people {
+		switch p.Gender {
+		case "male":
+			counts.Male++
+		case "female":
+			counts.Female++
+		default:
+			counts.Unspecified++
+		}
+	}
+	return counts
+}
+
+// GetGenderCounts returns how many people are male, female or unspecified
+func (h *FirestoreTreeHandler) GetGenderCounts(c *gin.Context) {
+	ctx := treeContext(c)
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"genders": countGenders(people),
+		"total":   len(people),
+	})
+}
+
+// TreeAnalytics is a data-completeness summary of the tree
+type TreeAnalytics struct {
+	Total        int          `json:"total"`
+	RootCount    int          `json:"root_count"`
+	Genders      GenderCounts `json:"genders"`
+	WithBirth    int          `json:"with_birth"`    // People whose birth year can be parsed
+	WithLocation int          `json:"with_location"` // People with a location set
+	WithAvatar   int          `json:"with_avatar"`   // People with a custom (non-generated) avatar
+	LinkedUsers  int          `json:"linked_users"`  // People claimed by a user account
+	ComputedAt   string       `json:"computed_at"`
+}
+
+// GetTreeAnalytics returns data-completeness counts for dashboards.
+// Results are cached briefly; ?refresh=true forces a recompute.
+func (h *FirestoreTreeHandler) GetTreeAnalytics(c *gin.Context) {
+	ctx := treeContext(c)
+	if c.Query("refresh") != "true" {
+		if cached, ok := treeStatsCache.Get(treeCacheKey(ctx, "analytics")); ok {
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+	}
+
+	people, err := fetchAllPeople(ctx, h.client)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch people"})
+		return
+	}
+
+	hasParent := make(map[string]bool)
+	for _, p := range people {
+		for _, childID := range p.Children {
+			hasParent[childID] = true
+		}
+	}
+
+	analytics := TreeAnalytics{
+		Total:      len(people),
+		Genders:    countGenders(people),
+		ComputedAt: time.Now().Format(time.RFC3339),
+	}
+	for _, p := range people {
+		if !hasParent[p.ID] {
+			analytics.RootCount++
+		}
+		if _, ok := utils.ParseBirthYear(p.Birth); ok {
+			analytics.WithBirth++
+		}
+		if strings.TrimSpace(p.Location) != "" {
+			analytics.WithLocation++
+		}
+		if p.Avatar != "" && !strings.HasPrefix(p.Avatar, "https://api.dicebear.com/") {
+			analytics.WithAvatar++
+		}
+		if p.LinkedUserID != "" {
+			analytics.LinkedUsers++
+		}
+	}
+
+	treeStatsCache.Set(treeCacheKey(ctx, "analytics"), analytics)
+	c.JSON(http.StatusOK, analytics)
+}
+
+// TreeOverview summarizes one tree for the super-admin overview
+type TreeOverview struct {
+	TreeID       string `json:"tree_id"` // Empty for the original tree
+	TreeName     string `json:"tree_name"`
+	PersonCount  int64  `json:"person_count"`
+	UserCount    int64  `json:"user_count"`
+	PendingCount int64  `json:"pending_count"` // Pending suggestions + identity claims + permission requests
+}
+
+// GetTreesOverview lists the original tree (settings/tree) and every tree in the trees
+// collection with its size (super-admin only). Counts use Firestore aggregation queries
+// rather than reading every document. Users are shared between trees and the original
+// tree's carry no tree_id, so its user count is the total minus those of the other trees.
+func (h *FirestoreTreeHandler) GetTreesOverview(c *gin.Context) {
+	ctx := context.Background()
+	settings := loadTreeSettings(ctx, h.client)
+
+	treeDocs, err := h.coll(ctx, "trees").Documents(ctx).GetAll()
+	if err != nil {
+		log.Printf("[TreesOverview] Failed to fetch trees: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trees"})
+		return
+	}
+
+	original := TreeOverview{TreeName: settings.TreeName}
+	if err := h.countTreeContents(ctx, &original); err != nil {
+		log.Printf("[TreesOverview] Failed to count the original tree: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count tree contents"})
+		return
+	}
+	if original.UserCount, err = countQuery(ctx, h.coll(ctx, "users").Query); err != nil {
+		log.Printf("[TreesOverview] Failed to count users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count users"})
+		return
+	}
+
+	trees := []TreeOverview{}
+	for _, doc := range treeDocs {
+		var tree models.Tree
+		if err := doc.DataTo(&tree); err != nil {
+			continue
+		}
+		overview := TreeOverview{TreeID: doc.Ref.ID, TreeName: tree.Name}
+		if err := h.countTreeContents(database.WithTree(ctx, doc.Ref.ID), &overview); err != nil {
+			log.Printf("[TreesOverview] Failed to count tree %s: %v", doc.Ref.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count tree contents"})
+			return
+		}
+		if overview.UserCount, err = countQuery(ctx, h.coll(ctx, "users").Where("tree_id", "==", doc.Ref.ID)); err != nil {
+			log.Printf("[TreesOverview] Failed to count users of tree %s: %v", doc.Ref.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count users"})
+			return
+		}
+		original.UserCount -= overview.UserCount
+		trees = append(trees, overview)
+	}
+	trees = append([]TreeOverview{original}, trees...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"trees": trees,
+		"total": len(trees),
+	})
+}
+
+// countTreeContents fills in the people and pending item counts of the tree ctx is scoped to
+func (h *FirestoreTreeHandler) countTreeContents(ctx context.Context, overview *TreeOverview) error {
+	var err error
+	if overview.PersonCount, err = countQuery(ctx, h.coll(ctx, "people").Query); err != nil {
+		return err
+	}
+	for _, collection := range []string{"suggestions", "identity_claims", "permission_requests"} {
+		pending, err := countQuery(ctx, h.coll(ctx, collection).Where("status", "==", "pending"))
+		if err != nil {
+			return err
+		}
+		overview.PendingCount += pending
+	}
+	return nil
+}
+
+// countQuery runs a server-side count aggregation for q
+func countQuery(ctx context.Context, q firestore.Query) (int64, error) {
+	result, err := q.NewAggregationQuery().WithCount("count").Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := result["count"]
+	if !ok {
+		return 0, fmt.Errorf("count missing from aggregation result")
+	}
+	count, ok := value.(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count type %T", value)
+	}
+	return count.GetIntegerValue(), nil
+}
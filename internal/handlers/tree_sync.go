@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tombstoneRetention is how long deleted_people tombstones are kept
+// (TOMBSTONE_RETENTION_DAYS, default 30). Configure a Firestore TTL policy on expire_at.
+var tombstoneRetention = func() time.Duration {
+	if v := os.Getenv("TOMBSTONE_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour
+		}
+	}
+	return 30 * 24 * time.Hour
+}()
+
+// PersonTombstone records that a person was deleted, with enough data to restore them
+type PersonTombstone struct {
+	PersonID     string        `json:"person_id" firestore:"person_id"`
+	Person       models.Person `json:"person" firestore:"person"`         // Snapshot at deletion time
+	ParentIDs    []string      `json:"parent_ids" firestore:"parent_ids"` // People that listed the person as a child
+	DeletedBy    string        `json:"deleted_by" firestore:"deleted_by"` // User ID; empty for suggestion deletes
+	SuggestionID string        `json:"suggestion_id,omitempty" firestore:"suggestion_id"`
+	DeletedAt    time.Time     `json:"deleted_at" firestore:"deleted_at"`
+	ExpireAt     time.Time     `json:"expire_at" firestore:"expire_at"` // Firestore TTL field
+}
+
+// newTombstone builds the tombstone for a person being deleted now
+func newTombstone(person models.Person, parentIDs []string, deletedBy, suggestionID string) PersonTombstone {
+	now := time.Now()
+	if parentIDs == nil {
+		parentIDs = []string{}
+	}
+	return PersonTombstone{
+		PersonID:     person.ID,
+		Person:       person,
+		ParentIDs:    parentIDs,
+		DeletedBy:    deletedBy,
+		SuggestionID: suggestionID,
+		DeletedAt:    now,
+		ExpireAt:     now.Add(tombstoneRetention),
+	}
+}
+
+// recordDeletion writes a tombstone for a deleted person. Failures are logged only.
+func recordDeletion(ctx context.Context, client *firestore.Client, tombstone PersonTombstone) {
+	if _, err := database.TreeCollection(ctx, client, "deleted_people").Doc(tombstone.PersonID).Set(ctx, tombstone); err != nil {
+		log.Printf("[Sync] Failed to record tombstone for %s: %v", tombstone.PersonID, err)
+	}
+}
+
+// GetChanges returns people updated after ?since= (RFC 3339) and the ids deleted since
+// then, for clients syncing incrementally instead of using the SSE stream.
+// full_resync_required is set when since predates the tombstone retention window.
+func (h *FirestoreTreeHandler) GetChanges(c *gin.Context) {
+	since, err := time.Parse(time.RFC3339, c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC 3339 timestamp"})
+		return
+	}
+
+	ctx := treeContext(c)
+	now := time.Now()
+
+	updated := []models.Person{}
+	iter := h.coll(ctx, "people").Where("updated_at", ">", since).Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			iter.Stop()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch changes"})
+			return
+		}
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			continue
+		}
+		person.RelationshipType = person.ParentRelationship()
+		updated = append(updated, person)
+	}
+	iter.Stop()
+
+	deleted := []string{}
+	tombstones := h.coll(ctx, "deleted_people").Where("deleted_at", ">", since).Documents(ctx)
+	defer tombstones.Stop()
+	for {
+		doc, err := tombstones.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deletions"})
+			return
+		}
+		deleted = append(deleted, doc.Ref.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":                since,
+		"until":                now,
+		"updated":              updated,
+		"deleted":              deleted,
+		"full_resync_required": since.Before(now.Add(-tombstoneRetention)),
+	})
+}
+
+// errPersonAlreadyExists reports that a restore target id is in use again
+var errPersonAlreadyExists = errors.New("person already exists")
+
+// maxDeletedListing caps how many tombstones GetDeletedPeople returns
+const maxDeletedListing = 200
+
+// GetDeletedPeople lists recent deletions, newest first (?limit=, default 50)
+func (h *FirestoreTreeHandler) GetDeletedPeople(c *gin.Context) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+	if limit > maxDeletedListing {
+		limit = maxDeletedListing
+	}
+
+	ctx := treeContext(c)
+	iter := h.coll(ctx, "deleted_people").OrderBy("deleted_at", firestore.Desc).Limit(limit).Documents(ctx)
+	defer iter.Stop()
+
+	deleted := []PersonTombstone{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deletions"})
+			return
+		}
+		var tombstone PersonTombstone
+		if err := doc.DataTo(&tombstone); err != nil {
+			continue
+		}
+		deleted = append(deleted, tombstone)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted":        deleted,
+		"count":          len(deleted),
+		"retention_days": int(tombstoneRetention.Hours() / 24),
+	})
+}
+
+// RestoreDeletedPerson recreates a person from their tombstone. The person is re-added
+// under parents that still exist, keeps only children that still exist, and comes back
+// unlinked since the user account may have claimed someone else in the meantime.
+func (h *FirestoreTreeHandler) RestoreDeletedPerson(c *gin.Context) {
+	id := c.Param("id")
+	ctx := treeContext(c)
+
+	var restored models.Person
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		tombstoneRef := h.coll(ctx, "deleted_people").Doc(id)
+		tombstoneDoc, err := tx.Get(tombstoneRef)
+		if err != nil {
+			return err
+		}
+		var tombstone PersonTombstone
+		if err := tombstoneDoc.DataTo(&tombstone); err != nil {
+			return err
+		}
+
+		personRef := h.coll(ctx, "people").Doc(id)
+		if _, err := tx.Get(personRef); err == nil {
+			return errPersonAlreadyExists
+		} else if status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		// Reads must all happen before writes in a transaction
+		var parentRefs []*firestore.DocumentRef
+		parentIDs := []string{}
+		for _, parentID := range tombstone.ParentIDs {
+			ref := h.coll(ctx, "people").Doc(parentID)
+			if _, err := tx.Get(ref); err == nil {
+				parentRefs = append(parentRefs, ref)
+				parentIDs = append(parentIDs, parentID)
+			}
+		}
+		children := []string{}
+		for _, childID := range tombstone.Person.Children {
+			if _, err := tx.Get(h.coll(ctx, "people").Doc(childID)); err == nil {
+				children = append(children, childID)
+			}
+		}
+		spouses := []string{}
+		for _, spouseID := range tombstone.Person.Spouses {
+			if _, err := tx.Get(h.coll(ctx, "people").Doc(spouseID)); err == nil {
+				spouses = append(spouses, spouseID)
+			}
+		}
+
+		restored = tombstone.Person
+		restored.ID = id
+		restored.Children = children
+		restored.ParentIDs = parentIDs
+		restored.Spouses = spouses
+		restored.LinkedUserID = ""
+		restored.UpdatedAt = time.Now()
+
+		if err := tx.Set(personRef, restored); err != nil {
+			return err
+		}
+		for _, ref := range parentRefs {
+			if err := tx.Update(ref, []firestore.Update{
+				{Path: "children", Value: firestore.ArrayUnion(id)},
+				{Path: "updated_at", Value: restored.UpdatedAt},
+			}); err != nil {
+				return err
+			}
+		}
+		for _, childID := range children {
+			if err := tx.Update(h.coll(ctx, "people").Doc(childID), []firestore.Update{
+				{Path: "parent_ids", Value: firestore.ArrayUnion(id)},
+				{Path: "updated_at", Value: restored.UpdatedAt},
+			}); err != nil {
+				return err
+			}
+		}
+		for _, spouseID := range spouses {
+			if err := tx.Update(h.coll(ctx, "people").Doc(spouseID), []firestore.Update{
+				{Path: "spouses", Value: firestore.ArrayUnion(id)},
+				{Path: "updated_at", Value: restored.UpdatedAt},
+			}); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(tombstoneRef)
+	})
+	if err != nil {
+		switch {
+		case err == errPersonAlreadyExists:
+			c.JSON(http.StatusConflict, gin.H{"error": "A person with this ID already exists"})
+		case status.Code(err) == codes.NotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deleted person not found"})
+		default:
+			log.Printf("[Sync] Failed to restore %s: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore person"})
+		}
+		return
+	}
+
+	recordAudit(ctx, h.client, c, "person_restore", id, map[string]interface{}{"name": restored.Name})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Person restored successfully",
+		"person":  restored,
+	})
+}
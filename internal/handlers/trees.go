@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// requestTreeID is the tree of the authenticated user, stamped on the documents they
+// create. Empty means the original tree, which predates the trees collection.
+func requestTreeID(c *gin.Context) string {
+	return c.GetString("tree_id")
+}
+
+// treeContext is the context for a request's Firestore calls, scoped to the caller's tree
+// so per-tree collections resolve to that tree's documents
+func treeContext(c *gin.Context) context.Context {
+	return database.WithTree(context.Background(), requestTreeID(c))
+}
+
+// userInTree reports whether a users document belongs to the tree of ctx. Users are shared
+// across trees, so admin reads and writes of other users check the user's tree_id.
+func userInTree(ctx context.Context, doc *firestore.DocumentSnapshot) bool {
+	treeID, _ := doc.Data()["tree_id"].(string)
+	return treeID == database.TreeID(ctx)
+}
+
+// allTreeIDs lists every tree for background jobs, starting with the original tree ("")
+func allTreeIDs(ctx context.Context, client *firestore.Client) ([]string, error) {
+	refs, err := database.Collection(client, "trees").DocumentRefs(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{""}
+	for _, ref := range refs {
+		ids = append(ids, ref.ID)
+	}
+	return ids, nil
+}
+
+// resolveRegistrationTree finds the tree a new user asked to join: by id, otherwise by
+// name in the trees collection, otherwise the original tree if the name matches the tree
+// settings. The original tree is returned with an empty ID.
+func resolveRegistrationTree(ctx context.Context, client *firestore.Client, treeID, treeName string) (models.Tree, error) {
+	if treeID != "" {
+		doc, err := database.Collection(client, "trees").Doc(treeID).Get(ctx)
+		if err != nil {
+			return models.Tree{}, errors.New("Tree not found")
+		}
+		var tree models.Tree
+		if err := doc.DataTo(&tree); err != nil {
+			return models.Tree{}, err
+		}
+		return tree, nil
+	}
+
+	treeName = strings.TrimSpace(treeName)
+	if treeName == "" {
+		return models.Tree{}, errors.New("tree_id or tree_name is required")
+	}
+	docs, err := database.Collection(client, "trees").Where("name", "==", treeName).Limit(1).Documents(ctx).GetAll()
+	if err != nil {
+		return models.Tree{}, err
+	}
+	if len(docs) > 0 {
+		var tree models.Tree
+		if err := docs[0].DataTo(&tree); err != nil {
+			return models.Tree{}, err
+		}
+		return tree, nil
+	}
+
+	// The original tree is configured through settings rather than the trees collection
+	configuredTreeName := ""
+	if doc, err := database.TreeCollection(ctx, client, "settings").Doc("tree").Get(ctx); err == nil {
+		configuredTreeName, _ = doc.Data()["tree_name"].(string)
+	}
+	if configuredTreeName == "" {
+		return models.Tree{}, errors.New("No tree has been created yet. Please contact admin.")
+	}
+	if treeName != configuredTreeName {
+		return models.Tree{}, errors.New("Invalid tree name. The available tree is: " + configuredTreeName)
+	}
+	return models.Tree{Name: configuredTreeName}, nil
+}
+
+// CreateTree creates a new family tree owned by the calling admin. Names are unique.
+// The tree's people, suggestions and settings live under trees/{id}, apart from other trees.
+func (h *FirestoreAuthHandler) CreateTree(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.CreateTreeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tree name is required"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tree name is required"})
+		return
+	}
+
+	ctx := treeContext(c)
+	settingsName := loadTreeSettings(ctx, h.client).TreeName
+	now := time.Now()
+	tree := models.Tree{
+		ID:        uuid.New().String(),
+		Name:      name,
+		OwnerID:   userID.(string),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// Checking and creating in one transaction keeps two admins from claiming one name
+	errNameTaken := errors.New("tree name taken")
+	err := h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		existing, err := tx.Documents(h.coll(ctx, "trees").Where("name", "==", name).Limit(1)).GetAll()
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 || strings.EqualFold(name, settingsName) {
+			return errNameTaken
+		}
+		return tx.Create(h.coll(ctx, "trees").Doc(tree.ID), tree)
+	})
+	if errors.Is(err, errNameTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A tree with this name already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("[Trees] Failed to create tree %q: %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tree"})
+		return
+	}
+
+	recordAudit(ctx, h.client, c, "tree_create", tree.ID, map[string]interface{}{
+		"name": tree.Name,
+	})
+	c.JSON(http.StatusCreated, tree)
+}
+
+// ListTrees returns the trees the user belongs to: the one they joined and any they own.
+// Members of the original tree see it with an empty id.
+func (h *FirestoreAuthHandler) ListTrees(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "users").Doc(userID.(string)).Get(ctx)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var user models.User
+	if err := doc.DataTo(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
+		return
+	}
+
+	owned, err := h.coll(ctx, "trees").Where("owner_id", "==", userID.(string)).Documents(ctx).GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trees"})
+		return
+	}
+
+	trees := []models.Tree{}
+	seen := map[string]bool{}
+	for _, d := range owned {
+		var tree models.Tree
+		if err := d.DataTo(&tree); err != nil {
+			continue
+		}
+		seen[tree.ID] = true
+		trees = append(trees, tree)
+	}
+	if user.TreeID == "" {
+		trees = append(trees, models.Tree{Name: loadTreeSettings(ctx, h.client).TreeName})
+	} else if !seen[user.TreeID] {
+		if d, err := h.coll(ctx, "trees").Doc(user.TreeID).Get(ctx); err == nil {
+			var tree models.Tree
+			if err := d.DataTo(&tree); err == nil {
+				trees = append(trees, tree)
+			}
+		}
+	}
+	sort.SliceStable(trees, func(i, j int) bool {
+		return strings.ToLower(trees[i].Name) < strings.ToLower(trees[j].Name)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"trees":           trees,
+		"current_tree_id": user.TreeID,
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"google.golang.org/api/option"
+)
+
+func requestInTree(treeID string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if treeID != "" {
+		c.Set("tree_id", treeID)
+	}
+	return c
+}
+
+func TestTreeContextIsolatesPeople(t *testing.T) {
+	client, err := firestore.NewClient(context.Background(), "test-project", option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer client.Close()
+	h := NewFirestoreTreeHandler(client)
+
+	ctxA := treeContext(requestInTree("tree-a"))
+	ctxB := treeContext(requestInTree("tree-b"))
+	ctxOriginal := treeContext(requestInTree(""))
+
+	for _, name := range []string{"people", "suggestions", "deleted_people", "person_history"} {
+		a := h.coll(ctxA, name).Doc("p1").Path
+		b := h.coll(ctxB, name).Doc("p1").Path
+		original := h.coll(ctxOriginal, name).Doc("p1").Path
+		if a == b || a == original || b == original {
+			t.Errorf("%s: a tree-A member reads another tree's document: A %q, B %q, original %q", name, a, b, original)
+		}
+	}
+
+	if a, b := h.coll(ctxA, "users").Path, h.coll(ctxB, "users").Path; a != b {
+		t.Errorf("users should be shared between trees: A %q, B %q", a, b)
+	}
+}
+
+// Per-tree collections must go through h.coll or database.TreeCollection; a raw
+// database.Collection call would read the original tree from every tree
+func TestNoUnscopedTreeCollections(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := regexp.MustCompile(`database\.Collection\([^,]+,\s*"([a-z_]+)"\)`)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range raw.FindAllStringSubmatch(string(src), -1) {
+			if database.IsTreeCollection(m[1]) {
+				t.Errorf("%s: %s is not scoped to the request's tree", file, m[0])
+			}
+		}
+	}
+}
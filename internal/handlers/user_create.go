@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/models"
+	"github.com/mamiri/findyourroot/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// errEmailTaken is returned when provisioning an account for an email that already has one
+var errEmailTaken = errors.New("email already registered")
+
+// CreateUser provisions a verified account for someone who can't register themselves
+// (admin only). Without a password one is generated and returned once in the response.
+// With person_id the new user is linked to that person, who must not be claimed yet.
+func (h *FirestoreAuthHandler) CreateUser(c *gin.Context) {
+	var req models.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A valid email is required"})
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	validRoles := map[models.UserRole]bool{
+		models.RoleViewer:      true,
+		models.RoleContributor: true,
+		models.RoleCoAdmin:     true,
+		models.RoleAdmin:       true,
+	}
+	if !validRoles[req.Role] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be viewer, contributor, co-admin, or admin"})
+		return
+	}
+
+	password := req.Password
+	generated := password == ""
+	if generated {
+		token, err := utils.GenerateSecureToken(12)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate password"})
+			return
+		}
+		password = token
+	} else if len(password) < minPasswordLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Password must be at least %d characters", minPasswordLength)})
+		return
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.auth.BcryptCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user"})
+		return
+	}
+
+	// The account joins the admin's own tree
+	ctx := treeContext(c)
+	treeID := requestTreeID(c)
+	treeName := ""
+	if treeID != "" {
+		if treeDoc, err := h.coll(ctx, "trees").Doc(treeID).Get(ctx); err == nil {
+			treeName, _ = treeDoc.Data()["name"].(string)
+		}
+	} else if settingsDoc, err := h.coll(ctx, "settings").Doc("tree").Get(ctx); err == nil {
+		treeName, _ = settingsDoc.Data()["tree_name"].(string)
+	}
+
+	now := time.Now()
+	userRef := h.coll(ctx, "users").NewDoc()
+	user := models.User{
+		ID:           userRef.ID,
+		Email:        req.Email,
+		PasswordHash: string(hashedPassword),
+		Role:         req.Role,
+		IsAdmin:      req.Role == models.RoleAdmin,
+		TreeName:     treeName,
+		TreeID:       treeID,
+		IsVerified:   true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	// The email and the person's link are checked and written together so two admins
+	// can't provision the same account or claim the same person at once
+	err = h.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		existing, err := tx.Documents(h.coll(ctx, "users").Where("email", "==", req.Email).Limit(1)).GetAll()
+		if err != nil {
+			return err
+		}
+
+		var personRef *firestore.DocumentRef
+		var person *models.Person
+		if req.PersonID != "" {
+			personRef = h.coll(ctx, "people").Doc(req.PersonID)
+			doc, err := tx.Get(personRef)
+			if err != nil {
+				return errPersonNotFound
+			}
+			person = &models.Person{}
+			if err := doc.DataTo(person); err != nil {
+				return err
+			}
+		}
+		if err := checkNewUser(len(existing) > 0, person); err != nil {
+			return err
+		}
+
+		if err := tx.Create(userRef, user); err != nil {
+			return err
+		}
+		if personRef == nil {
+			return nil
+		}
+		return tx.Update(personRef, []firestore.Update{
+			{Path: "linked_user_id", Value: user.ID},
+			{Path: "updated_at", Value: now},
+		})
+	})
+	switch {
+	case errors.Is(err, errEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		return
+	case errors.Is(err, errPersonNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	case errors.Is(err, errPersonAlreadyLinked):
+		c.JSON(http.StatusConflict, gin.H{"error": "Person is already linked to another user"})
+		return
+	case err != nil:
+		log.Printf("[CreateUser] Failed to create %s: %v", req.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating user"})
+		return
+	}
+
+	recordAudit(ctx, h.client, c, "user_create", user.ID, map[string]interface{}{
+		"email":     user.Email,
+		"role":      string(user.Role),
+		"person_id": req.PersonID,
+	})
+
+	response := gin.H{
+		"user": gin.H{
+			"id":          user.ID,
+			"email":       user.Email,
+			"role":        user.Role,
+			"is_admin":    user.IsAdmin,
+			"tree_name":   user.TreeName,
+			"tree_id":     user.TreeID,
+			"is_verified": user.IsVerified,
+			"person_id":   req.PersonID,
+		},
+	}
+	if generated {
+		response["generated_password"] = password // Shown only here; never stored in plain text
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// checkNewUser rejects an email that already has an account and a person (nil when none
+// was requested) who is already linked to another user
+func checkNewUser(emailTaken bool, person *models.Person) error {
+	if emailTaken {
+		return errEmailTaken
+	}
+	if person != nil && person.LinkedUserID != "" {
+		return errPersonAlreadyLinked
+	}
+	return nil
+}
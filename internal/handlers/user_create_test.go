@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+func TestCheckNewUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		emailTaken bool
+		person     *models.Person
+		want       error
+	}{
+		{"new email, no person", false, nil, nil},
+		{"new email, unclaimed person", false, &models.Person{ID: "p1"}, nil},
+		{"duplicate email", true, nil, errEmailTaken},
+		{"duplicate email wins over claimed person", true, &models.Person{ID: "p1", LinkedUserID: "u1"}, errEmailTaken},
+		{"person already claimed", false, &models.Person{ID: "p1", LinkedUserID: "u1"}, errPersonAlreadyLinked},
+	}
+	for _, tt := range tests {
+		if got := checkNewUser(tt.emailTaken, tt.person); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+)
+
+// DeleteUser permanently deletes an account (admin only). References to the user are
+// cleaned up first (person links, likes, pending requests, claims and suggestions) and
+// reported in the response; if that cleanup fails the account is kept. Admins cannot
+// delete themselves or the last admin.
+func (h *FirestoreAuthHandler) DeleteUser(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+	targetUserID := c.Param("id")
+
+	if adminID.(string) == targetUserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete your own account"})
+		return
+	}
+
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "users").Doc(targetUserID).Get(ctx)
+	if err != nil || !userInTree(ctx, doc) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var targetUser models.User
+	if err := doc.DataTo(&targetUser); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
+		return
+	}
+
+	if targetUser.Role == models.RoleAdmin {
+		admins, err := countTreeAdmins(ctx, h.client)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count admins"})
+			return
+		}
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete the last admin"})
+			return
+		}
+	}
+
+	integrityService := NewReferentialIntegrityService(h.client)
+	cleaned, err := integrityService.OnUserDeleted(ctx, targetUserID)
+	if err != nil {
+		// Deleting now would leave dangling references; keep the account so the delete can be retried
+		log.Printf("[DeleteUser] Cleanup for %s failed, account kept: %v", targetUserID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up references to the user", "cleaned": cleaned})
+		return
+	}
+	if _, err := h.coll(ctx, "users").Doc(targetUserID).Delete(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+
+	recordAudit(ctx, h.client, c, "user_delete", targetUserID, map[string]interface{}{
+		"email":   targetUser.Email,
+		"role":    string(targetUser.Role),
+		"cleaned": cleaned,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User deleted",
+		"user":    targetUser.Email,
+		"cleaned": cleaned,
+	})
+}
+
+// countTreeAdmins counts the admins of the tree of ctx. Users of all trees share one
+// collection, so the tree is filtered here rather than in the query.
+func countTreeAdmins(ctx context.Context, client *firestore.Client) (int64, error) {
+	docs, err := database.Collection(client, "users").Where("role", "==", string(models.RoleAdmin)).Select("tree_id").Documents(ctx).GetAll()
+	if err != nil {
+		return 0, err
+	}
+	var admins int64
+	for _, doc := range docs {
+		if userInTree(ctx, doc) {
+			admins++
+		}
+	}
+	return admins, nil
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// MergeUsers folds a duplicate account into another one (admin only).
+// The merged user's linked person, suggestions, identity claims, permission requests
+// and likes move to the kept user; the kept account's credentials and role are unchanged.
+func (h *FirestoreAuthHandler) MergeUsers(c *gin.Context) {
+	var req models.MergeUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keep_id and merge_id are required"})
+		return
+	}
+	if req.KeepID == req.MergeID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge a user into itself"})
+		return
+	}
+
+	ctx := treeContext(c)
+
+	keepDoc, err := h.coll(ctx, "users").Doc(req.KeepID).Get(ctx)
+	if err != nil || !userInTree(ctx, keepDoc) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User to keep not found"})
+		return
+	}
+	mergeDoc, err := h.coll(ctx, "users").Doc(req.MergeID).Get(ctx)
+	if err != nil || !userInTree(ctx, mergeDoc) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User to merge not found"})
+		return
+	}
+
+	var keepUser, mergeUser models.User
+	if err := keepDoc.DataTo(&keepUser); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
+		return
+	}
+	if err := mergeDoc.DataTo(&mergeUser); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
+		return
+	}
+	// Older accounts have no stored id; the document ID is authoritative
+	keepUser.ID = keepDoc.Ref.ID
+	mergeUser.ID = mergeDoc.Ref.ID
+
+	// Never merge away the last admin
+	if mergeUser.Role == models.RoleAdmin && keepUser.Role != models.RoleAdmin {
+		admins, err := countTreeAdmins(ctx, h.client)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count admins"})
+			return
+		}
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot merge away the last admin"})
+			return
+		}
+	}
+
+	// At most one of the two accounts may be linked to a person
+	keepLinked, err := linkedPersonIDs(ctx, h.client, req.KeepID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked person"})
+		return
+	}
+	mergeLinked, err := linkedPersonIDs(ctx, h.client, req.MergeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked person"})
+		return
+	}
+	if len(keepLinked) > 0 && len(mergeLinked) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Both accounts are linked to a person; unlink one first"})
+		return
+	}
+
+	now := time.Now()
+	for _, personID := range mergeLinked {
+		if _, err := h.coll(ctx, "people").Doc(personID).Update(ctx, []firestore.Update{
+			{Path: "linked_user_id", Value: req.KeepID},
+			{Path: "updated_at", Value: now},
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer linked person"})
+			return
+		}
+		recordPersonHistoryFor(ctx, h.client, c,
+			models.Person{ID: personID, LinkedUserID: req.MergeID},
+			models.Person{ID: personID, LinkedUserID: req.KeepID},
+			"merge", req.MergeID)
+	}
+
+	transferred := gin.H{"linked_person": len(mergeLinked)}
+	for _, collection := range []string{"suggestions", "identity_claims", "permission_requests"} {
+		moved, err := reassignUserDocs(ctx, h.client, collection, req.MergeID, keepUser)
+		if err != nil {
+			log.Printf("[MergeUsers] Failed to transfer %s: %v", collection, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer " + collection})
+			return
+		}
+		transferred[collection] = moved
+	}
+
+	likes, err := transferLikes(ctx, h.client, req.MergeID, req.KeepID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer likes"})
+		return
+	}
+	transferred["likes"] = likes
+
+	// Anything left now references nothing the kept user needs
+	integrityService := NewReferentialIntegrityService(h.client)
+	if _, err := integrityService.OnUserDeleted(ctx, req.MergeID); err != nil {
+		log.Printf("[MergeUsers] Cleanup for %s failed, account kept: %v", req.MergeID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up references to the merged account"})
+		return
+	}
+	if _, err := h.coll(ctx, "users").Doc(req.MergeID).Delete(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete merged account"})
+		return
+	}
+
+	recordAudit(ctx, h.client, c, "user_merge", req.KeepID, map[string]interface{}{
+		"merged_id":    req.MergeID,
+		"merged_email": mergeUser.Email,
+		"transferred":  map[string]interface{}(transferred),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Users merged",
+		"kept":        keepUser.Email,
+		"merged":      mergeUser.Email,
+		"transferred": transferred,
+	})
+}
+
+// linkedPersonIDs returns the IDs of people linked to a user
+func linkedPersonIDs(ctx context.Context, client *firestore.Client, userID string) ([]string, error) {
+	iter := database.TreeCollection(ctx, client, "people").Where("linked_user_id", "==", userID).Documents(ctx)
+	defer iter.Stop()
+
+	var ids []string
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.Ref.ID)
+	}
+	return ids, nil
+}
+
+// reassignUserDocs moves every document in a collection owned by fromID to the given user
+func reassignUserDocs(ctx context.Context, client *firestore.Client, collection, fromID string, to models.User) (int, error) {
+	iter := database.Collection(client, collection).Where("user_id", "==", fromID).Documents(ctx)
+	defer iter.Stop()
+
+	batch := client.Batch()
+	pending, moved := 0, 0
+	now := time.Now()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return moved, err
+		}
+
+		batch.Update(doc.Ref, []firestore.Update{
+			{Path: "user_id", Value: to.ID},
+			{Path: "user_email", Value: to.Email},
+			{Path: "updated_at", Value: now},
+		})
+		pending++
+		moved++
+
+		if pending == 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return moved, err
+			}
+			pending = 0
+			batch = client.Batch()
+		}
+	}
+	if pending > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+// transferLikes replaces fromID with toID in liked_by arrays without double-counting
+// people both users liked
+func transferLikes(ctx context.Context, client *firestore.Client, fromID, toID string) (int, error) {
+	iter := database.TreeCollection(ctx, client, "people").Where("liked_by", "array-contains", fromID).Documents(ctx)
+	defer iter.Stop()
+
+	moved := 0
+	now := time.Now()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return moved, err
+		}
+
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			continue
+		}
+
+		likedBy := []string{}
+		alreadyLiked := false
+		for _, id := range person.LikedBy {
+			if id == toID {
+				alreadyLiked = true
+			}
+			if id != fromID {
+				likedBy = append(likedBy, id)
+			}
+		}
+		if !alreadyLiked {
+			likedBy = append(likedBy, toID)
+			moved++
+		}
+
+		if _, err := doc.Ref.Update(ctx, []firestore.Update{
+			{Path: "liked_by", Value: likedBy},
+			{Path: "likes_count", Value: likesCount(likedBy)},
+			{Path: "updated_at", Value: now},
+		}); err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gin-gonic/gin"
+	"github.com/mamiri/findyourroot/internal/database"
+	"github.com/mamiri/findyourroot/internal/models"
+	"google.golang.org/api/iterator"
+)
+
+// Verification rules, in the order they are tried
+const (
+	VerifyRuleFatherNameExact  = "father_name_exact"  // Parent's name equals the father's name
+	VerifyRuleFatherNamePrefix = "father_name_prefix" // Parent's name starts with the father's name
+)
+
+// VerificationMatch describes the tree node that verified a user
+type VerificationMatch struct {
+	Rule       string `json:"rule"`
+	PersonID   string `json:"person_id"`
+	PersonName string `json:"person_name"`
+	ParentID   string `json:"parent_id"`
+	ParentName string `json:"parent_name"`
+}
+
+// matchTreeMembership looks for a person born in birthYear whose parent matches fatherName.
+// Returns nil if no rule matches.
+func matchTreeMembership(ctx context.Context, client *firestore.Client, fatherName, birthYear string) *VerificationMatch {
+	if fatherName == "" || birthYear == "" {
+		return nil
+	}
+
+	peopleIter := database.TreeCollection(ctx, client, "people").Where("birth", "==", birthYear).Documents(ctx)
+	defer peopleIter.Stop()
+
+	var prefixMatch *VerificationMatch
+	for {
+		doc, err := peopleIter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			continue
+		}
+
+		var person models.Person
+		if err := doc.DataTo(&person); err != nil {
+			continue
+		}
+
+		// Find this person's parent and check if father's name matches
+		parentsIter := database.TreeCollection(ctx, client, "people").Where("children", "array-contains", person.ID).Documents(ctx)
+		for {
+			parentDoc, err := parentsIter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				continue
+			}
+
+			var parent models.Person
+			if err := parentDoc.DataTo(&parent); err != nil {
+				continue
+			}
+
+			match := &VerificationMatch{
+				PersonID:   person.ID,
+				PersonName: person.Name,
+				ParentID:   parent.ID,
+				ParentName: parent.Name,
+			}
+			if parent.Name == fatherName {
+				match.Rule = VerifyRuleFatherNameExact
+				parentsIter.Stop()
+				return match
+			}
+			if prefixMatch == nil && strings.HasPrefix(parent.Name, fatherName) {
+				match.Rule = VerifyRuleFatherNamePrefix
+				prefixMatch = match
+			}
+		}
+		parentsIter.Stop()
+	}
+
+	return prefixMatch
+}
+
+// ReverifyUser re-runs tree-membership verification for a user (admin only)
+func (h *FirestoreAuthHandler) ReverifyUser(c *gin.Context) {
+	h.reverify(c, c.Param("id"))
+}
+
+// ReverifyMe re-runs tree-membership verification for the current user
+func (h *FirestoreAuthHandler) ReverifyMe(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	h.reverify(c, userID.(string))
+}
+
+// reverify matches the user's registration details against the current tree and marks
+// them verified if a match now exists. Never un-verifies an already verified user.
+func (h *FirestoreAuthHandler) reverify(c *gin.Context, userID string) {
+	ctx := treeContext(c)
+
+	doc, err := h.coll(ctx, "users").Doc(userID).Get(ctx)
+	if err != nil || !userInTree(ctx, doc) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var user models.User
+	if err := doc.DataTo(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
+		return
+	}
+
+	match := matchTreeMembership(ctx, h.client, user.FatherName, user.BirthYear)
+	if match == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"is_verified": user.IsVerified,
+			"matched":     false,
+			"message":     "No matching person found in the tree",
+		})
+		return
+	}
+
+	if !user.IsVerified {
+		_, err = doc.Ref.Update(ctx, []firestore.Update{
+			{Path: "is_verified", Value: true},
+			{Path: "updated_at", Value: time.Now()},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update verification"})
+			return
+		}
+		log.Printf("[Reverify] User %s verified via %s (person %s)", user.Email, match.Rule, match.PersonID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"is_verified": true,
+		"matched":     true,
+		"match":       match,
+	})
+}
+
+// GetPendingVerificationUsers lists unverified users, most recently registered first,
+// with the father name and birth year they submitted for manual matching (admin only)
+func (h *FirestoreAuthHandler) GetPendingVerificationUsers(c *gin.Context) {
+	ctx := treeContext(c)
+
+	iter := h.coll(ctx, "users").Where("is_verified", "==", false).Documents(ctx)
+	defer iter.Stop()
+
+	type pendingUser struct {
+		resp      models.PendingVerificationUser
+		createdAt time.Time
+	}
+	var pending []pendingUser
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+			return
+		}
+		if !userInTree(ctx, doc) {
+			continue
+		}
+
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			continue
+		}
+		pending = append(pending, pendingUser{
+			resp: models.PendingVerificationUser{
+				ID:         doc.Ref.ID,
+				Email:      user.Email,
+				Role:       user.Role,
+				TreeName:   user.TreeName,
+				FatherName: user.FatherName,
+				BirthYear:  user.BirthYear,
+				CreatedAt:  user.CreatedAt.Format(time.RFC3339),
+			},
+			createdAt: user.CreatedAt,
+		})
+	}
+
+	// Sort by registration time descending in code (avoids a composite index)
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].createdAt.After(pending[j].createdAt)
+	})
+
+	users := make([]models.PendingVerificationUser, len(pending))
+	for i, p := range pending {
+		users[i] = p.resp
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"total": len(users),
+	})
+}
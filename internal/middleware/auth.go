@@ -16,6 +16,10 @@ type Claims struct {
 	Email   string `json:"email"`
 	IsAdmin bool   `json:"is_admin"`
 	Role    string `json:"role"`
+	// TreeID is the tree the user joined; empty for the original tree
+	TreeID string `json:"tree_id,omitempty"`
+	// ImpersonatedBy is the admin's user ID when this token was issued for impersonation
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -62,7 +66,9 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("email", claims.Email)
 		c.Set("is_admin", claims.IsAdmin)
 		c.Set("role", claims.Role)
+		c.Set("tree_id", claims.TreeID)
 		c.Set("claims", claims)
+		c.Set("impersonated_by", claims.ImpersonatedBy)
 
 		c.Next()
 	}
@@ -162,3 +168,46 @@ func RequireAdmin() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireSuperAdmin ensures the user is the deployment's super-admin: an admin whose email
+// matches SUPER_ADMIN_EMAIL, falling back to the bootstrap ADMIN_EMAIL
+func RequireSuperAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := c.Get("claims")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		userClaims := claims.(*Claims)
+		role := models.UserRole(userClaims.Role)
+
+		superEmail := os.Getenv("SUPER_ADMIN_EMAIL")
+		if superEmail == "" {
+			superEmail = os.Getenv("ADMIN_EMAIL")
+		}
+
+		if !role.CanManageUsers() || superEmail == "" || !strings.EqualFold(userClaims.Email, superEmail) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Super-admin access required", "required_role": "super-admin"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// BlockImpersonation rejects requests made with an impersonation token, for routes that
+// perform sensitive writes (role changes, approvals, tree edits)
+func BlockImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if impersonatedBy, _ := c.Get("impersonated_by"); impersonatedBy != nil && impersonatedBy != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This action is not allowed while impersonating a user"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
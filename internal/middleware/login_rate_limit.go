@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// envInt reads a positive integer from the environment, falling back to def
+func envInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+var (
+	// loginRateLimitAttempts is how many failed logins one email+IP may make within the
+	// window before being throttled (LOGIN_RATE_LIMIT_ATTEMPTS, default 10)
+	loginRateLimitAttempts = envInt("LOGIN_RATE_LIMIT_ATTEMPTS", 10)
+
+	// loginRateLimitWindow is how long failures are remembered (LOGIN_RATE_LIMIT_WINDOW_MINUTES, default 15)
+	loginRateLimitWindow = time.Duration(envInt("LOGIN_RATE_LIMIT_WINDOW_MINUTES", 15)) * time.Minute
+
+	// loginBackoffBase is the first throttle period; each further failure doubles it,
+	// up to loginBackoffMax (LOGIN_BACKOFF_SECONDS, default 30)
+	loginBackoffBase = time.Duration(envInt("LOGIN_BACKOFF_SECONDS", 30)) * time.Second
+	loginBackoffMax  = time.Hour
+)
+
+// loginAttempts is the failure history of one email+IP
+type loginAttempts struct {
+	failures     int
+	firstFailure time.Time
+	blockedUntil time.Time
+}
+
+// loginLimiter tracks failed logins in memory. Each instance keeps its own counts; the
+// per-account lockout stored in Firestore still applies across instances.
+var loginLimiter = struct {
+	sync.Mutex
+	attempts  map[string]*loginAttempts
+	lastPrune time.Time
+}{attempts: make(map[string]*loginAttempts)}
+
+// loginKey identifies the attempts of one email from one IP
+func loginKey(email, ip string) string {
+	return strings.ToLower(strings.TrimSpace(email)) + "|" + ip
+}
+
+// ClearLoginAttempts forgets the failures recorded for an email from every IP, so an
+// admin unlocking an account also lifts the rate limit
+func ClearLoginAttempts(email string) {
+	prefix := loginKey(email, "")
+	loginLimiter.Lock()
+	defer loginLimiter.Unlock()
+	for key := range loginLimiter.attempts {
+		if strings.HasPrefix(key, prefix) {
+			delete(loginLimiter.attempts, key)
+		}
+	}
+}
+
+// pruneLoginAttempts drops entries whose window and block have both passed. Callers hold the lock.
+func pruneLoginAttempts(now time.Time) {
+	if now.Sub(loginLimiter.lastPrune) < loginRateLimitWindow {
+		return
+	}
+	loginLimiter.lastPrune = now
+	for key, a := range loginLimiter.attempts {
+		if now.Sub(a.firstFailure) > loginRateLimitWindow && now.After(a.blockedUntil) {
+			delete(loginLimiter.attempts, key)
+		}
+	}
+}
+
+// loginBlockedUntil reports until when key is throttled, or the zero time
+func loginBlockedUntil(key string, now time.Time) time.Time {
+	loginLimiter.Lock()
+	defer loginLimiter.Unlock()
+	pruneLoginAttempts(now)
+	if a, ok := loginLimiter.attempts[key]; ok && now.Before(a.blockedUntil) {
+		return a.blockedUntil
+	}
+	return time.Time{}
+}
+
+// recordLoginResult resets key on success and counts a failure otherwise. Past the
+// threshold every failure blocks the key for loginBackoffBase doubled per extra failure.
+func recordLoginResult(key string, success bool, now time.Time) {
+	loginLimiter.Lock()
+	defer loginLimiter.Unlock()
+	if success {
+		delete(loginLimiter.attempts, key)
+		return
+	}
+
+	a, ok := loginLimiter.attempts[key]
+	if !ok || (now.Sub(a.firstFailure) > loginRateLimitWindow && now.After(a.blockedUntil)) {
+		a = &loginAttempts{firstFailure: now}
+		loginLimiter.attempts[key] = a
+	}
+	a.failures++
+	if over := a.failures - loginRateLimitAttempts; over >= 0 {
+		backoff := loginBackoffMax
+		if over < 32 {
+			if d := loginBackoffBase << uint(over); d > 0 && d < loginBackoffMax {
+				backoff = d
+			}
+		}
+		a.blockedUntil = now.Add(backoff)
+		log.Printf("[RateLimit] Login throttled for %s after %d failures (%s)", key, a.failures, backoff)
+	}
+}
+
+// LoginRateLimit throttles repeated failed logins per email and client IP, answering 429
+// with Retry-After while blocked. A 401 or 423 from the handler counts as a failure and a
+// 200 clears the count. The JSON body is read for the email and restored for the handler.
+func LoginRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var req struct {
+			Email string `json:"email"`
+		}
+		_ = json.Unmarshal(body, &req)
+		key := loginKey(req.Email, c.ClientIP())
+
+		now := time.Now()
+		if blockedUntil := loginBlockedUntil(key, now); !blockedUntil.IsZero() {
+			retryAfter := int(blockedUntil.Sub(now).Seconds()) + 1
+			c.Header("Retry-After", fmt.Sprintf("%d", retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many failed login attempts. Try again later.",
+				"retry_after": retryAfter,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		switch c.Writer.Status() {
+		case http.StatusOK:
+			recordLoginResult(key, true, time.Now())
+		case http.StatusUnauthorized, http.StatusLocked:
+			recordLoginResult(key, false, time.Now())
+		}
+	}
+}
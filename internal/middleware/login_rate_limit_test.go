@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loginRouter serves /login through LoginRateLimit, answering with *status
+func loginRouter(status *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", LoginRateLimit(), func(c *gin.Context) {
+		c.JSON(*status, gin.H{})
+	})
+	return r
+}
+
+func postLogin(r *gin.Engine, email string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"`+email+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLoginRateLimitBlocksRepeatedFailures(t *testing.T) {
+	email := "blocked@example.com"
+	defer ClearLoginAttempts(email)
+
+	status := http.StatusUnauthorized
+	r := loginRouter(&status)
+	for i := 0; i < loginRateLimitAttempts; i++ {
+		if w := postLogin(r, email); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: got %d, want 401", i+1, w.Code)
+		}
+	}
+
+	// Even a correct password is refused while blocked
+	status = http.StatusOK
+	w := postLogin(r, email)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("got %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("429 without Retry-After")
+	}
+
+	// Other emails from the same IP are unaffected
+	if w := postLogin(r, "other@example.com"); w.Code != http.StatusOK {
+		t.Errorf("other email: got %d, want 200", w.Code)
+	}
+}
+
+func TestLoginRateLimitSuccessResetsCount(t *testing.T) {
+	email := "reset@example.com"
+	defer ClearLoginAttempts(email)
+
+	status := http.StatusUnauthorized
+	r := loginRouter(&status)
+	for i := 0; i < loginRateLimitAttempts-1; i++ {
+		postLogin(r, email)
+	}
+	status = http.StatusOK
+	if w := postLogin(r, email); w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", w.Code)
+	}
+
+	// The count starts over, so another threshold-minus-one failures stay unblocked
+	status = http.StatusUnauthorized
+	for i := 0; i < loginRateLimitAttempts-1; i++ {
+		if w := postLogin(r, email); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d after success: got %d, want 401", i+1, w.Code)
+		}
+	}
+}
+
+func TestLoginBackoffDoubles(t *testing.T) {
+	key := loginKey("backoff@example.com", "192.0.2.1")
+	defer ClearLoginAttempts("backoff@example.com")
+
+	now := time.Now()
+	for i := 0; i < loginRateLimitAttempts; i++ {
+		recordLoginResult(key, false, now)
+	}
+	first := loginBlockedUntil(key, now).Sub(now)
+	if first != loginBackoffBase {
+		t.Fatalf("first block %s, want %s", first, loginBackoffBase)
+	}
+	recordLoginResult(key, false, now)
+	if second := loginBlockedUntil(key, now).Sub(now); second != 2*loginBackoffBase {
+		t.Errorf("second block %s, want %s", second, 2*loginBackoffBase)
+	}
+}
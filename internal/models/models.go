@@ -27,6 +27,60 @@ func (r UserRole) CanManageUsers() bool {
 	return r == RoleAdmin
 }
 
+// Permissions are the capabilities derived from a role, sent to the frontend so it
+// doesn't have to re-derive role logic
+type Permissions struct {
+	CanEdit        bool `json:"can_edit"`
+	CanApprove     bool `json:"can_approve"`
+	CanManageUsers bool `json:"can_manage_users"`
+}
+
+// Permissions returns the capabilities of the role
+func (r UserRole) Permissions() Permissions {
+	return Permissions{
+		CanEdit:        r.CanEditDirectly(),
+		CanApprove:     r.CanApprove(),
+		CanManageUsers: r.CanManageUsers(),
+	}
+}
+
+// AuditLog records a sensitive administrative action
+type AuditLog struct {
+	ID         string                 `json:"id" firestore:"id"`
+	Action     string                 `json:"action" firestore:"action"` // e.g. "impersonation_start"
+	ActorID    string                 `json:"actor_id" firestore:"actor_id"`
+	ActorEmail string                 `json:"actor_email" firestore:"actor_email"`
+	TargetID   string                 `json:"target_id" firestore:"target_id"` // User or person acted upon
+	Details    map[string]interface{} `json:"details" firestore:"details"`
+	IPAddress  string                 `json:"ip_address" firestore:"ip_address"`
+	CreatedAt  time.Time              `json:"created_at" firestore:"created_at"`
+}
+
+// PersonHistoryEntry records one change to a person with the before/after value of each field
+type PersonHistoryEntry struct {
+	ID          string        `json:"id" firestore:"id"`
+	PersonID    string        `json:"person_id" firestore:"person_id"`
+	EditorID    string        `json:"editor_id" firestore:"editor_id"`
+	EditorEmail string        `json:"editor_email" firestore:"editor_email"`
+	Source      string        `json:"source" firestore:"source"`                           // "update", "suggestion" or "merge"
+	SourceID    string        `json:"source_id,omitempty" firestore:"source_id,omitempty"` // e.g. the approved suggestion
+	Changes     []FieldChange `json:"changes" firestore:"changes"`
+	CreatedAt   time.Time     `json:"created_at" firestore:"created_at"`
+}
+
+// FieldChange is one changed field of a person
+type FieldChange struct {
+	Field  string      `json:"field" firestore:"field"`
+	Before interface{} `json:"before" firestore:"before"`
+	After  interface{} `json:"after" firestore:"after"`
+}
+
+// ImpersonateRequest is an admin's re-confirmation before impersonating a user
+type ImpersonateRequest struct {
+	Password string `json:"password" binding:"required"` // Admin's own password
+	Reason   string `json:"reason" binding:"required"`   // Why support needs the user's view
+}
+
 // SuggestionType represents the type of tree edit suggestion
 type SuggestionType string
 
@@ -46,43 +100,99 @@ type Suggestion struct {
 	Status         string         `json:"status" firestore:"status"`                     // pending, approved, rejected
 	UserID         string         `json:"user_id" firestore:"user_id"`                   // Who made the suggestion
 	UserEmail      string         `json:"user_email" firestore:"user_email"`
-	ReviewedBy     string         `json:"reviewed_by" firestore:"reviewed_by"` // Admin/co-admin who reviewed
+	TreeID         string         `json:"tree_id,omitempty" firestore:"tree_id,omitempty"` // Tree of the suggesting user; empty for the original tree
+	ReviewedBy     string         `json:"reviewed_by" firestore:"reviewed_by"`             // Admin/co-admin who reviewed
 	ReviewerEmail  string         `json:"reviewer_email" firestore:"reviewer_email"`
 	ReviewNotes    string         `json:"review_notes" firestore:"review_notes"` // Notes from reviewer
+	ReviewingBy    string         `json:"reviewing_by" firestore:"reviewing_by"` // Approver who claimed this suggestion for review
+	ReviewingEmail string         `json:"reviewing_email" firestore:"reviewing_email"`
+	ReviewingAt    time.Time      `json:"reviewing_at" firestore:"reviewing_at"` // When the claim was made (expires after a timeout)
 	CreatedAt      time.Time      `json:"created_at" firestore:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at" firestore:"updated_at"`
 }
 
 // PersonData holds the data for a person (used in suggestions)
 type PersonData struct {
-	Name               string `json:"name" firestore:"name"`
-	Role               string `json:"role" firestore:"role"`
-	Gender             string `json:"gender" firestore:"gender"` // "male", "female", or empty
-	Birth              string `json:"birth" firestore:"birth"`
-	Location           string `json:"location" firestore:"location"`
-	Avatar             string `json:"avatar" firestore:"avatar"`
-	Bio                string `json:"bio" firestore:"bio"`
-	InstagramUsername  string `json:"instagram_username" firestore:"instagram_username"`
-	InstagramAvatarURL string `json:"instagram_avatar_url" firestore:"instagram_avatar_url"`
+	Name               string   `json:"name" firestore:"name"`
+	AltNames           []string `json:"alt_names" firestore:"alt_names"` // Nicknames, maiden names, etc.
+	Role               string   `json:"role" firestore:"role"`
+	Occupation         string   `json:"occupation" firestore:"occupation"`
+	Gender             string   `json:"gender" firestore:"gender"` // "male", "female", or empty
+	Birth              string   `json:"birth" firestore:"birth"`
+	Death              string   `json:"death" firestore:"death"`
+	Deceased           bool     `json:"deceased" firestore:"deceased"`
+	Location           string   `json:"location" firestore:"location"`
+	Avatar             string   `json:"avatar" firestore:"avatar"`
+	Bio                string   `json:"bio" firestore:"bio"`
+	InstagramUsername  string   `json:"instagram_username" firestore:"instagram_username"`
+	InstagramAvatarURL string   `json:"instagram_avatar_url" firestore:"instagram_avatar_url"`
+	ClearedFields      []string `json:"cleared_fields,omitempty" firestore:"cleared_fields,omitempty"` // Edit only: fields to empty, since blank values are ignored
 }
 
 // User represents a user in the system
 type User struct {
-	ID           string   `json:"id" firestore:"id"`
-	Email        string   `json:"email" firestore:"email"`
-	PasswordHash string   `json:"-" firestore:"password_hash"`
-	Role         UserRole `json:"role" firestore:"role"`
-	IsAdmin      bool     `json:"is_admin" firestore:"is_admin"`       // Deprecated, use Role instead
-	TreeName     string   `json:"tree_name" firestore:"tree_name"`     // Family tree name (e.g., "Batur")
-	FatherName   string   `json:"father_name" firestore:"father_name"` // Father's name for verification
-	BirthYear    string   `json:"birth_year" firestore:"birth_year"`   // Birth year for verification
-	IsVerified   bool     `json:"is_verified" firestore:"is_verified"` // Whether user is verified as part of the tree
+	ID                  string             `json:"id" firestore:"id"`
+	Email               string             `json:"email" firestore:"email"`
+	PasswordHash        string             `json:"-" firestore:"password_hash"`
+	Role                UserRole           `json:"role" firestore:"role"`
+	IsAdmin             bool               `json:"is_admin" firestore:"is_admin"`                         // Deprecated, use Role instead
+	TreeName            string             `json:"tree_name" firestore:"tree_name"`                       // Family tree name (e.g., "Batur")
+	TreeID              string             `json:"tree_id,omitempty" firestore:"tree_id,omitempty"`       // Tree joined at registration; empty for the original tree
+	FatherName          string             `json:"father_name" firestore:"father_name"`                   // Father's name for verification
+	BirthYear           string             `json:"birth_year" firestore:"birth_year"`                     // Birth year for verification
+	IsVerified          bool               `json:"is_verified" firestore:"is_verified"`                   // Whether user is verified as part of the tree
+	OnboardingDismissed bool               `json:"onboarding_dismissed" firestore:"onboarding_dismissed"` // User hid the first-run checklist
+	FailedLoginAttempts int                `json:"-" firestore:"failed_login_attempts"`                   // Consecutive wrong passwords, reset on success
+	LockedUntil         time.Time          `json:"locked_until" firestore:"locked_until"`                 // Login refused until then
+	NotificationPrefs   *NotificationPrefs `json:"notification_prefs" firestore:"notification_prefs"`     // Nil means every notification is on
 	// REMOVED: PersonID - the link is now owned by Person.LinkedUserID only
 	// To find a user's linked person, query: people WHERE linked_user_id == user.id
 	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" firestore:"updated_at"`
 }
 
+// NotificationPrefs are the emails a user has opted into
+type NotificationPrefs struct {
+	EmailReviewOutcome     bool `json:"email_review_outcome" firestore:"email_review_outcome"`         // Their suggestion was approved or rejected
+	EmailClaimOutcome      bool `json:"email_claim_outcome" firestore:"email_claim_outcome"`           // Their identity claim was approved or rejected
+	EmailPermissionOutcome bool `json:"email_permission_outcome" firestore:"email_permission_outcome"` // Their role request was approved or rejected
+	EmailAccountSecurity   bool `json:"email_account_security" firestore:"email_account_security"`     // Account lockouts and similar security notices
+}
+
+// DefaultNotificationPrefs has every notification turned on
+func DefaultNotificationPrefs() NotificationPrefs {
+	return NotificationPrefs{
+		EmailReviewOutcome:     true,
+		EmailClaimOutcome:      true,
+		EmailPermissionOutcome: true,
+		EmailAccountSecurity:   true,
+	}
+}
+
+// Notifications returns the user's notification preferences, defaulting to all on
+func (u User) Notifications() NotificationPrefs {
+	if u.NotificationPrefs == nil {
+		return DefaultNotificationPrefs()
+	}
+	return *u.NotificationPrefs
+}
+
+// UpdateNotificationPrefsRequest changes notification preferences; omitted fields keep their value
+type UpdateNotificationPrefsRequest struct {
+	EmailReviewOutcome     *bool `json:"email_review_outcome"`
+	EmailClaimOutcome      *bool `json:"email_claim_outcome"`
+	EmailPermissionOutcome *bool `json:"email_permission_outcome"`
+	EmailAccountSecurity   *bool `json:"email_account_security"`
+}
+
+// OnboardingState is the first-run checklist shown to a user
+type OnboardingState struct {
+	IdentityClaimed bool `json:"identity_claimed"` // Linked to a person or has a pending claim
+	RoleRequested   bool `json:"role_requested"`   // Has a role above viewer or has asked for one
+	Completed       bool `json:"completed"`
+	Dismissed       bool `json:"dismissed"`
+}
+
 // PermissionRequest represents a request for elevated permissions
 type PermissionRequest struct {
 	ID            string    `json:"id" firestore:"id"`
@@ -91,37 +201,78 @@ type PermissionRequest struct {
 	RequestedRole UserRole  `json:"requested_role" firestore:"requested_role"`
 	Message       string    `json:"message" firestore:"message"`
 	Status        string    `json:"status" firestore:"status"` // pending, approved, rejected
+	ReviewNotes   string    `json:"review_notes" firestore:"review_notes"`
 	CreatedAt     time.Time `json:"created_at" firestore:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" firestore:"updated_at"`
 }
 
 // IdentityClaimRequest represents a request to claim a tree node as oneself
 type IdentityClaimRequest struct {
-	ID          string    `json:"id" firestore:"id"`
-	UserID      string    `json:"user_id" firestore:"user_id"`
-	UserEmail   string    `json:"user_email" firestore:"user_email"`
-	PersonID    string    `json:"person_id" firestore:"person_id"`       // The tree node they claim to be
-	PersonName  string    `json:"person_name" firestore:"person_name"`   // Name of the person for display
-	Message     string    `json:"message" firestore:"message"`           // Why they believe this is them
-	Status      string    `json:"status" firestore:"status"`             // pending, approved, rejected
-	ReviewedBy  string    `json:"reviewed_by" firestore:"reviewed_by"`   // Admin who reviewed
-	ReviewNotes string    `json:"review_notes" firestore:"review_notes"` // Admin's notes
-	CreatedAt   time.Time `json:"created_at" firestore:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" firestore:"updated_at"`
+	ID                string    `json:"id" firestore:"id"`
+	UserID            string    `json:"user_id" firestore:"user_id"`
+	UserEmail         string    `json:"user_email" firestore:"user_email"`
+	PersonID          string    `json:"person_id" firestore:"person_id"`                   // The tree node they claim to be
+	PersonName        string    `json:"person_name" firestore:"person_name"`               // Name of the person for display
+	Message           string    `json:"message" firestore:"message"`                       // Why they believe this is them
+	Evidence          []string  `json:"evidence" firestore:"evidence"`                     // Links to supporting photos/documents
+	InstagramUsername string    `json:"instagram_username" firestore:"instagram_username"` // Optional, stored on the person on approval
+	Status            string    `json:"status" firestore:"status"`                         // pending, approved, rejected
+	ReviewedBy        string    `json:"reviewed_by" firestore:"reviewed_by"`               // Admin who reviewed
+	ReviewNotes       string    `json:"review_notes" firestore:"review_notes"`             // Admin's notes
+	CreatedAt         time.Time `json:"created_at" firestore:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// Relationship types of a child to the parent listing it in children
+const (
+	RelationshipBiological = "biological"
+	RelationshipAdopted    = "adopted"
+	RelationshipStep       = "step"
+	RelationshipFoster     = "foster"
+)
+
+// ValidRelationshipType reports whether t is a known relationship type
+func ValidRelationshipType(t string) bool {
+	switch t {
+	case RelationshipBiological, RelationshipAdopted, RelationshipStep, RelationshipFoster:
+		return true
+	}
+	return false
+}
+
+// ParentRelationship returns the person's relationship type to their parent,
+// defaulting to biological for people stored before the field existed
+func (p Person) ParentRelationship() string {
+	if p.RelationshipType == "" {
+		return RelationshipBiological
+	}
+	return p.RelationshipType
 }
 
 // Person represents a family tree member
 type Person struct {
 	ID                  string    `json:"id" firestore:"id"`
 	Name                string    `json:"name" firestore:"name"`
-	Role                string    `json:"role" firestore:"role"`
-	Gender              string    `json:"gender" firestore:"gender"` // "male", "female", or empty
+	AltNames            []string  `json:"alt_names" firestore:"alt_names"`               // Nicknames, maiden names, etc.
+	Role                string    `json:"role" firestore:"role"`                         // Family relationship label (e.g. "Father")
+	Occupation          string    `json:"occupation" firestore:"occupation"`             // Job or title, optional
+	Gender              string    `json:"gender" firestore:"gender"`                     // "male", "female", or empty
+	GenderDefaulted     bool      `json:"gender_defaulted" firestore:"gender_defaulted"` // Gender was a default, not given explicitly
 	Birth               string    `json:"birth" firestore:"birth"`
+	Death               string    `json:"death" firestore:"death"`       // Same formats as birth, optional
+	Deceased            bool      `json:"deceased" firestore:"deceased"` // Also true when only the death date is unknown
 	Location            string    `json:"location" firestore:"location"` // Legacy, optional
 	Avatar              string    `json:"avatar" firestore:"avatar"`
 	Bio                 string    `json:"bio" firestore:"bio"` // Legacy, optional
 	Children            []string  `json:"children" firestore:"children"`
+	ParentIDs           []string  `json:"parent_ids" firestore:"parent_ids"`               // Kept in step with the parents' children arrays
+	Spouses             []string  `json:"spouses" firestore:"spouses"`                     // Symmetric: each spouse lists the other
+	RelationshipType    string    `json:"relationship_type" firestore:"relationship_type"` // To the parent listing this person; empty means biological
+	DataVerified        bool      `json:"data_verified" firestore:"data_verified"`         // Record was vetted by an admin/co-admin
+	DataVerifiedBy      string    `json:"data_verified_by" firestore:"data_verified_by"`   // User ID of the vetting approver
+	DataVerifiedAt      time.Time `json:"data_verified_at" firestore:"data_verified_at"`
 	CreatedBy           string    `json:"created_by" firestore:"created_by"`                       // User ID of creator
+	TreeID              string    `json:"tree_id,omitempty" firestore:"tree_id,omitempty"`         // Tree this person belongs to; empty for the original tree
 	LinkedUserID        string    `json:"linked_user_id" firestore:"linked_user_id"`               // User ID if someone claimed this identity
 	InstagramUsername   string    `json:"instagram_username" firestore:"instagram_username"`       // Instagram handle
 	InstagramAvatarURL  string    `json:"instagram_avatar_url" firestore:"instagram_avatar_url"`   // Cached Instagram profile picture URL
@@ -132,23 +283,125 @@ type Person struct {
 	LikedBy             []string  `json:"liked_by" firestore:"liked_by"`                           // User IDs who liked
 	CreatedAt           time.Time `json:"created_at" firestore:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at" firestore:"updated_at"`
+	Age                 *int      `json:"age,omitempty" firestore:"-"` // Computed on read from birth (and death); never stored
+}
+
+// PersonSummary is a lightweight view of a person for relationship listings
+type PersonSummary struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Gender           string `json:"gender"`
+	Birth            string `json:"birth"`
+	Avatar           string `json:"avatar"`
+	RelationshipType string `json:"relationship_type"` // To the parent listing this person
+}
+
+// Summary returns the lightweight view of the person
+func (p Person) Summary() PersonSummary {
+	return PersonSummary{
+		ID:               p.ID,
+		Name:             p.Name,
+		Gender:           p.Gender,
+		Birth:            p.Birth,
+		Avatar:           p.Avatar,
+		RelationshipType: p.ParentRelationship(),
+	}
 }
 
 // RegisterRequest represents registration data
 type RegisterRequest struct {
 	Email      string `json:"email" binding:"required,email"`
 	Password   string `json:"password" binding:"required,min=6"`
-	TreeName   string `json:"tree_name" binding:"required"` // Must be "Batur" for now
+	TreeName   string `json:"tree_name"`   // Tree to join by name; the original tree is matched against the tree settings
+	TreeID     string `json:"tree_id"`     // Tree to join by id; takes precedence over tree_name
+	InviteCode string `json:"invite_code"` // Joins the invite's tree with its role, already verified
 	FatherName string `json:"father_name" binding:"required"`
 	BirthYear  string `json:"birth_year" binding:"required"`
 }
 
+// Tree is one family tree hosted by the service
+type Tree struct {
+	ID        string    `json:"id" firestore:"id"`
+	Name      string    `json:"name" firestore:"name"`
+	OwnerID   string    `json:"owner_id" firestore:"owner_id"` // Admin who created the tree
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// Invite lets people register into a tree without the father-name check. The document ID
+// is the code's SHA-256 hash; the code itself is only shown to the admin who created it.
+type Invite struct {
+	Role      UserRole  `json:"role" firestore:"role"`
+	TreeID    string    `json:"tree_id" firestore:"tree_id"`
+	TreeName  string    `json:"tree_name" firestore:"tree_name"`
+	MaxUses   int       `json:"max_uses" firestore:"max_uses"`
+	Uses      int       `json:"uses" firestore:"uses"`
+	ExpiresAt time.Time `json:"expires_at" firestore:"expires_at"` // Zero means the invite never expires
+	CreatedBy string    `json:"created_by" firestore:"created_by"`
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+}
+
+// CreateInviteRequest creates an invite code. Role defaults to viewer and MaxUses to 1;
+// ExpiresInHours of 0 means no expiry.
+type CreateInviteRequest struct {
+	Role           UserRole `json:"role"`
+	MaxUses        int      `json:"max_uses"`
+	ExpiresInHours int      `json:"expires_in_hours"`
+}
+
+// CreateTreeRequest creates a new family tree
+type CreateTreeRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
 // LoginRequest represents login credentials
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
 
+// ForgotPasswordRequest asks for a password reset link to be emailed
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password using an emailed reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ChangePasswordRequest changes the current user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// RefreshTokenRequest presents a refresh token to rotate or revoke
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken is an issued refresh token; the document ID is the token's SHA-256 hash.
+// Tokens descending from one login share a FamilyID so reuse can revoke them all.
+type RefreshToken struct {
+	UserID     string    `json:"user_id" firestore:"user_id"`
+	FamilyID   string    `json:"family_id" firestore:"family_id"`
+	ExpiresAt  time.Time `json:"expires_at" firestore:"expires_at"`
+	RevokedAt  time.Time `json:"revoked_at" firestore:"revoked_at"`   // Zero while the token is usable
+	ReplacedBy string    `json:"replaced_by" firestore:"replaced_by"` // Hash of the token issued when this one was rotated
+	CreatedAt  time.Time `json:"created_at" firestore:"created_at"`
+}
+
+// PasswordReset is a pending reset token; the document ID is the token's SHA-256 hash,
+// so the token itself is never stored
+type PasswordReset struct {
+	UserID    string    `json:"user_id" firestore:"user_id"`
+	ExpiresAt time.Time `json:"expires_at" firestore:"expires_at"`
+	UsedAt    time.Time `json:"used_at" firestore:"used_at"` // Zero until the token is redeemed
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+}
+
 // PermissionRequestRequest represents a request to elevate permissions
 type PermissionRequestRequest struct {
 	RequestedRole UserRole `json:"requested_role" binding:"required"`
@@ -175,39 +428,86 @@ type LoginResponse struct {
 
 // CreatePersonRequest represents a request to create a person
 type CreatePersonRequest struct {
-	Name     string   `json:"name" binding:"required"`
-	Role     string   `json:"role" binding:"required"`
-	Gender   string   `json:"gender"`   // "male", "female", or empty - used for avatar generation
-	Birth    string   `json:"birth"`    // Optional
-	Location string   `json:"location"` // Legacy, optional
-	Avatar   string   `json:"avatar"`   // Optional - backend generates default if empty
-	Bio      string   `json:"bio"`      // Legacy, optional
-	Children []string `json:"children"`
-	ParentID *string  `json:"parent_id"` // Optional parent ID - backend will handle the relationship
+	Name             string   `json:"name" binding:"required"`
+	AltNames         []string `json:"alt_names"` // Optional nicknames / maiden names
+	Role             string   `json:"role" binding:"required"`
+	Occupation       string   `json:"occupation"` // Optional job/title
+	Gender           string   `json:"gender"`     // "male", "female", or empty - used for avatar generation
+	Birth            string   `json:"birth"`      // Optional
+	Death            string   `json:"death"`      // Optional, implies deceased
+	Deceased         bool     `json:"deceased"`   // Optional
+	Location         string   `json:"location"`   // Legacy, optional
+	Avatar           string   `json:"avatar"`     // Optional - backend generates default if empty
+	Bio              string   `json:"bio"`        // Legacy, optional
+	Children         []string `json:"children"`
+	ParentID         *string  `json:"parent_id"`         // Optional parent ID - backend will handle the relationship
+	ParentIDs        []string `json:"parent_ids"`        // Optional, up to two parents (e.g. mother and father); combined with parent_id
+	RelationshipType string   `json:"relationship_type"` // To the parent: biological (default), adopted, step or foster
 }
 
 // UpdatePersonRequest represents a request to update a person
 type UpdatePersonRequest struct {
 	Name              *string  `json:"name"`
+	AltNames          []string `json:"alt_names"`
 	Role              *string  `json:"role"`
+	Occupation        *string  `json:"occupation"`
 	Birth             *string  `json:"birth"`
+	Death             *string  `json:"death"`    // Setting a date also marks the person deceased
+	Deceased          *bool    `json:"deceased"` // Pointer so it can be set back to false
 	Location          *string  `json:"location"`
 	Avatar            *string  `json:"avatar"`
 	Bio               *string  `json:"bio"`
 	Children          []string `json:"children"`
+	RelationshipType  *string  `json:"relationship_type"`
 	InstagramUsername *string  `json:"instagram_username"`
 }
 
+// SpouseRequest names the person to link as a spouse
+type SpouseRequest struct {
+	SpouseID string `json:"spouse_id" binding:"required"`
+}
+
+// MovePersonRequest re-homes a person (and their subtree) under another parent
+type MovePersonRequest struct {
+	NewParentID  string `json:"new_parent_id" binding:"required"`
+	FromParentID string `json:"from_parent_id"` // Optional: only replace this parent; default replaces all current parents
+}
+
+// ReorderChildrenRequest is a person's existing children in their new (birth) order
+type ReorderChildrenRequest struct {
+	Children []string `json:"children" binding:"required"`
+}
+
+// LikesStatusRequest asks which of the given people the caller has liked
+type LikesStatusRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// BatchLikeRequest likes or unlikes several people at once
+type BatchLikeRequest struct {
+	IDs    []string `json:"ids" binding:"required"`
+	Action string   `json:"action" binding:"required"` // "like" or "unlike"
+}
+
 // ClaimIdentityRequest represents a user's request to claim a tree node
 type ClaimIdentityRequest struct {
-	PersonID string `json:"person_id" binding:"required"` // The tree node ID they claim to be
-	Message  string `json:"message"`                      // Why they believe this is them
+	PersonID          string   `json:"person_id" binding:"required"` // The tree node ID they claim to be
+	Message           string   `json:"message"`                      // Why they believe this is them
+	Evidence          []string `json:"evidence"`                     // Optional http(s) links to supporting material
+	InstagramUsername string   `json:"instagram_username"`           // Optional, enriched onto the person on approval
 }
 
 // ReviewClaimRequest represents admin's review of an identity claim
 type ReviewClaimRequest struct {
-	Approved    bool   `json:"approved"`
-	ReviewNotes string `json:"review_notes"`
+	Approved        bool   `json:"approved"`
+	ReviewNotes     string `json:"review_notes"`
+	DeferEnrichment bool   `json:"defer_enrichment"` // Store the claim's Instagram username only, enrich separately
+}
+
+// ResolveClaimConflictRequest picks the winning claim among competing claims for one person
+type ResolveClaimConflictRequest struct {
+	ClaimID     string `json:"claim_id" binding:"required"` // Claim to approve; the other pending claims are rejected
+	ReviewNotes string `json:"review_notes"`                // Sent to the rejected claimants
 }
 
 // CreateSuggestionRequest represents a request to suggest a tree change
@@ -249,11 +549,32 @@ type BatchReviewRequest struct {
 	ReviewNotes   string   `json:"review_notes"`
 }
 
+// ClearQueueRequest confirms clearing every pending item of a review queue
+type ClearQueueRequest struct {
+	Confirm bool   `json:"confirm"`                   // Must be true
+	Reason  string `json:"reason" binding:"required"` // Recorded as the review notes of each item
+}
+
 // UpdateUserRoleRequest represents a request to change a user's role
 type UpdateUserRoleRequest struct {
 	Role UserRole `json:"role" binding:"required"`
 }
 
+// CreateUserRequest provisions an account on someone's behalf. An empty password is
+// generated, an empty role means viewer, and PersonID optionally links a tree node.
+type CreateUserRequest struct {
+	Email    string   `json:"email" binding:"required,email"`
+	Password string   `json:"password"`
+	Role     UserRole `json:"role"`
+	PersonID string   `json:"person_id"`
+}
+
+// MergeUsersRequest merges a duplicate account into the account being kept
+type MergeUsersRequest struct {
+	KeepID  string `json:"keep_id" binding:"required"`
+	MergeID string `json:"merge_id" binding:"required"`
+}
+
 // UserListResponse represents a user in the admin user list
 // PersonID is derived from Person.LinkedUserID (Person owns the relationship)
 type UserListResponse struct {
@@ -267,6 +588,26 @@ type UserListResponse struct {
 	CreatedAt  string   `json:"created_at"`
 }
 
+// LinkedPersonResponse is a tree node together with the account linked to it
+type LinkedPersonResponse struct {
+	PersonID   string   `json:"person_id"`
+	PersonName string   `json:"person_name"`
+	UserID     string   `json:"user_id"`
+	UserEmail  string   `json:"user_email"` // Empty if the linked user no longer exists
+	UserRole   UserRole `json:"user_role"`
+}
+
+// PendingVerificationUser is an unverified user with the details they registered with
+type PendingVerificationUser struct {
+	ID         string   `json:"id"`
+	Email      string   `json:"email"`
+	Role       UserRole `json:"role"`
+	TreeName   string   `json:"tree_name"`
+	FatherName string   `json:"father_name"`
+	BirthYear  string   `json:"birth_year"`
+	CreatedAt  string   `json:"created_at"`
+}
+
 // SuggestionResponse represents a suggestion in API responses
 type SuggestionResponse struct {
 	ID             string      `json:"id"`
@@ -281,6 +622,9 @@ type SuggestionResponse struct {
 	ReviewedBy     string      `json:"reviewed_by,omitempty"`
 	ReviewerEmail  string      `json:"reviewer_email,omitempty"`
 	ReviewNotes    string      `json:"review_notes,omitempty"`
+	ReviewingBy    string      `json:"reviewing_by,omitempty"` // Only set while the review claim is active
+	ReviewingEmail string      `json:"reviewing_email,omitempty"`
+	ReviewingUntil string      `json:"reviewing_until,omitempty"`
 	CreatedAt      string      `json:"created_at"`
 	UpdatedAt      string      `json:"updated_at"`
 }
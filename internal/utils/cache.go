@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// TTLCache is a small in-memory key/value cache whose entries expire after a fixed duration
+type TTLCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]ttlEntry
+}
+
+type ttlEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// NewTTLCache creates a cache whose entries live for ttl
+func NewTTLCache(ttl time.Duration) *TTLCache {
+	return &TTLCache{
+		ttl:     ttl,
+		entries: make(map[string]ttlEntry),
+	}
+}
+
+// Get returns the cached value for key if present and not expired
+func (c *TTLCache) Get(key string) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set stores value under key, replacing any existing entry
+func (c *TTLCache) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = ttlEntry{value: value, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// Delete removes key from the cache
+func (c *TTLCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// Clear removes all entries
+func (c *TTLCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]ttlEntry)
+}
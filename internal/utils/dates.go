@@ -0,0 +1,279 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	isoDatePattern   = regexp.MustCompile(`^(\d{4})-\d{1,2}(-\d{1,2})?$`)
+	slashDatePattern = regexp.MustCompile(`^\d{1,2}[/.-]\d{1,2}[/.-](\d{4})$`)
+	yearPattern      = regexp.MustCompile(`^\d{4}$`)
+	dateSeparator    = regexp.MustCompile(`[/.-]`)
+)
+
+// ParseBirthYear extracts the year from a stored birth string
+// Supported formats: "YYYY", "YYYY-MM", "YYYY-MM-DD", "DD/MM/YYYY" (also with . or - separators)
+func ParseBirthYear(birth string) (int, bool) {
+	birth = strings.TrimSpace(birth)
+	if birth == "" {
+		return 0, false
+	}
+
+	var yearStr string
+	switch {
+	case yearPattern.MatchString(birth):
+		yearStr = birth
+	case isoDatePattern.MatchString(birth):
+		yearStr = isoDatePattern.FindStringSubmatch(birth)[1]
+	case slashDatePattern.MatchString(birth):
+		yearStr = slashDatePattern.FindStringSubmatch(birth)[1]
+	default:
+		return 0, false
+	}
+
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		return 0, false
+	}
+	return year, true
+}
+
+// parseDateParts splits a stored date into year, month and day; month and day are 0
+// when the format doesn't include them (same formats as ParseBirthYear)
+func parseDateParts(date string) (year, month, day int, ok bool) {
+	date = strings.TrimSpace(date)
+	year, ok = ParseBirthYear(date)
+	if !ok || yearPattern.MatchString(date) {
+		return year, 0, 0, ok
+	}
+	parts := dateSeparator.Split(date, -1)
+	if isoDatePattern.MatchString(date) {
+		month, _ = strconv.Atoi(parts[1])
+		if len(parts) == 3 {
+			day, _ = strconv.Atoi(parts[2])
+		}
+	} else {
+		day, _ = strconv.Atoi(parts[0])
+		month, _ = strconv.Atoi(parts[1])
+	}
+	if month < 1 || month > 12 {
+		month, day = 0, 0
+	}
+	if day < 1 || day > 31 {
+		day = 0
+	}
+	return year, month, day, true
+}
+
+// Age returns a person's age in whole years: at death when deceased, otherwise at now.
+// With only years known it is the difference of the years. It reports false when the
+// birth (or a deceased person's death) has no recognizable year, or the result is negative.
+func Age(birth, death string, deceased bool, now time.Time) (int, bool) {
+	by, bm, bd, ok := parseDateParts(birth)
+	if !ok {
+		return 0, false
+	}
+	ay, am, ad := now.Year(), int(now.Month()), now.Day()
+	if deceased {
+		if ay, am, ad, ok = parseDateParts(death); !ok {
+			return 0, false
+		}
+	}
+
+	age := ay - by
+	if bm > 0 && am > 0 && (am < bm || (am == bm && bd > 0 && ad > 0 && ad < bd)) {
+		age--
+	}
+	if age < 0 {
+		return 0, false
+	}
+	return age, true
+}
+
+// gedcomMonths are the month abbreviations used in GEDCOM dates
+var gedcomMonths = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+// GEDCOMDate converts a stored birth string to a GEDCOM date ("1980", "MAR 1980", "5 MAR 1980").
+// Unrecognized text is returned as a date phrase in parentheses; empty input returns "".
+func GEDCOMDate(birth string) string {
+	birth = strings.TrimSpace(birth)
+	if birth == "" {
+		return ""
+	}
+
+	var year, month, day string
+	switch {
+	case yearPattern.MatchString(birth):
+		year = birth
+	case isoDatePattern.MatchString(birth):
+		parts := strings.Split(birth, "-")
+		year, month = parts[0], parts[1]
+		if len(parts) == 3 {
+			day = parts[2]
+		}
+	case slashDatePattern.MatchString(birth):
+		parts := dateSeparator.Split(birth, -1)
+		day, month, year = parts[0], parts[1], parts[2]
+	default:
+		return "(" + birth + ")"
+	}
+
+	if month == "" {
+		return year
+	}
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 {
+		return "(" + birth + ")"
+	}
+	if day == "" {
+		return fmt.Sprintf("%s %s", gedcomMonths[m-1], year)
+	}
+	d, err := strconv.Atoi(day)
+	if err != nil || d < 1 || d > 31 {
+		return "(" + birth + ")"
+	}
+	return fmt.Sprintf("%d %s %s", d, gedcomMonths[m-1], year)
+}
+
+// ParseGEDCOMDate converts a GEDCOM date to the stored format: "1980", "MAR 1980" and
+// "5 MAR 1980" become "1980", "1980-03" and "1980-03-05". Date phrases lose their
+// parentheses; anything else (ranges, approximations) is kept as free text.
+func ParseGEDCOMDate(date string) string {
+	date = strings.TrimSpace(date)
+	if strings.HasPrefix(date, "(") && strings.HasSuffix(date, ")") {
+		return strings.TrimSpace(date[1 : len(date)-1])
+	}
+
+	parts := strings.Fields(strings.ToUpper(date))
+	if len(parts) == 0 || len(parts) > 3 || !yearPattern.MatchString(parts[len(parts)-1]) {
+		return date
+	}
+	year := parts[len(parts)-1]
+	if len(parts) == 1 {
+		return year
+	}
+	month := 0
+	for i, m := range gedcomMonths {
+		if parts[len(parts)-2] == m {
+			month = i + 1
+		}
+	}
+	if month == 0 {
+		return date
+	}
+	if len(parts) == 2 {
+		return fmt.Sprintf("%s-%02d", year, month)
+	}
+	day, err := strconv.Atoi(parts[0])
+	if err != nil || day < 1 || day > 31 {
+		return date
+	}
+	return fmt.Sprintf("%s-%02d-%02d", year, month, day)
+}
+
+// ValidateBirthYear rejects a birth whose year lies in the future or more than maxAge
+// years in the past. Births without a recognizable year are accepted as free text.
+func ValidateBirthYear(birth string, maxAge int, now time.Time) error {
+	year, ok := ParseBirthYear(birth)
+	if !ok {
+		return nil
+	}
+	if year > now.Year() {
+		return fmt.Errorf("birth year %d is in the future", year)
+	}
+	if maxAge > 0 && year < now.Year()-maxAge {
+		return fmt.Errorf("birth year %d is more than %d years ago", year, maxAge)
+	}
+	return nil
+}
+
+// ValidateDeathYear rejects a death year in the future or before the birth year.
+// Unparseable values are accepted, matching ValidateBirthYear.
+func ValidateDeathYear(death, birth string, now time.Time) error {
+	year, ok := ParseBirthYear(death)
+	if !ok {
+		return nil
+	}
+	if year > now.Year() {
+		return fmt.Errorf("death year %d is in the future", year)
+	}
+	if born, ok := ParseBirthYear(birth); ok && year < born {
+		return fmt.Errorf("death year %d is before birth year %d", year, born)
+	}
+	return nil
+}
+
+// DatedPerson is the minimal person data needed for date consistency checks
+type DatedPerson struct {
+	ID       string
+	Name     string
+	Birth    string
+	Children []string
+}
+
+// DateIssue is a parent-child pair whose birth years are implausible
+type DateIssue struct {
+	ParentID   string `json:"parent_id"`
+	ParentName string `json:"parent_name"`
+	ParentYear int    `json:"parent_year"`
+	ChildID    string `json:"child_id"`
+	ChildName  string `json:"child_name"`
+	ChildYear  int    `json:"child_year"`
+	Gap        int    `json:"gap"`   // child year - parent year
+	Issue      string `json:"issue"` // "child_born_before_parent" or "parent_too_young"
+}
+
+// ValidateTreeDateConsistency checks every parent-child pair with parseable birth years and
+// flags children born before their parent or when the parent was younger than minParentAge.
+// Pairs where either year can't be parsed are skipped.
+func ValidateTreeDateConsistency(people []DatedPerson, minParentAge int) []DateIssue {
+	byID := make(map[string]DatedPerson, len(people))
+	for _, p := range people {
+		byID[p.ID] = p
+	}
+
+	issues := []DateIssue{}
+	for _, parent := range people {
+		parentYear, ok := ParseBirthYear(parent.Birth)
+		if !ok {
+			continue
+		}
+		for _, childID := range parent.Children {
+			child, exists := byID[childID]
+			if !exists {
+				continue
+			}
+			childYear, ok := ParseBirthYear(child.Birth)
+			if !ok {
+				continue
+			}
+
+			gap := childYear - parentYear
+			issue := ""
+			if gap <= 0 {
+				issue = "child_born_before_parent"
+			} else if gap < minParentAge {
+				issue = "parent_too_young"
+			}
+			if issue == "" {
+				continue
+			}
+
+			issues = append(issues, DateIssue{
+				ParentID:   parent.ID,
+				ParentName: parent.Name,
+				ParentYear: parentYear,
+				ChildID:    child.ID,
+				ChildName:  child.Name,
+				ChildYear:  childYear,
+				Gap:        gap,
+				Issue:      issue,
+			})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBirthYear(t *testing.T) {
+	tests := []struct {
+		birth  string
+		want   int
+		wantOK bool
+	}{
+		{"1980", 1980, true},
+		{" 1980 ", 1980, true},
+		{"1980-03", 1980, true},
+		{"1980-3-5", 1980, true},
+		{"1980-03-05", 1980, true},
+		{"05/03/1980", 1980, true},
+		{"5.3.1980", 1980, true},
+		{"05-03-1980", 1980, true},
+		{"1359", 1359, true}, // Solar Hijri years are kept as given
+		{"", 0, false},
+		{"about 1980", 0, false},
+		{"80", 0, false},
+		{"1980/03/05", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseBirthYear(tt.birth)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseBirthYear(%q) = %d, %v; want %d, %v", tt.birth, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseDateParts(t *testing.T) {
+	tests := []struct {
+		date             string
+		year, month, day int
+		ok               bool
+	}{
+		{"1980", 1980, 0, 0, true},
+		{"1980-03", 1980, 3, 0, true},
+		{"1980-03-05", 1980, 3, 5, true},
+		{"05/03/1980", 1980, 3, 5, true},
+		{"5.3.1980", 1980, 3, 5, true},
+		{"1980-13-05", 1980, 0, 0, true}, // Invalid month keeps only the year
+		{"32/03/1980", 1980, 3, 0, true}, // Invalid day keeps year and month
+		{"unknown", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		year, month, day, ok := parseDateParts(tt.date)
+		if year != tt.year || month != tt.month || day != tt.day || ok != tt.ok {
+			t.Errorf("parseDateParts(%q) = %d, %d, %d, %v; want %d, %d, %d, %v",
+				tt.date, year, month, day, ok, tt.year, tt.month, tt.day, tt.ok)
+		}
+	}
+}
+
+func TestAge(t *testing.T) {
+	now := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		birth    string
+		death    string
+		deceased bool
+		want     int
+		wantOK   bool
+	}{
+		{"year only", "1980", "", false, 44, true},
+		{"birthday passed", "1980-03-05", "", false, 44, true},
+		{"birthday not yet", "1980-09-01", "", false, 43, true},
+		{"birthday later this month", "20/06/1980", "", false, 43, true},
+		{"deceased", "1900", "1975-01-01", true, 75, true},
+		{"deceased before birthday", "1900-05-10", "1975-05-09", true, 74, true},
+		{"deceased without death date", "1900", "", true, 0, false},
+		{"unparseable birth", "unknown", "", false, 0, false},
+		{"born in the future", "2030", "", false, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Age(tt.birth, tt.death, tt.deceased, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: Age = %d, %v; want %d, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -78,62 +78,10 @@ Consider these Persian-specific variations:
 Respond ONLY with a JSON object (no markdown, no code blocks):
 {"are_similar": true/false, "confidence": 0.0-1.0, "explanation": "brief explanation in English"}`, name1, name2)
 
-	reqBody := GeminiRequest{
-		Contents: []GeminiContent{
-			{
-				Parts: []GeminiPart{
-					{Text: prompt},
-				},
-			},
-		},
-	}
-
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash:generateContent?key=%s", apiKey)
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	responseText, err := generateWithGemini(apiKey, prompt)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var geminiResp GeminiResponse
-	if err := json.Unmarshal(body, &geminiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse Gemini response: %v", err)
-	}
-
-	if geminiResp.Error != nil {
-		return nil, fmt.Errorf("Gemini API error: %s", geminiResp.Error.Message)
-	}
-
-	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("empty response from Gemini")
-	}
-
-	responseText := geminiResp.Candidates[0].Content.Parts[0].Text
-	responseText = strings.TrimSpace(responseText)
-
-	// Clean up potential markdown code blocks
-	responseText = strings.TrimPrefix(responseText, "```json")
-	responseText = strings.TrimPrefix(responseText, "```")
-	responseText = strings.TrimSuffix(responseText, "```")
-	responseText = strings.TrimSpace(responseText)
 
 	var result GeminiNameMatchResult
 	if err := json.Unmarshal([]byte(responseText), &result); err != nil {
@@ -187,6 +135,22 @@ Format: [{"person_id": "id", "name": "name", "similarity": 0.0-1.0, "match_type"
 
 Only include names with similarity > 0.7`, targetName, namesList.String())
 
+	responseText, err := generateWithGemini(apiKey, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []NameMatchResult
+	if err := json.Unmarshal([]byte(responseText), &results); err != nil {
+		return nil, fmt.Errorf("failed to parse Gemini results: %v", err)
+	}
+
+	return results, nil
+}
+
+// generateWithGemini sends a single prompt to Gemini and returns the response text with any
+// markdown code fences stripped
+func generateWithGemini(apiKey, prompt string) (string, error) {
 	reqBody := GeminiRequest{
 		Contents: []GeminiContent{
 			{
@@ -199,7 +163,7 @@ Only include names with similarity > 0.7`, targetName, namesList.String())
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash:generateContent?key=%s", apiKey)
@@ -207,45 +171,207 @@ Only include names with similarity > 0.7`, targetName, namesList.String())
 	client := &http.Client{Timeout: 30 * time.Second}
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	var geminiResp GeminiResponse
 	if err := json.Unmarshal(body, &geminiResp); err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to parse Gemini response: %v", err)
 	}
 
 	if geminiResp.Error != nil {
-		return nil, fmt.Errorf("Gemini API error: %s", geminiResp.Error.Message)
+		return "", fmt.Errorf("Gemini API error: %s", geminiResp.Error.Message)
 	}
 
 	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("empty response from Gemini")
+		return "", fmt.Errorf("empty response from Gemini")
 	}
 
 	responseText := geminiResp.Candidates[0].Content.Parts[0].Text
 	responseText = strings.TrimSpace(responseText)
+
+	// Clean up potential markdown code blocks
 	responseText = strings.TrimPrefix(responseText, "```json")
 	responseText = strings.TrimPrefix(responseText, "```")
 	responseText = strings.TrimSuffix(responseText, "```")
-	responseText = strings.TrimSpace(responseText)
+	return strings.TrimSpace(responseText), nil
+}
 
-	var results []NameMatchResult
-	if err := json.Unmarshal([]byte(responseText), &results); err != nil {
+// SanityCheckPerson is a person as described to Gemini for a date sanity check
+type SanityCheckPerson struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Birth string `json:"birth"`
+}
+
+// GeminiDateWarning is an implausible date relationship flagged by Gemini
+type GeminiDateWarning struct {
+	PersonID string `json:"person_id"` // The relative involved (or the subject itself)
+	Severity string `json:"severity"`  // "warning" or "error"
+	Message  string `json:"message"`
+}
+
+// CheckDateSanityWithGemini asks Gemini whether a person's birth date is plausible given
+// their parents' and children's birth dates
+func CheckDateSanityWithGemini(subject SanityCheckPerson, parents, children []SanityCheckPerson) ([]GeminiDateWarning, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY not set")
+	}
+
+	describe := func(people []SanityCheckPerson) string {
+		if len(people) == 0 {
+			return "(none)\n"
+		}
+		var b strings.Builder
+		for _, p := range people {
+			birth := p.Birth
+			if birth == "" {
+				birth = "unknown"
+			}
+			b.WriteString(fmt.Sprintf("- ID: %s, Name: %s, Birth: %s\n", p.ID, p.Name, birth))
+		}
+		return b.String()
+	}
+
+	subjectBirth := subject.Birth
+	if subjectBirth == "" {
+		subjectBirth = "unknown"
+	}
+
+	prompt := fmt.Sprintf(`You are checking a family tree for data-entry mistakes in birth dates.
+
+Person: ID: %s, Name: %s, Birth: %s
+
+Parents:
+%s
+Children:
+%s
+Flag any implausible date relationships, for example:
+1. A child born before (or in the same year as) a parent
+2. A parent who would have been younger than about 12 or older than about 70 at a child's birth
+3. A birth date in the future or an unparseable date
+Dates may be years (1350) or full dates, and may use the Persian (Solar Hijri) calendar; judge consistency within the family rather than against today's Gregorian year when calendars look mixed.
+
+Respond ONLY with a JSON array (no markdown, no code blocks). If everything is plausible, return [].
+Format: [{"person_id": "id of the relative involved, or the person's own id", "severity": "warning" or "error", "message": "brief explanation in English"}]`,
+		subject.ID, subject.Name, subjectBirth, describe(parents), describe(children))
+
+	responseText, err := generateWithGemini(apiKey, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []GeminiDateWarning
+	if err := json.Unmarshal([]byte(responseText), &warnings); err != nil {
 		return nil, fmt.Errorf("failed to parse Gemini results: %v", err)
 	}
 
+	return warnings, nil
+}
+
+// InferGendersWithGemini asks Gemini for the likely gender of each name.
+// The result maps each input name to "male" or "female"; names Gemini is unsure of are omitted.
+func InferGendersWithGemini(names []string) (map[string]string, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY not set")
+	}
+
+	var namesList strings.Builder
+	for _, name := range names {
+		namesList.WriteString(fmt.Sprintf("- %s\n", name))
+	}
+
+	prompt := fmt.Sprintf(`You are an expert in Persian and Arabic names. For each full name below, give the most likely gender of the person based on their first name.
+
+Names:
+%s
+Respond ONLY with a JSON object (no markdown, no code blocks) mapping each name exactly as given to "male" or "female". Leave out any name where you are not confident.`, namesList.String())
+
+	responseText, err := generateWithGemini(apiKey, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(responseText), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse Gemini results: %v", err)
+	}
+
+	results := make(map[string]string, len(raw))
+	for name, gender := range raw {
+		gender = strings.ToLower(strings.TrimSpace(gender))
+		if gender == "male" || gender == "female" {
+			results[name] = gender
+		}
+	}
+	return results, nil
+}
+
+// GeminiNameVariants lists the spellings Gemini considers the same name as Name
+type GeminiNameVariants struct {
+	Name    string `json:"name"`
+	Matches []struct {
+		Name       string  `json:"name"`
+		Similarity float64 `json:"similarity"`
+	} `json:"matches"`
+}
+
+// MatchNameVariantsWithGemini asks Gemini, in a single prompt, which of the given spellings
+// are variants of each target name. Targets with no variants may be omitted from the result.
+func MatchNameVariantsWithGemini(targets, spellings []string) ([]GeminiNameVariants, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY not set")
+	}
+
+	var targetList, spellingList strings.Builder
+	for _, name := range targets {
+		targetList.WriteString(fmt.Sprintf("- %s\n", name))
+	}
+	for _, name := range spellings {
+		spellingList.WriteString(fmt.Sprintf("- %s\n", name))
+	}
+
+	prompt := fmt.Sprintf(`You are an expert in Persian and Arabic names. For each target name, find the names in the family tree list that are spellings of the same name.
+
+Target names:
+%s
+Names in the tree:
+%s
+Consider these Persian-specific variations:
+1. Similar-sounding letters that are often confused (ذ/ز/ض, ث/س/ص, ط/ت, ق/غ, ح/ه, ع/ا at word start)
+2. Tanvin and tashdid variations (محمد vs محمّد)
+3. Space variations (محمد علی vs محمدعلی)
+4. Arabic vs Persian characters (ي vs ی, ك vs ک, ة vs ه)
+5. Transliteration from English variations
+
+Respond ONLY with a JSON array (no markdown, no code blocks), copying names exactly as given. Leave out targets with no matches.
+Format: [{"name": "target name", "matches": [{"name": "name from the tree", "similarity": 0.0-1.0}]}]
+
+Only include matches with similarity > 0.7`, targetList.String(), spellingList.String())
+
+	responseText, err := generateWithGemini(apiKey, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []GeminiNameVariants
+	if err := json.Unmarshal([]byte(responseText), &results); err != nil {
+		return nil, fmt.Errorf("failed to parse Gemini results: %v", err)
+	}
 	return results, nil
 }
@@ -0,0 +1,65 @@
+package utils
+
+import "strings"
+
+// persianNameGenders maps common Persian first names (normalized) to a gender
+var persianNameGenders = map[string]string{
+	// Male
+	"محمد": "male", "علی": "male", "حسن": "male", "حسین": "male", "رضا": "male",
+	"مهدی": "male", "احمد": "male", "ابراهیم": "male", "اسماعیل": "male", "یوسف": "male",
+	"جعفر": "male", "عباس": "male", "اکبر": "male", "اصغر": "male", "قربان": "male",
+	"غلام": "male", "غلامرضا": "male", "غلامحسین": "male", "محمدعلی": "male", "محمدرضا": "male",
+	"امیر": "male", "مجید": "male", "مسعود": "male", "محمود": "male", "مصطفی": "male",
+	"مرتضی": "male", "مجتبی": "male", "صادق": "male", "کاظم": "male", "جواد": "male",
+	"سعید": "male", "حمید": "male", "رسول": "male", "کریم": "male", "رحیم": "male",
+	"نادر": "male", "بهرام": "male", "داریوش": "male", "کوروش": "male", "فرهاد": "male",
+	"بهروز": "male", "جمشید": "male", "سیاوش": "male", "رستم": "male", "ناصر": "male",
+	"منصور": "male", "یعقوب": "male", "موسی": "male", "عیسی": "male", "هادی": "male",
+	"باقر": "male", "تقی": "male", "نقی": "male", "هاشم": "male", "قاسم": "male",
+	"اسدالله": "male", "نصرالله": "male", "حبیب": "male", "ایرج": "male", "آرش": "male",
+	// Female
+	"فاطمه": "female", "زهرا": "female", "مریم": "female", "زینب": "female", "معصومه": "female",
+	"سکینه": "female", "خدیجه": "female", "رقیه": "female", "طاهره": "female", "صدیقه": "female",
+	"منیژه": "female", "مهین": "female", "پروین": "female", "شهلا": "female", "ژاله": "female",
+	"لیلا": "female", "نرگس": "female", "سارا": "female", "مینا": "female", "نسرین": "female",
+	"فرشته": "female", "اعظم": "female", "اشرف": "female", "عفت": "female", "عصمت": "female",
+	"شیرین": "female", "پری": "female", "ملیحه": "female", "مهناز": "female", "فریبا": "female",
+	"الهام": "female", "نازنین": "female", "سمیه": "female", "راضیه": "female", "مرضیه": "female",
+	"منیره": "female", "بتول": "female", "کبری": "female", "صغری": "female", "آمنه": "female",
+	"هاجر": "female", "گلی": "female", "گلناز": "female", "ناهید": "female", "سیمین": "female",
+}
+
+// BuildGenderDictionary merges overrides (name -> "male"/"female") over the built-in
+// dictionary, keyed by normalized name for lookup with InferGenderFromName
+func BuildGenderDictionary(overrides map[string]string) map[string]string {
+	dict := make(map[string]string, len(persianNameGenders)+len(overrides))
+	for name, gender := range persianNameGenders {
+		dict[NormalizePersianName(name)] = gender
+	}
+	for name, gender := range overrides {
+		dict[NormalizePersianName(name)] = gender
+	}
+	return dict
+}
+
+// InferGenderFromName guesses a gender from the first part of a name using a dictionary
+// built by BuildGenderDictionary. Returns "" when the first name is unknown.
+func InferGenderFromName(name string, dict map[string]string) string {
+	parts := strings.Fields(name)
+	if len(parts) == 0 {
+		return ""
+	}
+
+	// Try compound first names ("محمد علی") before the first part alone
+	candidates := []string{parts[0]}
+	if len(parts) > 1 {
+		candidates = []string{parts[0] + parts[1], parts[0]}
+	}
+
+	for _, candidate := range candidates {
+		if gender, ok := dict[NormalizePersianName(candidate)]; ok {
+			return gender
+		}
+	}
+	return ""
+}
@@ -50,6 +50,25 @@ func FetchInstagramProfile(username string) (*InstagramProfile, error) {
 	return profile, nil
 }
 
+// instagramProfileCache remembers recent profile fetches
+var instagramProfileCache = NewTTLCache(15 * time.Minute)
+
+// FetchInstagramProfileCached is FetchInstagramProfile backed by a short-lived cache,
+// for background enrichment where slightly stale data is fine
+func FetchInstagramProfileCached(username string) (*InstagramProfile, error) {
+	key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(username, "@")))
+	if cached, ok := instagramProfileCache.Get(key); ok {
+		return cached.(*InstagramProfile), nil
+	}
+
+	profile, err := FetchInstagramProfile(username)
+	if err != nil {
+		return nil, err
+	}
+	instagramProfileCache.Set(key, profile)
+	return profile, nil
+}
+
 // GetInstagramAvatarProxy returns a proxy URL that fetches Instagram profile pictures
 // Tries multiple proxy services for reliability
 func GetInstagramAvatarProxy(username string) string {
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// Mailer sends plain-text email
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// smtpMailer sends mail through an SMTP relay
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// Send delivers a plain-text message to a single recipient
+func (m *smtpMailer) Send(to, subject, body string) error {
+	msg := strings.Join([]string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
+
+// NewMailerFromEnv returns an SMTP mailer configured from SMTP_HOST, SMTP_PORT (default 587),
+// SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM, or nil when SMTP_HOST or SMTP_FROM is unset
+func NewMailerFromEnv() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	from := os.Getenv("SMTP_FROM")
+	if host == "" || from == "" {
+		return nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return &smtpMailer{addr: host + ":" + port, auth: auth, from: from}
+}
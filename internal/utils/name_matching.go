@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"unicode"
@@ -72,16 +73,22 @@ func NormalizePersianName(name string) string {
 		} else if unicode.Is(unicode.Mn, r) {
 			// Skip diacritical marks (تشدید، فتحه، کسره، ضمه، etc.)
 			continue
-		} else if !unicode.IsSpace(r) {
+		} else if !isNameSeparator(r) {
 			// Keep non-space characters
 			normalized.WriteRune(r)
 		}
-		// Skip spaces to normalize "محمد علی" to "محمدعلی"
+		// Skip spaces and ZWNJ to normalize "محمد علی" and "محمد‌علی" to "محمدعلی"
 	}
 
 	return normalized.String()
 }
 
+// isNameSeparator reports whether r only separates name parts: whitespace or a
+// zero-width (non-)joiner, which Persian uses in place of a space
+func isNameSeparator(r rune) bool {
+	return unicode.IsSpace(r) || r == '\u200c' || r == '\u200d'
+}
+
 // PersianPhoneticHash creates a phonetic hash of a Persian name
 // This maps similar-sounding letters to the same character, so:
 // ذکی, زکی, ضکی all become the same hash
@@ -102,8 +109,8 @@ func PersianPhoneticHash(name string) string {
 			continue
 		}
 
-		// Skip spaces
-		if unicode.IsSpace(r) {
+		// Skip spaces and ZWNJ
+		if isNameSeparator(r) {
 			continue
 		}
 
@@ -134,6 +141,32 @@ func NormalizePersianNameKeepSpaces(name string) string {
 	return strings.TrimSpace(normalized.String())
 }
 
+// persianDisplayCharMap is the subset of persianCharMap that is safe to apply to stored names:
+// it only swaps Arabic code points for the Persian letters they render as, so the display form
+// is unchanged (unlike آ -> ا or ة -> ه, which alter the spelling)
+var persianDisplayCharMap = map[rune]rune{
+	'ك': 'ک', // Arabic kaf to Persian kaf
+	'ي': 'ی', // Arabic ya to Persian ya
+	'ى': 'ی', // Arabic alef maksura to ya
+}
+
+// NormalizePersianCharacters cleans a name for storage: Arabic kaf/ya become their Persian
+// forms, Arabic diacritics and tatweel are dropped and runs of whitespace collapse to one
+// space. Case and Latin text are left as-is. Idempotent.
+func NormalizePersianCharacters(name string) string {
+	var normalized strings.Builder
+	for _, r := range name {
+		if mapped, ok := persianDisplayCharMap[r]; ok {
+			normalized.WriteRune(mapped)
+		} else if r == 'ـ' || (unicode.Is(unicode.Mn, r) && r >= 0x0600 && r <= 0x06FF) {
+			continue
+		} else {
+			normalized.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(normalized.String()), " ")
+}
+
 // LevenshteinDistance calculates the edit distance between two strings
 func LevenshteinDistance(s1, s2 string) int {
 	r1 := []rune(s1)
@@ -303,6 +336,57 @@ func FindSimilarNames(targetName string, existingNames map[string]string, thresh
 	return results
 }
 
+// FindSimilarNamesWithAliases works like FindSimilarNames but each person may be known by
+// several names (primary name plus alternates). The best-scoring name per person is returned.
+func FindSimilarNamesWithAliases(targetName string, existingNames map[string][]string, threshold float64) []NameMatchResult {
+	var results []NameMatchResult
+
+	for personID, names := range existingNames {
+		var best *NameMatchResult
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			matches := FindSimilarNames(targetName, map[string]string{personID: name}, threshold)
+			if len(matches) > 0 && (best == nil || matches[0].Similarity > best.Similarity) {
+				best = &matches[0]
+			}
+		}
+		if best != nil {
+			results = append(results, *best)
+		}
+	}
+
+	// Sort by similarity (highest first)
+	for i := 0; i < len(results)-1; i++ {
+		for j := i + 1; j < len(results); j++ {
+			if results[j].Similarity > results[i].Similarity {
+				results[i], results[j] = results[j], results[i]
+			}
+		}
+	}
+
+	return results
+}
+
+// CleanAltNames trims alternate names, drops duplicates and rejects empty entries
+func CleanAltNames(names []string) ([]string, error) {
+	cleaned := make([]string, 0, len(names))
+	seen := make(map[string]bool)
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("alt_names[%d] must be a non-empty string", i)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		cleaned = append(cleaned, name)
+	}
+	return cleaned, nil
+}
+
 // calculatePhoneticSimilarity calculates similarity between two phonetic hashes
 func calculatePhoneticSimilarity(hash1, hash2 string) float64 {
 	if hash1 == hash2 {
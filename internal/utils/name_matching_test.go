@@ -0,0 +1,58 @@
+package utils
+
+import "testing"
+
+func TestNormalizePersianNameSpaceVariants(t *testing.T) {
+	want := NormalizePersianName("محمدعلی")
+	variants := []string{
+		"محمد علی",       // Space
+		"محمد\u200cعلی",  // Zero-width non-joiner
+		"محمد \u200cعلی", // Space and ZWNJ
+		"محمد\u00a0علی",  // No-break space
+		"محمد  علی",      // Double space
+		" محمدعلی ",      // Surrounding spaces
+		"محمّد علي",      // Tashdid and Arabic ya
+	}
+	for _, v := range variants {
+		if got := NormalizePersianName(v); got != want {
+			t.Errorf("NormalizePersianName(%q) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestPersianPhoneticHashIgnoresZWNJ(t *testing.T) {
+	if PersianPhoneticHash("عبد\u200cالله") != PersianPhoneticHash("عبدالله") {
+		t.Error("ZWNJ changed the phonetic hash")
+	}
+	if PersianPhoneticHash("ذکی") != PersianPhoneticHash("زکی") {
+		t.Error("ذ and ز should share a phonetic hash")
+	}
+}
+
+func TestCalculateNameSimilaritySpaceVariants(t *testing.T) {
+	pairs := [][2]string{
+		{"محمد علی", "محمدعلی"},
+		{"محمد\u200cعلی", "محمدعلی"},
+		{"عبد الله", "عبد\u200cالله"},
+	}
+	for _, p := range pairs {
+		if got := CalculateNameSimilarity(p[0], p[1]); got != 1.0 {
+			t.Errorf("CalculateNameSimilarity(%q, %q) = %v, want 1", p[0], p[1], got)
+		}
+	}
+}
+
+func TestFindSimilarNamesMatchesZWNJVariant(t *testing.T) {
+	existing := map[string]string{"p1": "محمدعلی", "p2": "حسن"}
+	matches := FindSimilarNames("محمد\u200cعلی", existing, 0.9)
+	if len(matches) != 1 || matches[0].PersonID != "p1" {
+		t.Fatalf("matches = %+v, want only p1", matches)
+	}
+}
+
+func TestNormalizePersianCharactersKeepsZWNJ(t *testing.T) {
+	// ZWNJ is part of correct spelling, so the stored form keeps it
+	if got := NormalizePersianCharacters("عبد\u200cالله  كريم"); got != "عبد\u200cالله کریم" {
+		t.Errorf("NormalizePersianCharacters = %q", got)
+	}
+}
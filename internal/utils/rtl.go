@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// arabicForms maps a letter to its presentation forms: isolated, final, initial, medial.
+// Right-joining letters only have isolated and final forms.
+var arabicForms = func() map[rune][]rune {
+	forms := make(map[rune][]rune)
+
+	// Arabic Presentation Forms-B are laid out sequentially in letter order
+	counts := []struct {
+		letter rune
+		n      int
+	}{
+		{0x0621, 1}, {0x0622, 2}, {0x0623, 2}, {0x0624, 2}, {0x0625, 2}, {0x0626, 4},
+		{0x0627, 2}, {0x0628, 4}, {0x0629, 2}, {0x062A, 4}, {0x062B, 4}, {0x062C, 4},
+		{0x062D, 4}, {0x062E, 4}, {0x062F, 2}, {0x0630, 2}, {0x0631, 2}, {0x0632, 2},
+		{0x0633, 4}, {0x0634, 4}, {0x0635, 4}, {0x0636, 4}, {0x0637, 4}, {0x0638, 4},
+		{0x0639, 4}, {0x063A, 4}, {0x0641, 4}, {0x0642, 4}, {0x0643, 4}, {0x0644, 4},
+		{0x0645, 4}, {0x0646, 4}, {0x0647, 4}, {0x0648, 2}, {0x0649, 2}, {0x064A, 4},
+	}
+	next := rune(0xFE80)
+	for _, c := range counts {
+		f := make([]rune, c.n)
+		for i := range f {
+			f[i] = next
+			next++
+		}
+		forms[c.letter] = f
+	}
+
+	// Persian letters live in Presentation Forms-A
+	forms[0x067E] = []rune{0xFB56, 0xFB57, 0xFB58, 0xFB59} // پ
+	forms[0x0686] = []rune{0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D} // چ
+	forms[0x0698] = []rune{0xFB8A, 0xFB8B}                 // ژ
+	forms[0x06A9] = []rune{0xFB8E, 0xFB8F, 0xFB90, 0xFB91} // ک
+	forms[0x06AF] = []rune{0xFB92, 0xFB93, 0xFB94, 0xFB95} // گ
+	forms[0x06CC] = []rune{0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF} // ی
+	return forms
+}()
+
+// isTransparent reports whether r is a combining mark that does not affect joining
+func isTransparent(r rune) bool {
+	return (r >= 0x064B && r <= 0x065F) || r == 0x0670
+}
+
+// joinsBothSides reports whether r is a dual-joining letter
+func joinsBothSides(r rune) bool {
+	return len(arabicForms[r]) == 4
+}
+
+// ShapeArabic replaces Arabic/Persian letters with their contextual presentation forms,
+// for renderers (such as PDF writers) that draw glyphs without shaping. Order is unchanged.
+func ShapeArabic(s string) string {
+	runes := []rune(s)
+	out := make([]rune, len(runes))
+
+	neighbour := func(i, step int) rune {
+		for j := i + step; j >= 0 && j < len(runes); j += step {
+			if !isTransparent(runes[j]) {
+				return runes[j]
+			}
+		}
+		return 0
+	}
+
+	for i, r := range runes {
+		forms, ok := arabicForms[r]
+		if !ok {
+			out[i] = r
+			continue
+		}
+		prev, next := neighbour(i, -1), neighbour(i, 1)
+		joinPrev := joinsBothSides(prev) && len(forms) > 1
+		joinNext := len(forms) == 4 && len(arabicForms[next]) > 1
+
+		switch {
+		case joinPrev && joinNext:
+			out[i] = forms[3]
+		case joinPrev:
+			out[i] = forms[1]
+		case joinNext:
+			out[i] = forms[2]
+		default:
+			out[i] = forms[0]
+		}
+	}
+	return string(out)
+}
+
+// isLTRRune reports whether r belongs to a left-to-right run (Latin letters and digits)
+func isLTRRune(r rune) bool {
+	return r < 0x0590 && (unicode.IsLetter(r) || unicode.IsDigit(r)) || (r >= 0x06F0 && r <= 0x06F9) || (r >= 0x0660 && r <= 0x0669)
+}
+
+// mirrorRune swaps paired punctuation for right-to-left display
+func mirrorRune(r rune) rune {
+	switch r {
+	case '(':
+		return ')'
+	case ')':
+		return '('
+	case '[':
+		return ']'
+	case ']':
+		return '['
+	case '<':
+		return '>'
+	case '>':
+		return '<'
+	}
+	return r
+}
+
+// VisualRTL shapes a right-to-left line and reorders it for left-to-right drawing:
+// the line is reversed while runs of Latin letters and digits keep their reading order.
+// Lines without Persian/Arabic characters are returned unchanged.
+func VisualRTL(s string) string {
+	if !ContainsPersianCharacters(s) {
+		return s
+	}
+	runes := []rune(ShapeArabic(s))
+
+	// Split into runs, treating separators between LTR characters as part of the LTR run
+	type run struct {
+		text []rune
+		ltr  bool
+	}
+	var runs []run
+	for i := 0; i < len(runes); {
+		if isLTRRune(runes[i]) {
+			j := i + 1
+			for j < len(runes) {
+				if isLTRRune(runes[j]) {
+					j++
+					continue
+				}
+				if strings.ContainsRune(" -/.:,", runes[j]) && j+1 < len(runes) && isLTRRune(runes[j+1]) {
+					j += 2
+					continue
+				}
+				break
+			}
+			runs = append(runs, run{text: runes[i:j], ltr: true})
+			i = j
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && !isLTRRune(runes[j]) {
+			j++
+		}
+		runs = append(runs, run{text: runes[i:j]})
+		i = j
+	}
+
+	var b strings.Builder
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].ltr {
+			b.WriteString(string(runs[i].text))
+			continue
+		}
+		for k := len(runs[i].text) - 1; k >= 0; k-- {
+			b.WriteRune(mirrorRune(runs[i].text[k]))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	scriptBlockPattern = regexp.MustCompile(`(?is)<(script|style|iframe|object|embed)\b.*?(</(script|style|iframe|object|embed)\s*>|$)`)
+	tagPattern         = regexp.MustCompile(`(?s)<[/!?]?[a-zA-Z][^>]*(>|$)|<!--.*?(-->|$)`)
+)
+
+// ErrMarkupNotAllowed is returned by SanitizeText in reject mode when the text contains markup
+var ErrMarkupNotAllowed = errors.New("markup is not allowed")
+
+// SanitizeRejects reports whether free text containing markup is rejected instead of stripped
+// (TEXT_SANITIZE_MODE=reject; the default "strip" removes tags)
+var SanitizeRejects = strings.EqualFold(os.Getenv("TEXT_SANITIZE_MODE"), "reject")
+
+// ContainsMarkup reports whether s contains anything that looks like an HTML tag or comment.
+// A lone "<" as in "a < b" is not markup.
+func ContainsMarkup(s string) bool {
+	return tagPattern.MatchString(s)
+}
+
+// StripMarkup removes script/style blocks with their content, then any remaining tags.
+// Stripping repeats until no markup is left, since removing an inner tag can join the
+// text around it into a new one, as in "<<b>img onerror=...>".
+func StripMarkup(s string) string {
+	for {
+		stripped := scriptBlockPattern.ReplaceAllString(s, "")
+		stripped = tagPattern.ReplaceAllString(stripped, "")
+		if stripped == s || !ContainsMarkup(stripped) {
+			return strings.TrimSpace(stripped)
+		}
+		s = stripped
+	}
+}
+
+// SanitizeText cleans user-supplied free text before it is stored,
+// stripping markup or returning ErrMarkupNotAllowed depending on TEXT_SANITIZE_MODE
+func SanitizeText(s string) (string, error) {
+	if !ContainsMarkup(s) {
+		return s, nil
+	}
+	if SanitizeRejects {
+		return s, ErrMarkupNotAllowed
+	}
+	return StripMarkup(s), nil
+}
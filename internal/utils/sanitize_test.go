@@ -0,0 +1,27 @@
+package utils
+
+import "testing"
+
+func TestStripMarkup(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"a < b and c > d", "a < b and c > d"},
+		{"<b>bold</b> name", "bold name"},
+		{"hi<script>alert(1)</script>", "hi"},
+		{"<<b>img src=x onerror=alert(1)>", ""},
+		{"<<!-- -->svg onload=alert(1)>", ""},
+		{"<scr<script>x</script>ipt>alert(1)</script>", "alert(1)"},
+		{"<<<b>b>i>mg src=x>text", "mg src=x>text"},
+	}
+	for _, tt := range tests {
+		got := StripMarkup(tt.in)
+		if got != tt.want {
+			t.Errorf("StripMarkup(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if ContainsMarkup(got) {
+			t.Errorf("StripMarkup(%q) left markup: %q", tt.in, got)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"cloud.google.com/go/firestore"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"github.com/mamiri/findyourroot/internal/config"
 	"github.com/mamiri/findyourroot/internal/database"
 	"github.com/mamiri/findyourroot/internal/models"
 	"golang.org/x/crypto/bcrypt"
@@ -49,7 +50,11 @@ func main() {
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	authConfig, err := config.LoadAuth()
+	if err != nil {
+		log.Fatalf("Invalid auth configuration: %v", err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), authConfig.BcryptCost)
 	if err != nil {
 		log.Fatalf("Failed to hash password: %v", err)
 	}